@@ -7,8 +7,14 @@ import (
 	"github.com/banking-app/account-service/src/gateway"
 	"github.com/banking-app/account-service/src/handler"
 	"github.com/banking-app/account-service/src/server"
+	authService "github.com/banking-app/account-service/src/service/auth"
 	bankingService "github.com/banking-app/account-service/src/service/banking"
+	exchangeService "github.com/banking-app/account-service/src/service/exchange"
 	kafkaService "github.com/banking-app/account-service/src/service/kafka"
+	reconcileService "github.com/banking-app/account-service/src/service/reconcile"
+	relayService "github.com/banking-app/account-service/src/service/relay"
+	schedulerService "github.com/banking-app/account-service/src/service/scheduler"
+	statementService "github.com/banking-app/account-service/src/service/statement"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/fx"
@@ -27,8 +33,14 @@ func main() {
 		// Provide all the constructors
 		fx.Provide(
 			config.LoadFromFile,
+			exchangeService.NewFileRateProvider,
 			bankingService.NewService,
+			authService.NewAuthService,
 			kafkaService.NewKafkaService,
+			relayService.NewRelay,
+			schedulerService.NewScheduler,
+			reconcileService.NewReconciler,
+			statementService.NewStatements,
 			gateway.NewGateway,
 			handler.NewHandler,
 			server.NewGinServer,
@@ -36,7 +48,9 @@ func main() {
 		// Invoke runs the application
 		fx.Invoke(
 			server.RunServer,
-			kafkaService.StartKafkaScan,
+			relayService.StartRelay,
+			schedulerService.StartScheduler,
+			reconcileService.StartReconciler,
 		),
 	)
 
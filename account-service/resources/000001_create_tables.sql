@@ -12,6 +12,7 @@ CREATE TABLE IF NOT EXISTS accounts (
 );
 
 --transactions.sql
+-- every transaction posted to an account, kept as the service's own record
 create table IF NOT EXISTS transactions (
     id UUID primary key,
     account UUID not null,
@@ -21,6 +22,67 @@ create table IF NOT EXISTS transactions (
     foreign key (account) references accounts(id)
 );
 
+-- links the debit and credit legs of a transfer
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id UUID;
+
+-- every account and transaction is denominated in a single currency
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+-- exchange rate applied to a cross-currency transfer leg
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(20,10);
+
+-- the transaction a refund or reversal compensates; a transaction can only be
+-- reversed once
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of UUID;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions(reversal_of) WHERE reversal_of IS NOT NULL;
+-- free text the customer gave the payment, from a hold or standing order
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reference VARCHAR(140);
+
+--outbox.sql
+-- events waiting to be published to kafka, written in the same database
+-- transaction as the balance change they describe
+DO $$
+BEGIN
+    IF to_regclass('outbox') IS NULL THEN
+        CREATE TABLE outbox (
+            id UUID PRIMARY KEY,
+            seq BIGSERIAL NOT NULL,
+            account UUID,
+            payload JSONB NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+        );
+
+        -- before the outbox existed, transactions only held events whose
+        -- publish had failed, so move any that are still waiting
+        INSERT INTO outbox (id, account, payload, created_at)
+        SELECT id, account, jsonb_strip_nulls(jsonb_build_object(
+                'id', id,
+                'account', account,
+                'amount', jsonb_build_object('value', amount::text, 'currency', currency),
+                'type', type,
+                'transferId', transfer_id,
+                'fxRate', fx_rate::text,
+                'timestamp', to_char(timestamp, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'))),
+            timestamp
+        FROM transactions
+        ORDER BY timestamp;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_seq ON outbox(seq);
+
+-- published events are kept and marked rather than deleted
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS sent_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent ON outbox(seq) WHERE sent_at IS NULL;
+
+-- the outbox carries several kinds of event, each published to its own topic
+-- and keyed by what it is about
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'transaction';
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS event_key VARCHAR(100);
+
+-- the relay looks up the unsent events queued before each one under its key
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent_key ON outbox ((COALESCE(event_key, account::text, id::text)), seq) WHERE sent_at IS NULL;
+
 --users.sql
 create table IF NOT EXISTS users (
     first_name varchar(50) not null,
@@ -32,17 +94,345 @@ create table IF NOT EXISTS users (
     updated_at timestamp not null
 );  
 
+-- users move between pending, active, disabled and locked; users who
+-- existed before statuses did are active
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_logins INTEGER NOT NULL DEFAULT 0;
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'valid_user_status'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT valid_user_status CHECK (status IN ('pending', 'active', 'disabled', 'locked'));
+    END IF;
+END $$;
+
+--account_owners.sql
+-- the users who may act on an account and in what role
+DO $$
+BEGIN
+    IF to_regclass('account_owners') IS NULL THEN
+        CREATE TABLE account_owners (
+            account_id UUID NOT NULL REFERENCES accounts(id),
+            user_email VARCHAR(100) NOT NULL REFERENCES users(email),
+            role VARCHAR(20) NOT NULL CHECK (role IN ('primary', 'joint', 'signatory')),
+            created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (account_id, user_email)
+        );
+
+        -- accounts used to belong to the user with the same email
+        INSERT INTO account_owners (account_id, user_email, role)
+        SELECT a.id, u.email, 'primary'
+        FROM accounts a JOIN users u ON u.email = a.email;
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_owners_primary ON account_owners(account_id) WHERE role = 'primary';
+CREATE INDEX IF NOT EXISTS idx_account_owners_user ON account_owners(user_email);
+
+-- a user may now hold several accounts, so the email is only a contact
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_email_key;
+
+-- how far below zero an account's balance may go
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0.00;
+
+-- the balance less what pending holds reserve, which is what may be spent
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS available_balance DECIMAL(15,2);
+UPDATE accounts SET available_balance = balance WHERE available_balance IS NULL;
+ALTER TABLE accounts ALTER COLUMN available_balance SET NOT NULL;
+
+-- when and why an account was closed
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closure_reason VARCHAR(255);
+
+--account_status_history.sql
+-- every change of an account's status, who made it and why
+CREATE TABLE IF NOT EXISTS account_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    from_status VARCHAR(10) NOT NULL,
+    to_status VARCHAR(10) NOT NULL,
+    reason VARCHAR(255),
+    actor VARCHAR(100) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_account_status_history_account ON account_status_history(account_id, id);
+
+-- finds accounts with no recent transactions, to mark them inactive
+CREATE INDEX IF NOT EXISTS idx_transactions_account_timestamp ON transactions(account, timestamp);
+
+--interest.sql
+-- interest earned by an account each day, from its balance at the end of
+-- the day, kept to fractions of a cent until the month is posted
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    accrual_date DATE NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    rate NUMERIC(10,6) NOT NULL,
+    amount NUMERIC(20,10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, accrual_date)
+);
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_date ON interest_accruals(accrual_date);
+
+-- the interest paid to an account for a month, at most once per month;
+-- transaction_id is empty when nothing could be paid
+CREATE TABLE IF NOT EXISTS interest_postings (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    period DATE NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    transaction_id UUID,
+    posted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, period)
+);
+
+--standing_orders.sql
+-- transfers users have scheduled to run once or on a schedule
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id UUID PRIMARY KEY,
+    from_account UUID NOT NULL REFERENCES accounts(id),
+    to_account UUID NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('once', 'daily', 'weekly', 'monthly')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    max_occurrences INTEGER,
+    occurrences INTEGER NOT NULL DEFAULT 0,
+    next_run_date DATE NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled', 'failed')),
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    retry_at TIMESTAMP WITH TIME ZONE,
+    last_error VARCHAR(255),
+    reference VARCHAR(140),
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_standing_orders_from_account ON standing_orders(from_account);
+CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders(next_run_date) WHERE status = 'active';
+
+--idempotency_keys.sql
+-- the result of each money-moving request made with an Idempotency-Key,
+-- written in the database transaction that posts it; response holds the
+-- transactions posted and is replayed when the key is used again
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_email VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_email, idempotency_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+--account_limits.sql
+-- limits set on a single account, each overriding its account type's;
+-- a null column leaves the account type's limit in place
+CREATE TABLE IF NOT EXISTS account_limits (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id),
+    daily_withdrawal DECIMAL(15,2) CHECK (daily_withdrawal >= 0),
+    monthly_withdrawal DECIMAL(15,2) CHECK (monthly_withdrawal >= 0),
+    withdrawals_per_hour INTEGER CHECK (withdrawals_per_hour >= 0),
+    daily_deposit DECIMAL(15,2) CHECK (daily_deposit >= 0),
+    monthly_deposit DECIMAL(15,2) CHECK (monthly_deposit >= 0),
+    deposits_per_hour INTEGER CHECK (deposits_per_hour >= 0),
+    updated_by VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+--risk_reviews.sql
+-- deposits and withdrawals the risk rules held for review or blocked
+CREATE TABLE IF NOT EXISTS risk_reviews (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('deposit', 'withdraw')),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    rule VARCHAR(50) NOT NULL,
+    reason VARCHAR(255),
+    status VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected', 'blocked')),
+    requested_by VARCHAR(100) NOT NULL,
+    transaction_id UUID,
+    decided_by VARCHAR(100),
+    decision_reason VARCHAR(255),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_risk_reviews_status ON risk_reviews(status, created_at);
+
+--holds.sql
+-- funds reserved for authorised payments until they are captured, released
+-- or expire
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    captured_amount DECIMAL(15,2),
+    transaction_id UUID,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'captured', 'released', 'expired')),
+    reference VARCHAR(140),
+    created_by VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_holds_account ON holds(account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_holds_expiry ON holds(expires_at) WHERE status = 'pending';
+
+--ledger.sql
+-- every movement of money as a journal entry of postings that sum to zero in
+-- each currency. Customer postings name their account; the others are the
+-- bank's suspense, fee_income, interest_expense and transfer_clearing
+-- accounts. accounts.balance is the sum of an account's postings.
+DO $$
+BEGIN
+    IF to_regclass('journal_entries') IS NULL THEN
+        CREATE TABLE journal_entries (
+            id UUID PRIMARY KEY,
+            transaction_id UUID UNIQUE REFERENCES transactions(id),
+            kind VARCHAR(20) NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+        );
+        CREATE TABLE postings (
+            id BIGSERIAL PRIMARY KEY,
+            journal_id UUID NOT NULL REFERENCES journal_entries(id),
+            ledger VARCHAR(20) NOT NULL,
+            account_id UUID REFERENCES accounts(id),
+            amount DECIMAL(15,2) NOT NULL,
+            currency VARCHAR(3) NOT NULL,
+            CHECK ((ledger = 'customer') = (account_id IS NOT NULL))
+        );
+
+        -- post the transactions recorded before the ledger existed, each
+        -- as its own entry sharing its id
+        INSERT INTO journal_entries (id, transaction_id, kind, created_at)
+        SELECT id, id, type, timestamp FROM transactions;
+
+        INSERT INTO postings (journal_id, ledger, account_id, amount, currency)
+        SELECT id, 'customer', account,
+            CASE WHEN type IN ('debit', 'closing', 'fee', 'reversal') THEN -amount ELSE amount END, currency
+        FROM transactions;
+
+        INSERT INTO postings (journal_id, ledger, account_id, amount, currency)
+        SELECT t.id,
+            CASE
+                WHEN t.transfer_id IS NOT NULL THEN 'transfer_clearing'
+                WHEN COALESCE(o.type, t.type) = 'fee' THEN 'fee_income'
+                WHEN COALESCE(o.type, t.type) = 'interest' THEN 'interest_expense'
+                ELSE 'suspense'
+            END,
+            NULL,
+            CASE WHEN t.type IN ('debit', 'closing', 'fee', 'reversal') THEN t.amount ELSE -t.amount END, t.currency
+        FROM transactions t
+        LEFT JOIN transactions o ON o.id = t.reversal_of;
+
+        -- balances that were edited in place are brought into the ledger
+        -- with an adjustment for the difference
+        CREATE TEMPORARY TABLE ledger_adjustments ON COMMIT DROP AS
+        SELECT md5('ledger-adjustment:' || a.id::text)::uuid AS id, a.id AS account_id,
+            a.balance - COALESCE(SUM(p.amount), 0) AS amount, a.currency
+        FROM accounts a
+        LEFT JOIN postings p ON p.account_id = a.id
+        GROUP BY a.id
+        HAVING a.balance <> COALESCE(SUM(p.amount), 0);
+
+        INSERT INTO journal_entries (id, kind) SELECT id, 'adjustment' FROM ledger_adjustments;
+        INSERT INTO postings (journal_id, ledger, account_id, amount, currency)
+        SELECT id, 'customer', account_id, amount, currency FROM ledger_adjustments
+        UNION ALL
+        SELECT id, 'suspense', NULL, -amount, currency FROM ledger_adjustments;
+    END IF;
+END $$;
+CREATE INDEX IF NOT EXISTS idx_postings_journal ON postings(journal_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account ON postings(account_id) WHERE account_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_postings_ledger ON postings(ledger, currency) WHERE account_id IS NULL;
+
+--running_balances.sql
+-- each account's transactions are numbered in the order they were posted and
+-- carry the balance after them. Existing transactions are numbered oldest
+-- first, with balances from the postings made up to them.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'accounts' AND column_name = 'transaction_seq') THEN
+        ALTER TABLE accounts ADD COLUMN transaction_seq BIGINT NOT NULL DEFAULT 0;
+        ALTER TABLE transactions ADD COLUMN seq BIGINT;
+        ALTER TABLE transactions ADD COLUMN balance_after DECIMAL(15,2);
+
+        WITH running AS (
+            SELECT j.transaction_id, p.account_id, j.created_at, j.id,
+                SUM(p.amount) OVER (PARTITION BY p.account_id ORDER BY j.created_at, j.id) AS balance_after
+            FROM postings p
+            JOIN journal_entries j ON j.id = p.journal_id
+            WHERE p.account_id IS NOT NULL
+        ), numbered AS (
+            SELECT transaction_id, balance_after,
+                ROW_NUMBER() OVER (PARTITION BY account_id ORDER BY created_at, id) AS seq
+            FROM running
+            WHERE transaction_id IS NOT NULL
+        )
+        UPDATE transactions t SET seq = n.seq, balance_after = n.balance_after
+        FROM numbered n
+        WHERE t.id = n.transaction_id;
+
+        UPDATE accounts a SET transaction_seq = t.seq
+        FROM (SELECT account, MAX(seq) AS seq FROM transactions GROUP BY account) t
+        WHERE a.id = t.account;
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_account_seq ON transactions(account, seq);
+
+--statements.sql
+-- a statement is kept as it was first generated, so it downloads the same
+-- every time
+CREATE TABLE IF NOT EXISTS statements (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    month VARCHAR(7) NOT NULL,
+    statement JSONB NOT NULL,
+    csv BYTEA NOT NULL,
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, month)
+);
+
+--refresh_tokens.sql
+-- refresh tokens are stored as a sha-256 hash and revoked when used or on logout
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    email VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_email ON refresh_tokens(email);
+
 -- Create index if not exists
 CREATE INDEX IF NOT EXISTS idx_accounts_email ON accounts(email);
 
 -- Add constraints if they don't exist
 DO $$ 
 BEGIN 
-    -- Check and add balance_non_negative constraint
+    -- Balances may go below zero down to the account's overdraft limit,
+    -- which replaces the old balance_non_negative constraint
+    ALTER TABLE accounts DROP CONSTRAINT IF EXISTS balance_non_negative;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'balance_within_overdraft_limit'
+    ) THEN
+        ALTER TABLE accounts ADD CONSTRAINT balance_within_overdraft_limit CHECK (balance >= -overdraft_limit);
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'overdraft_limit_non_negative'
+    ) THEN
+        ALTER TABLE accounts ADD CONSTRAINT overdraft_limit_non_negative CHECK (overdraft_limit >= 0);
+    END IF;
+
+    -- holds may only reserve funds the account could spend
     IF NOT EXISTS (
-        SELECT 1 FROM pg_constraint WHERE conname = 'balance_non_negative'
+        SELECT 1 FROM pg_constraint WHERE conname = 'available_balance_within_overdraft_limit'
     ) THEN
-        ALTER TABLE accounts ADD CONSTRAINT balance_non_negative CHECK (balance >= 0);
+        ALTER TABLE accounts ADD CONSTRAINT available_balance_within_overdraft_limit CHECK (available_balance >= -overdraft_limit);
     END IF;
 
     -- Check and add valid_status constraint
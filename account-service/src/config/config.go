@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 
+	"github.com/banking-app/riskengine"
+
 	"go.uber.org/fx"
 	"gopkg.in/yaml.v2"
 )
@@ -13,6 +15,20 @@ type Config struct {
 	MongoDB  MongoDB  `yaml:"mongodb"`
 	Postgres Postgres `yaml:"postgres"`
 	Kafka    Kafka    `yaml:"kafka"`
+	Exchange Exchange `yaml:"exchange"`
+	Auth     Auth     `yaml:"auth"`
+	Accounts Accounts `yaml:"accounts"`
+	Interest Interest `yaml:"interest"`
+	StandingOrders StandingOrders `yaml:"standing_orders"`
+	Idempotency    Idempotency    `yaml:"idempotency"`
+	Holds          Holds          `yaml:"holds"`
+	Ledger         Ledger         `yaml:"ledger"`
+	Reconciliation Reconciliation `yaml:"reconciliation"`
+	// Limits maps an account type to the limits on accounts of that type.
+	// Types without an entry have none.
+	Limits map[string]Limits `yaml:"limits"`
+	// Risk lists the fraud rules run over deposits and withdrawals
+	Risk riskengine.Config `yaml:"risk"`
 }
 
 type Gateway struct {
@@ -45,6 +61,124 @@ type Kafka struct {
 	BatchTimeout int      `yaml:"batch_timeout"`
 	RequiredAcks int      `yaml:"required_acks"`
 	Async        bool     `yaml:"async"`
+	// AuditTopic receives audit events, such as user status changes
+	AuditTopic string `yaml:"audit_topic"`
+
+	// OutboxPollInterval is how often, in milliseconds, the relay polls the outbox
+	OutboxPollInterval int `yaml:"outbox_poll_interval"`
+	// OutboxBatchSize is the most outbox events the relay claims and publishes at once
+	OutboxBatchSize int `yaml:"outbox_batch_size"`
+}
+
+type Exchange struct {
+	RatesFile string `yaml:"rates_file"`
+}
+
+type Auth struct {
+	// JwtSecret signs access tokens. The JWT_SECRET environment variable
+	// takes precedence, so that the secret need not live in the file.
+	JwtSecret string `yaml:"jwt_secret"`
+	// AccessTokenTTL and RefreshTokenTTL are token lifetimes in minutes
+	AccessTokenTTL  int `yaml:"access_token_ttl"`
+	RefreshTokenTTL int `yaml:"refresh_token_ttl"`
+	// MaxFailedLogins is how many failed logins in a row lock a user
+	MaxFailedLogins int `yaml:"max_failed_logins"`
+	// Admins are the emails of users who may manage other users
+	Admins []string `yaml:"admins"`
+}
+
+type Accounts struct {
+	// InactiveAfterDays is how many days without a transaction make an active
+	// account inactive. 0 turns this off.
+	InactiveAfterDays int `yaml:"inactive_after_days"`
+	// DormancyCheckInterval is how often, in minutes, accounts are checked
+	DormancyCheckInterval int `yaml:"dormancy_check_interval"`
+	// OverdraftFee is charged, in the account's currency, for each debit
+	// that leaves a checking account overdrawn, e.g. "25.00"
+	OverdraftFee string `yaml:"overdraft_fee"`
+}
+
+type Interest struct {
+	// Rates maps an account type to its rate table. Types without one earn
+	// no interest.
+	Rates map[string][]InterestTier `yaml:"rates"`
+	// DayCount is the number of days a year's rate is spread over
+	DayCount int `yaml:"day_count"`
+	// CheckInterval is how often, in minutes, interest is accrued and posted
+	CheckInterval int `yaml:"check_interval"`
+}
+
+type StandingOrders struct {
+	// CheckInterval is how often, in seconds, due standing orders are paid
+	CheckInterval int `yaml:"check_interval"`
+	// BatchSize is the most standing orders paid in one check
+	BatchSize int `yaml:"batch_size"`
+	// RetryInterval is how long, in minutes, a failed payment waits before
+	// it is tried again
+	RetryInterval int `yaml:"retry_interval"`
+	// MaxRetries is how many times a failed payment is retried before the
+	// occurrence is missed
+	MaxRetries int `yaml:"max_retries"`
+}
+
+type Idempotency struct {
+	// KeyTTL is how long, in hours, an Idempotency-Key is remembered. 0
+	// keeps keys forever.
+	KeyTTL int `yaml:"key_ttl"`
+}
+
+type Holds struct {
+	// DefaultExpiry is how long, in hours, a hold lasts when no expiry is
+	// asked for, and MaxExpiry the longest that may be asked for
+	DefaultExpiry int `yaml:"default_expiry"`
+	MaxExpiry     int `yaml:"max_expiry"`
+	// CheckInterval is how often, in minutes, expired holds are released
+	CheckInterval int `yaml:"check_interval"`
+	// BatchSize is the most expired holds released in one check
+	BatchSize int `yaml:"batch_size"`
+}
+
+type Ledger struct {
+	// VerifyInterval is how often, in minutes, balances are checked against
+	// the ledger. 0 turns this off.
+	VerifyInterval int `yaml:"verify_interval"`
+}
+
+type Reconciliation struct {
+	// Interval is how often, in minutes, every account is reconciled
+	// against transaction-service's history. 0 turns this off.
+	Interval int `yaml:"interval"`
+	// BatchSize is how many accounts are read at a time
+	BatchSize int `yaml:"batch_size"`
+	// GracePeriod is how long, in seconds, a transaction may take to reach
+	// transaction-service before it is reported missing
+	GracePeriod int `yaml:"grace_period"`
+	// Republish queues missing transactions to be published again
+	Republish bool `yaml:"republish"`
+}
+
+type Limits struct {
+	// DailyWithdrawal and MonthlyWithdrawal cap what may be withdrawn or
+	// transferred out per calendar day and month, e.g. "1000.00"
+	DailyWithdrawal   string `yaml:"daily_withdrawal"`
+	MonthlyWithdrawal string `yaml:"monthly_withdrawal"`
+	// WithdrawalsPerHour caps how many withdrawals and transfers out may be
+	// made in any hour. 0 sets no cap.
+	WithdrawalsPerHour int `yaml:"withdrawals_per_hour"`
+	// DailyDeposit and MonthlyDeposit cap what may be deposited per calendar
+	// day and month
+	DailyDeposit   string `yaml:"daily_deposit"`
+	MonthlyDeposit string `yaml:"monthly_deposit"`
+	// DepositsPerHour caps how many deposits may be made in any hour. 0 sets
+	// no cap.
+	DepositsPerHour int `yaml:"deposits_per_hour"`
+}
+
+type InterestTier struct {
+	// MinBalance is the balance from which Rate applies, e.g. "10000.00"
+	MinBalance string `yaml:"min_balance"`
+	// Rate is the annual rate as a decimal fraction, e.g. "0.035"
+	Rate string `yaml:"rate"`
 }
 
 func LoadFromFile() (*Config, error) {
@@ -59,6 +193,10 @@ func LoadFromFile() (*Config, error) {
 		return nil, err
 	}
 
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		cfg.Auth.JwtSecret = secret
+	}
+
 	return &cfg, nil
 
 }
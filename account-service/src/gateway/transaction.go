@@ -2,9 +2,11 @@ package gateway
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/banking-app/account-service/src/config"
 	"github.com/banking-app/account-service/src/model"
@@ -19,8 +21,14 @@ type Gateway interface {
 	GetTransactionbyId(transactionId string) (model.Transaction, error)
 	GetTransactionsbyAccount(accountId string, count int) ([]model.Transaction, error)
 	GetTransactionsbyMonthRange(accountId string, startMonth string, endMonth string) ([]model.Transaction, error)
+	GetAccountSummary(accountId string) (*model.TransactionSummary, error)
+	SearchTransactions(query url.Values) (*model.TransactionPage, error)
 }
 
+// ErrInvalidSearch is returned when transaction-service refuses the filters
+// of a search
+var ErrInvalidSearch = errors.New("invalid transaction search")
+
 func NewGateway(config *config.Config) Gateway {
 	client := &http.Client{}
 	return &gateway{
@@ -114,6 +122,64 @@ func (g *gateway) GetTransactionsbyMonthRange(accountId string, startMonth strin
 	return transactions, nil
 }
 
+// GetAccountSummary gets what the stored history of an account adds up to
+func (g *gateway) GetAccountSummary(accountId string) (*model.TransactionSummary, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/summary/%s", g.config.TransactionBaseUrl, accountId), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := g.transactionClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get summary of account %s: %s", accountId, res.Status)
+	}
+
+	var summary model.TransactionSummary
+	if err := json.NewDecoder(res.Body).Decode(&summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// SearchTransactions gets a page of the transactions matching query, which is
+// passed on as it is, cursor included
+func (g *gateway) SearchTransactions(query url.Values) (*model.TransactionPage, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", g.config.TransactionBaseUrl, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := g.transactionClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil || body.Error == "" {
+			return nil, ErrInvalidSearch
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSearch, body.Error)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search transactions: %s", res.Status)
+	}
+
+	var page model.TransactionPage
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
 func (g *gateway) parseTransactions(body io.ReadCloser) ([]model.Transaction, error) {
 	var transactions []model.Transaction
 	decoder := json.NewDecoder(body)
@@ -126,10 +192,9 @@ func (g *gateway) parseTransactions(body io.ReadCloser) ([]model.Transaction, er
 	return transactions, nil
 }
 func (g *gateway) parseTransaction(body io.ReadCloser) (model.Transaction, error) {
-	var transactions model.Transaction
+	var transaction model.Transaction
 	decoder := json.NewDecoder(body)
 	for decoder.More() {
-		var transaction model.Transaction
 		err := decoder.Decode(&transaction)
 		if err != nil {
 			return model.Transaction{}, err
@@ -140,5 +205,5 @@ func (g *gateway) parseTransaction(body io.ReadCloser) (model.Transaction, error
 		}
 		
 	}
-	return transactions, nil
+	return transaction, nil
 }
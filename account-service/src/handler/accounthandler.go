@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	accountpb "github.com/banking-app/protos/generated/account"
-	"github.com/google/uuid"
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
 
 	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,21 +24,20 @@ func (h handler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	account := model.NewAccountFromProto(req)
-	err := h.BankingService.CreateAccount(account)
+	account, err := model.NewAccountFromProto(req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// publish opening balance
-	err = h.KafkaService.PublishTransaction(model.NewTransaction(account.ID, account.Balance, "opening"))
+	// the signed-in user becomes the primary owner, and their email is the
+	// contact unless another is given
+	if account.Email == "" {
+		account.Email = authenticatedUser(c)
+	}
+	err = h.BankingService.CreateAccount(account, authenticatedUser(c))
 	if err != nil {
-		err= h.BankingService.CreateTransaction(model.NewTransaction(account.ID, account.Balance, "opening"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Account %s created successfully", account.ID)})
@@ -44,9 +46,8 @@ func (h handler) CreateAccount(c *gin.Context) {
 // GetAccountbyId gets a account by id
 func (h handler) GetAccountbyId(c *gin.Context) {
 	accountId := c.Param("accountId")
-	account, err := h.BankingService.GetAccountbyId(accountId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	account, ok := h.authorizedAccount(c, accountId, model.OpView)
+	if !ok {
 		return
 	}
 	c.JSON(http.StatusOK, account)
@@ -59,23 +60,20 @@ func (h handler) UpdateAccount(c *gin.Context) {
 		return
 	}
 	// find if account exists
-	account, err := h.BankingService.GetAccountbyId(req.Id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if account == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+	account, ok := h.authorizedAccount(c, req.Id, model.OpUpdate)
+	if !ok {
 		return
 	}
 	// update account
 	account.FirstName = req.FirstName
 	account.LastName = req.LastName
-	account.Email = req.Email
+	if req.Email != "" {
+		account.Email = req.Email
+	}
 	account.Type = req.Type
 	account.Password = req.Password
 
-	err = h.BankingService.UpdateAccount(account)
+	err := h.BankingService.UpdateAccount(account)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -83,61 +81,154 @@ func (h handler) UpdateAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Account updated successfully"})
 }
 
-// disableAccount disables an account
+// disableAccount closes an account. An account with money left in it is
+// only closed if a payout account is given to receive it. Closed accounts
+// cannot be reopened.
 func (h handler) DisableAccount(c *gin.Context) {
 	accountId := c.Param("accountId")
+	req := &accountpb.CloseAccountRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
 	//  get account
-	account, err := h.BankingService.GetAccountbyId(accountId)
+	account, ok := h.authorizedAccount(c, accountId, model.OpChangeStatus)
+	if !ok {
+		return
+	}
+	closing, err := h.BankingService.CloseAccount(account.ID, req.PayoutAccount, authenticatedUser(c), req.Reason)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if account == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Account Disabled successfully",
+		"paidOut":   closing.Amount,
+		"closingId": closing.ID,
+	})
+}
+
+// activateAccount activates an inactive account. Frozen accounts are
+// unfrozen by an admin instead.
+func (h handler) ActivateAccount(c *gin.Context) {
+	accountId := c.Param("accountId")
+
+	//  get account
+	account, ok := h.authorizedAccount(c, accountId, model.OpChangeStatus)
+	if !ok {
+		return
+	}
+	if account.Status == model.AccountFrozen {
+		c.JSON(http.StatusForbidden, gin.H{"error": "frozen accounts can only be unfrozen by an admin"})
 		return
 	}
-	// update account
-	if account.Status == "closed" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account is already closed"})
+	err := h.BankingService.ChangeAccountStatus(account.ID, model.AccountActive, authenticatedUser(c), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	account.Status = "closed"
+	c.JSON(http.StatusOK, gin.H{"message": "Account Activated successfully"})
+}
 
-	err = h.BankingService.UpdateAccount(account)
+// FreezeAccount freezes an account with a reason code, blocking debits but
+// not credits. Admin only.
+func (h handler) FreezeAccount(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.FreezeAccountRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.CheckFreezeReason(req.Reason); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.BankingService.ChangeAccountStatus(accountId, model.AccountFrozen, authenticatedUser(c), req.Reason)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Account Disabled successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Account Frozen successfully"})
 }
 
-// activateAccount activates an account
-func (h handler) ActivateAccount(c *gin.Context) {
+// UnfreezeAccount makes a frozen account active again. Admin only.
+func (h handler) UnfreezeAccount(c *gin.Context) {
 	accountId := c.Param("accountId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.UnfreezeAccountRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
 
-	//  get account
 	account, err := h.BankingService.GetAccountbyId(accountId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if account.Status != model.AccountFrozen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is not frozen"})
+		return
+	}
+
+	err = h.BankingService.ChangeAccountStatus(accountId, model.AccountActive, authenticatedUser(c), req.Reason)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if account == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+	c.JSON(http.StatusOK, gin.H{"message": "Account Unfrozen successfully"})
+}
+
+// SetOverdraftLimit sets how far below zero an account's balance may go.
+// Admin only.
+func (h handler) SetOverdraftLimit(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.SetOverdraftLimitRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	// update account
-	if account.Status == "active" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account is already active"})
+	if req.OverdraftLimit == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "overdraft_limit is required"})
+		return
+	}
+	limit, err := money.FromProto(req.OverdraftLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	account.Status = "active"
 
-	err = h.BankingService.UpdateAccount(account)
+	err = h.BankingService.SetOverdraftLimit(accountId, limit, authenticatedUser(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Account Activated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Overdraft limit updated successfully"})
+}
+
+// GetAccountStatusHistory lists an account's status changes. Owners and
+// admins can see it.
+func (h handler) GetAccountStatusHistory(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if !h.AuthService.IsAdmin(authenticatedUser(c)) {
+		if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+			return
+		}
+	}
+
+	history, err := h.BankingService.GetAccountStatusHistory(accountId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
 }
 
 func (h handler) Deposit(c *gin.Context) {
@@ -148,35 +239,30 @@ func (h handler) Deposit(c *gin.Context) {
 		return
 	}
 	// find if account exists
-	account, err := h.BankingService.GetAccountbyId(req.Id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	account, ok := h.authorizedAccount(c, req.Id, model.OpDeposit)
+	if !ok {
 		return
 	}
-	if account == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+	amount, err := money.FromProto(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	// update account
-	account.Balance = account.Balance + req.Amount
 
-	err = h.BankingService.Deposit(account.ID, req.Amount)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	key, ok := idempotencyKey(c, model.OpDeposit, req)
+	if !ok {
 		return
 	}
 
-	// publish deposit
-	err = h.KafkaService.PublishTransaction(model.NewTransaction(account.ID, req.Amount, "credit"))
+	// update account
+	transaction, err := h.BankingService.Deposit(account.ID, amount, authenticatedUser(c), key)
+	replayed(c, key)
 	if err != nil {
-		err= h.BankingService.CreateTransaction(model.NewTransaction(account.ID, req.Amount, "credit"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+		postingFailed(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s has deposited %f", account.ID, req.Amount)})
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s has deposited %s", account.ID, transaction.Amount)})
 
 }
 
@@ -188,43 +274,123 @@ func (h handler) Withdraw(c *gin.Context) {
 		return
 	}
 	// find if account exists
-	account, err := h.BankingService.GetAccountbyId(req.Id)
+	account, ok := h.authorizedAccount(c, req.Id, model.OpWithdraw)
+	if !ok {
+		return
+	}
+	amount, err := money.FromProto(req.Amount)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if account == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+
+	key, ok := idempotencyKey(c, model.OpWithdraw, req)
+	if !ok {
 		return
 	}
-	// update account
-	account.Balance = account.Balance - req.Amount
 
-	err = h.BankingService.Withdraw(account.ID, req.Amount)
+	// update account
+	transaction, err := h.BankingService.Withdraw(account.ID, amount, authenticatedUser(c), key)
+	replayed(c, key)
 	if err != nil {
+		postingFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s has withdrawn %s", account.ID, transaction.Amount)})
+
+}
+
+// Transfer moves money between two accounts
+func (h handler) Transfer(c *gin.Context) {
+
+	req := &accountpb.TransferRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// publish withdrawal
-	err = h.KafkaService.PublishTransaction(model.NewTransaction(account.ID, req.Amount, "debit"))
+	// only the source account needs a role, anyone may be paid
+	if _, ok := h.authorizedAccount(c, req.FromAccount, model.OpTransfer); !ok {
+		return
+	}
+
+	amount, err := money.FromProto(req.Amount)
 	if err != nil {
-		err= h.BankingService.CreateTransaction(model.NewTransaction(account.ID, req.Amount, "debit"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s has withdrawn %f", account.ID, req.Amount)})
+	key, ok := idempotencyKey(c, model.OpTransfer, req)
+	if !ok {
+		return
+	}
+
+	debit, credit, err := h.BankingService.Transfer(req.FromAccount, req.ToAccount, amount, key)
+	replayed(c, key)
+	if err != nil {
+		postingFailed(c, err)
+		return
+	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("%s has transferred %s to %s", req.FromAccount, debit.Amount, req.ToAccount),
+		"transferId": debit.TransferID,
+		"credited":   credit.Amount,
+		"fxRate":     credit.FxRate,
+	})
 }
 
-func (h handler) PublishTransactions(c *gin.Context) {
-	err:= h.KafkaService.PublishTransaction(model.NewTransaction(uuid.New().String(), 0, "opening"))
+// idempotencyKey reads the Idempotency-Key header of a money-moving request.
+// It returns nil when the client sent none, and writes the error response
+// and returns false when the key is invalid.
+func idempotencyKey(c *gin.Context, op model.Operation, req any) (*model.IdempotencyKey, bool) {
+	header, sent := c.Request.Header[model.IdempotencyKeyHeader]
+	if !sent {
+		return nil, true
+	}
+	key, err := model.NewIdempotencyKey(header[0], authenticatedUser(c), op, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return key, true
+}
+
+// replayed marks the response to a request that was already made with its
+// idempotency key
+func replayed(c *gin.Context, key *model.IdempotencyKey) {
+	if key != nil && key.Replayed {
+		c.Header(model.IdempotentReplayedHeader, "true")
+	}
+}
+
+// postingFailed writes the response for a deposit, withdrawal or transfer
+// that could not be posted. A posting over one of the account's limits is
+// answered with what the limit still allows, and one the risk rules held or
+// blocked with its review.
+func postingFailed(c *gin.Context, err error) {
+	var riskErr *model.RiskError
+	if errors.As(err, &riskErr) {
+		if riskErr.Outcome == risk.Block {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "reviewId": riskErr.ReviewID})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": err.Error(), "reviewId": riskErr.ReviewID})
+		return
+	}
+	var limitErr *model.LimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     err.Error(),
+			"limit":     limitErr.Rule,
+			"remaining": limitErr.Remaining,
+		})
+		return
+	}
+	if errors.Is(err, bankingService.ErrIdempotencyKeyReused) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Transaction published successfully"})
-}
\ No newline at end of file
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userKey is the gin context key holding the authenticated user's email
+const userKey = "user"
+
+// Login exchanges an email and password for a token pair
+func (h handler) Login(c *gin.Context) {
+	req := &accountpb.LoginRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.AuthService.Login(req.Email, req.Password)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
+func (h handler) RefreshToken(c *gin.Context) {
+	req := &accountpb.RefreshTokenRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.AuthService.Refresh(req.RefreshToken)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes a refresh token. Access tokens are short-lived and simply
+// expire.
+func (h handler) Logout(c *gin.Context) {
+	req := &accountpb.RefreshTokenRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.AuthService.Logout(req.RefreshToken); err != nil {
+		authError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Authenticate is middleware that requires a valid bearer access token and
+// records whose it is for the handlers that follow
+func (h handler) Authenticate(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return
+	}
+
+	email, err := h.AuthService.VerifyAccessToken(token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(userKey, email)
+	c.Next()
+}
+
+// authenticatedUser returns the email of the user making the request
+func authenticatedUser(c *gin.Context) string {
+	return c.GetString(userKey)
+}
+
+// authorizedAccount loads an account on which the authenticated user holds a
+// role permitting op, writing the error response and returning false otherwise
+func (h handler) authorizedAccount(c *gin.Context, accountID string, op model.Operation) (*model.Account, bool) {
+	role, err := h.BankingService.GetAccountRole(accountID, authenticatedUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if role == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access to this account is denied"})
+		return nil, false
+	}
+	if !model.RolePermits(role, op) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("a %s may not %s on this account", role, op)})
+		return nil, false
+	}
+
+	account, err := h.BankingService.GetAccountbyId(accountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return account, true
+}
+
+func authError(c *gin.Context, err error) {
+	if errors.Is(err, bankingService.ErrInvalidCredentials) || errors.Is(err, bankingService.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, bankingService.ErrUserDisabled) || errors.Is(err, bankingService.ErrUserLocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -2,22 +2,38 @@ package handler
 
 import (
 	"github.com/banking-app/account-service/src/gateway"
+	authService "github.com/banking-app/account-service/src/service/auth"
 	bankingService "github.com/banking-app/account-service/src/service/banking"
-	kafkaService "github.com/banking-app/account-service/src/service/kafka"
+	reconcileService "github.com/banking-app/account-service/src/service/reconcile"
+	statementService "github.com/banking-app/account-service/src/service/statement"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler interface {
 
+	// Auth methods
+	Login(c *gin.Context)
+	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	Authenticate(c *gin.Context)
+
 	// Account methods
 	CreateAccount(c *gin.Context)
 	GetAccountbyId(c *gin.Context)
 	UpdateAccount(c *gin.Context)
 	DisableAccount(c *gin.Context)
 	ActivateAccount(c *gin.Context)
+	FreezeAccount(c *gin.Context)
+	UnfreezeAccount(c *gin.Context)
+	SetOverdraftLimit(c *gin.Context)
+	GetAccountLimits(c *gin.Context)
+	SetAccountLimits(c *gin.Context)
+	GetAccountStatusHistory(c *gin.Context)
+	GetStatement(c *gin.Context)
 	Deposit(c *gin.Context)
 	Withdraw(c *gin.Context)
+	Transfer(c *gin.Context)
 
 	// User methods 
 	CreateUser(c *gin.Context)
@@ -25,25 +41,63 @@ type Handler interface {
 	UpdateUser(c *gin.Context)
 	DisableUser(c *gin.Context)
 	ActivateUser(c *gin.Context)
+	LockUser(c *gin.Context)
+
+	// Owner methods
+	ListUserAccounts(c *gin.Context)
+	AttachAccount(c *gin.Context)
+	DetachAccount(c *gin.Context)
+
+	// Standing order methods
+	CreateStandingOrder(c *gin.Context)
+	ListStandingOrders(c *gin.Context)
+	GetStandingOrder(c *gin.Context)
+	UpdateStandingOrder(c *gin.Context)
+	CancelStandingOrder(c *gin.Context)
+
+	// Hold methods
+	PlaceHold(c *gin.Context)
+	ListHolds(c *gin.Context)
+	GetHold(c *gin.Context)
+	CaptureHold(c *gin.Context)
+	ReleaseHold(c *gin.Context)
+
+	// Interest methods
+	AccrueInterest(c *gin.Context)
+
+	// Risk review methods
+	ListRiskReviews(c *gin.Context)
+	ApproveRiskReview(c *gin.Context)
+	RejectRiskReview(c *gin.Context)
+
+	// Ledger methods
+	VerifyLedger(c *gin.Context)
+	ReconcileAccounts(c *gin.Context)
 
 	// Transaction methods
 	GetTransactionbyId(c *gin.Context)
 	GetTransactionsbyAccount(c *gin.Context)
 	GetTransactionsbyMonthRange(c *gin.Context)
+	SearchTransactions(c *gin.Context)
+	ReverseTransaction(c *gin.Context)
 }
 
 // AccountHandlerImpl implements AccountHandler
 type handler struct {
 	BankingService bankingService.BankingService
-	KafkaService   kafkaService.KafkaService
+	AuthService    authService.AuthService
 	Gateway        gateway.Gateway
+	Reconciler     *reconcileService.Reconciler
+	Statements     *statementService.Statements
 }
 
 // NewAccountHandlerImpl returns a new AccountHandlerImpl
-func NewHandler(bankingService bankingService.BankingService, kafkaService kafkaService.KafkaService, gateway gateway.Gateway) Handler {
+func NewHandler(bankingService bankingService.BankingService, authService authService.AuthService, gateway gateway.Gateway, reconciler *reconcileService.Reconciler, statements *statementService.Statements) Handler {
 	return &handler{
 		BankingService: bankingService,
-		KafkaService:   kafkaService,
+		AuthService:    authService,
 		Gateway:        gateway,
+		Reconciler:     reconciler,
+		Statements:     statements,
 	}
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// example json request
+// {
+//   "amount": {"value": "75.00", "currency": "USD"},
+//   "reference": "hotel deposit",
+//   "expires_in": 72
+// }
+
+// PlaceHold reserves funds on an account for a payment to be captured later
+func (h handler) PlaceHold(c *gin.Context) {
+	accountId := c.Param("accountId")
+	req := &accountpb.PlaceHoldRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := h.authorizedAccount(c, accountId, model.OpWithdraw); !ok {
+		return
+	}
+
+	hold, err := model.NewHoldFromProto(req, accountId, authenticatedUser(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = h.BankingService.PlaceHold(hold); err != nil {
+		postingFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ListHolds lists the holds on an account
+func (h handler) ListHolds(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
+	holds, err := h.BankingService.ListHolds(accountId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holds)
+}
+
+// GetHold gets a hold on an account
+func (h handler) GetHold(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
+	hold, err := h.BankingService.GetHold(accountId, c.Param("holdId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+// CaptureHold settles a hold for its whole amount, or for less if an amount
+// is given, releasing the rest
+func (h handler) CaptureHold(c *gin.Context) {
+	accountId := c.Param("accountId")
+	req := &accountpb.CaptureHoldRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+	if _, ok := h.authorizedAccount(c, accountId, model.OpWithdraw); !ok {
+		return
+	}
+
+	var amount *money.Money
+	if req.Amount != nil {
+		parsed, err := money.FromProto(req.Amount)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		amount = &parsed
+	}
+
+	hold, transaction, err := h.BankingService.CaptureHold(accountId, c.Param("holdId"), amount)
+	if err != nil {
+		postingFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"hold":          hold,
+		"transactionId": transaction.ID,
+	})
+}
+
+// ReleaseHold cancels a hold, making its funds available again
+func (h handler) ReleaseHold(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpWithdraw); !ok {
+		return
+	}
+
+	hold, err := h.BankingService.ReleaseHold(accountId, c.Param("holdId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
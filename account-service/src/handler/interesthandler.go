@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// example json request
+// {
+//   "from": "2024-01-01",
+//   "to": "2024-01-31"
+// }
+
+// AccrueInterest accrues interest for a past range of days and posts every
+// month the range completes. Days and months already done are skipped.
+// Admin only.
+func (h handler) AccrueInterest(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.AccrueInterestRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := time.Parse(model.DateLayout, req.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2024-01-01"})
+		return
+	}
+	to, err := time.Parse(model.DateLayout, req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2024-01-31"})
+		return
+	}
+
+	run, err := h.BankingService.AccrueInterestRange(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
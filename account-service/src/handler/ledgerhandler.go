@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyLedger recomputes every balance from the ledger and reports drift.
+// It answers 200 when the books agree and 409 when they do not. Admin only.
+func (h handler) VerifyLedger(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	report, err := h.BankingService.VerifyLedger()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !report.OK() {
+		c.JSON(http.StatusConflict, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// example json request
+// {
+//   "account_ids": ["<accountId>"],
+//   "republish": true
+// }
+
+// ReconcileAccounts compares account balances and transactions with
+// transaction-service's history, for the accounts given or all of them, and
+// reports the mismatches. With republish, missing transactions are queued to
+// be published again. Admin only.
+func (h handler) ReconcileAccounts(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.ReconcileRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+
+	report, err := h.Reconciler.Reconcile(req.AccountIds, req.Republish)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/banking-app/account-service/src/model"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// example json request
+// {
+//   "daily_withdrawal": {"value": "500.00", "currency": "USD"},
+//   "withdrawals_per_hour": 3
+// }
+
+// GetAccountLimits returns the withdrawal and deposit limits that apply to
+// an account. Owners and admins can see them.
+func (h handler) GetAccountLimits(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if !h.AuthService.IsAdmin(authenticatedUser(c)) {
+		if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+			return
+		}
+	}
+
+	limits, err := h.BankingService.GetAccountLimits(accountId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetAccountLimits replaces the limits set on an account itself. Limits left
+// out fall back to the account type's. Admin only.
+func (h handler) SetAccountLimits(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.SetAccountLimitsRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limits, err := model.NewLimitsFromProto(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.BankingService.SetAccountLimits(accountId, *limits, authenticatedUser(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account limits updated successfully"})
+}
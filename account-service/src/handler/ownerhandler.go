@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banking-app/account-service/src/model"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// example json request
+// {
+//   "account_id": "123456",
+//   "role": "joint"
+// }
+
+// ListUserAccounts lists the accounts a user holds a role on. Users can only
+// list their own.
+func (h handler) ListUserAccounts(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	if userEmail != authenticatedUser(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "users can only list their own accounts"})
+		return
+	}
+
+	accounts, err := h.BankingService.GetUserAccounts(userEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// AttachAccount gives a user a joint owner or signatory role on an account.
+// Only the primary owner can attach users.
+func (h handler) AttachAccount(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	req := &accountpb.AttachAccountRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := h.authorizedAccount(c, req.AccountId, model.OpManageOwners); !ok {
+		return
+	}
+
+	owner, err := model.NewAccountOwner(req.AccountId, userEmail, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.BankingService.AddAccountOwner(owner)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s attached to account %s as %s", userEmail, req.AccountId, req.Role)})
+}
+
+// DetachAccount removes a user's role on an account. The primary owner can
+// detach anyone else, and a joint owner or signatory can detach themselves.
+func (h handler) DetachAccount(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	accountId := c.Param("accountId")
+
+	if userEmail != authenticatedUser(c) {
+		if _, ok := h.authorizedAccount(c, accountId, model.OpManageOwners); !ok {
+			return
+		}
+	}
+
+	err := h.BankingService.RemoveAccountOwner(accountId, userEmail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s detached from account %s", userEmail, accountId)})
+}
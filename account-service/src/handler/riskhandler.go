@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/banking-app/account-service/src/model"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReviewLimit is how many reviews are listed when no limit is given
+const defaultReviewLimit = 50
+
+// example json request
+// {
+//   "reason": "customer confirmed the withdrawal by phone"
+// }
+
+// ListRiskReviews lists the deposits and withdrawals the risk rules held or
+// blocked, pending ones by default. ?status=all lists every review. Admin
+// only.
+func (h handler) ListRiskReviews(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	status := c.DefaultQuery("status", model.ReviewPending)
+	if status == "all" {
+		status = ""
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReviewLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+		return
+	}
+
+	reviews, err := h.BankingService.ListRiskReviews(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ApproveRiskReview posts a deposit or withdrawal held for review. Admin
+// only.
+func (h handler) ApproveRiskReview(c *gin.Context) {
+	reviewId := c.Param("reviewId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.DecideRiskReviewRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+
+	transaction, err := h.BankingService.ApproveRiskReview(reviewId, authenticatedUser(c), req.Reason)
+	if err != nil {
+		postingFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       fmt.Sprintf("%s of %s on %s posted", transaction.Type, transaction.Amount, transaction.Account),
+		"transactionId": transaction.ID,
+	})
+}
+
+// RejectRiskReview rejects a deposit or withdrawal held for review, which is
+// then never posted. Admin only.
+func (h handler) RejectRiskReview(c *gin.Context) {
+	reviewId := c.Param("reviewId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.DecideRiskReviewRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+
+	err := h.BankingService.RejectRiskReview(reviewId, authenticatedUser(c), req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Risk review rejected"})
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+// example json request
+// {
+//   "to_account": "654321",
+//   "amount": {"value": "250.00", "currency": "USD"},
+//   "frequency": "monthly",
+//   "start_date": "2024-02-01",
+//   "max_occurrences": 12,
+//   "reference": "rent"
+// }
+
+// CreateStandingOrder schedules a one-off or recurring transfer out of an
+// account
+func (h handler) CreateStandingOrder(c *gin.Context) {
+	accountId := c.Param("accountId")
+	req := &accountpb.CreateStandingOrderRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := h.authorizedAccount(c, accountId, model.OpTransfer); !ok {
+		return
+	}
+
+	order, err := model.NewStandingOrderFromProto(req, accountId, authenticatedUser(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = h.BankingService.CreateStandingOrder(order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, order)
+}
+
+// ListStandingOrders lists the standing orders paying out of an account
+func (h handler) ListStandingOrders(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
+	orders, err := h.BankingService.ListStandingOrders(accountId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetStandingOrder gets a standing order paying out of an account
+func (h handler) GetStandingOrder(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
+	order, err := h.BankingService.GetStandingOrder(accountId, c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdateStandingOrder changes an active order's amount, end date, number of
+// occurrences and reference. Its schedule cannot be changed; cancel it and
+// create a new one instead.
+func (h handler) UpdateStandingOrder(c *gin.Context) {
+	accountId := c.Param("accountId")
+	req := &accountpb.UpdateStandingOrderRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	account, ok := h.authorizedAccount(c, accountId, model.OpTransfer)
+	if !ok {
+		return
+	}
+
+	order, err := h.BankingService.GetStandingOrder(accountId, c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if order.Status != model.OrderActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "standing order is no longer active"})
+		return
+	}
+
+	if req.Amount != nil {
+		amount, err := money.FromProto(req.Amount)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if amount.Currency == "" {
+			amount.Currency = account.Balance.Currency
+		}
+		if !amount.IsPositive() || amount.Currency != account.Balance.Currency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive and in the account's currency"})
+			return
+		}
+		order.Amount = amount
+	}
+	if err = order.SetLimits(req.EndDate, int(req.MaxOccurrences)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	order.Reference = req.Reference
+	if order.Finished() {
+		order.Status = model.OrderCompleted
+	}
+
+	if err = h.BankingService.UpdateStandingOrder(order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelStandingOrder stops a standing order from paying again
+func (h handler) CancelStandingOrder(c *gin.Context) {
+	accountId := c.Param("accountId")
+	if _, ok := h.authorizedAccount(c, accountId, model.OpTransfer); !ok {
+		return
+	}
+
+	err := h.BankingService.CancelStandingOrder(accountId, c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Standing order cancelled successfully"})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatement downloads an account's statement for a month, written like
+// 2024-03, as a PDF, or as CSV or JSON when format says so
+func (h handler) GetStatement(c *gin.Context) {
+	accountId := c.Param("accountId")
+	month := c.Param("month")
+	if _, err := time.Parse(model.MonthLayout, month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be like 2024-03"})
+		return
+	}
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf, csv or json"})
+		return
+	}
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
+	stored, err := h.Statements.Get(accountId, month)
+	if err != nil {
+		if errors.Is(err, model.ErrMonthNotEnded) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, model.ErrStatementNotReady) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, stored.Statement)
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s-%s.csv"`, accountId, month))
+		c.Data(http.StatusOK, "text/csv", stored.CSV)
+	default:
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s-%s.pdf"`, accountId, month))
+		c.Data(http.StatusOK, "application/pdf", stored.PDF)
+	}
+}
@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/banking-app/account-service/src/gateway"
+	"github.com/banking-app/account-service/src/model"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,6 +43,11 @@ func (h handler) GetTransactionbyId(c *gin.Context) {
 		return
 	}
 
+	// a transaction is visible to whoever may see its account
+	if _, ok := h.authorizedAccount(c, transaction.Account, model.OpView); !ok {
+		return
+	}
+
 	c.JSON(http.StatusOK, transaction)
 
 }
@@ -46,6 +57,10 @@ func (h *handler) GetTransactionsbyMonthRange(c *gin.Context) {
 	startMonth := c.Param("startMonth")
 	endMonth := c.Param("endMonth")
 
+	if _, ok := h.authorizedAccount(c, accountId, model.OpView); !ok {
+		return
+	}
+
 	transactions, err := h.Gateway.GetTransactionsbyMonthRange(accountId, startMonth, endMonth)
 	if err != nil {
 
@@ -66,6 +81,9 @@ func (h *handler) GetTransactionsbyMonthRange(c *gin.Context) {
 
 func (h *handler) GetTransactionsbyAccount(c *gin.Context) {
 	account := c.Param("account")
+	if _, ok := h.authorizedAccount(c, account, model.OpView); !ok {
+		return
+	}
 	count, err := strconv.Atoi(c.Param("count"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -90,3 +108,68 @@ func (h *handler) GetTransactionsbyAccount(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, transactions)
 }
+
+// SearchTransactions pages through transactions matching the filters in the
+// query, newest first, passing back transaction-service's cursor for the
+// next page. Searches of one account are open to whoever may see it;
+// searches across accounts are admin only.
+func (h handler) SearchTransactions(c *gin.Context) {
+	query := c.Request.URL.Query()
+	if account := query.Get("account"); account != "" {
+		if _, ok := h.authorizedAccount(c, account, model.OpView); !ok {
+			return
+		}
+	} else if !h.requireAdmin(c) {
+		return
+	}
+
+	page, err := h.Gateway.SearchTransactions(query)
+	if err != nil {
+		if errors.Is(err, gateway.ErrInvalidSearch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// example json request
+// {
+//   "reason": "duplicate card payment"
+// }
+
+// ReverseTransaction posts a refund or reversal that undoes a transaction on
+// its account. A transaction can only be reversed once. Admin only.
+func (h handler) ReverseTransaction(c *gin.Context) {
+	transactionId := c.Param("transactionId")
+	if !h.requireAdmin(c) {
+		return
+	}
+	req := &accountpb.ReverseTransactionRequest{}
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+
+	original, err := h.Gateway.GetTransactionbyId(transactionId)
+	if err != nil {
+		if strings.Contains(err.Error(), "no transactions found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	reversal, err := h.BankingService.ReverseTransaction(&original, authenticatedUser(c), req.Reason)
+	if err != nil {
+		if errors.Is(err, model.ErrTransactionReversed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, reversal)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/banking-app/account-service/src/model"
 
@@ -42,32 +43,37 @@ func (h handler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User created successfully"})
 }
 
-// GetUserbyEmail gets a user by email
+// GetUserbyEmail gets a user by email. Users can see themselves, admins can
+// see anyone.
 func (h handler) GetUserbyEmail(c *gin.Context) {
 	userEmail := c.Param("userEmail")
+	if !h.authorizedUser(c, userEmail) {
+		return
+	}
 	user, err := h.BankingService.GetUserbyEmail(userEmail)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, user)
 }
 
-// updateUser updates an user
+// updateUser updates an user. Status is changed through the disable, activate
+// and lock routes, never here.
 func (h handler) UpdateUser(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	if !h.authorizedUser(c, userEmail) {
+		return
+	}
 	req := &accountpb.UpdateUserRequest{}
 	if err := c.ShouldBindJSON(req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	// find if user exists
-	user, err := h.BankingService.GetUserbyEmail(req.Email)
+	user, err := h.BankingService.GetUserbyEmail(userEmail)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if user == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 	// update user
@@ -75,6 +81,7 @@ func (h handler) UpdateUser(c *gin.Context) {
 	user.LastName = req.LastName
 	user.Type = req.Type
 	user.Password = req.Password
+	user.UpdatedAt = time.Now()
 
 	err = h.BankingService.UpdateUser(user)
 	if err != nil {
@@ -85,68 +92,96 @@ func (h handler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
 }
 
-// disableUser disables an user
+// disableUser disables an user. Users can close their own login, admins can
+// disable anyone.
 func (h handler) DisableUser(c *gin.Context) {
-
-	req := &accountpb.DisableUserRequest{}
-	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	// find if user exists
-	user, err := h.BankingService.GetUserbyEmail(req.UserId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if user == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
+	userEmail := c.Param("userEmail")
+	if !h.authorizedUser(c, userEmail) {
 		return
 	}
-	// update user
-	if user.Status == "disabled" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user is already disabled"})
+	req := &accountpb.DisableUserRequest{}
+	if !bindOptionalJSON(c, req) {
 		return
 	}
-	user.Status = "disabled"
-
-	err = h.BankingService.UpdateUser(user)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !h.changeUserStatus(c, userEmail, model.UserDisabled, req.Reason) {
 		return
 	}
-
 	c.JSON(http.StatusOK, gin.H{"message": "User Disabled successfully"})
 }
 
-// activateUser activates an user
+// activateUser activates a disabled or locked user. Admin only.
 func (h handler) ActivateUser(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	if !h.requireAdmin(c) {
+		return
+	}
 	req := &accountpb.ActivateUserRequest{}
-	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindOptionalJSON(c, req) {
 		return
 	}
-	// find if user exists
-	user, err := h.BankingService.GetUserbyEmail(req.UserId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !h.changeUserStatus(c, userEmail, model.UserActive, req.Reason) {
 		return
 	}
-	if user == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
+	c.JSON(http.StatusOK, gin.H{"message": "User Activated successfully"})
+}
+
+// LockUser locks a user out until an admin activates them again. Admin only.
+func (h handler) LockUser(c *gin.Context) {
+	userEmail := c.Param("userEmail")
+	if !h.requireAdmin(c) {
 		return
 	}
-	// update user
-	if user.Status == "active" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user is already active"})
+	req := &accountpb.LockUserRequest{}
+	if !bindOptionalJSON(c, req) {
 		return
 	}
-	user.Status = "active"
+	if !h.changeUserStatus(c, userEmail, model.UserLocked, req.Reason) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User Locked successfully"})
+}
 
-	err = h.BankingService.UpdateUser(user)
+// changeUserStatus moves a user to a new status on behalf of the
+// authenticated user, writing the error response and returning false if the
+// lifecycle does not allow it
+func (h handler) changeUserStatus(c *gin.Context, email string, status string, reason string) bool {
+	err := h.BankingService.ChangeUserStatus(email, status, authenticatedUser(c), reason)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return false
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User Activated successfully"})
+	return true
+}
+
+// authorizedUser checks the authenticated user is either the given user or an
+// admin, writing the error response and returning false otherwise
+func (h handler) authorizedUser(c *gin.Context, email string) bool {
+	if email == authenticatedUser(c) || h.AuthService.IsAdmin(authenticatedUser(c)) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "access to this user is denied"})
+	return false
+}
+
+// requireAdmin checks the authenticated user is an admin, writing the error
+// response and returning false otherwise
+func (h handler) requireAdmin(c *gin.Context) bool {
+	if h.AuthService.IsAdmin(authenticatedUser(c)) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "only admins can do this"})
+	return false
+}
+
+// bindOptionalJSON binds a request body if one was sent, for routes whose
+// body is optional
+func bindOptionalJSON(c *gin.Context, req any) bool {
+	if c.Request.ContentLength == 0 {
+		return true
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
 }
@@ -1,24 +1,33 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	accountpb "github.com/banking-app/protos/generated/account"
+	"github.com/banking-app/protos/money"
 
 	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        string    `json:"id" db:"id"`
-	FirstName string    `json:"firstName" db:"first_name"`
-	LastName  string    `json:"lastName" db:"last_name"`
-	Email     string    `json:"email" db:"email"`
-	Type      string    `json:"type" db:"account_type"`
-	Balance   float64   `json:"balance" db:"balance"`
-	Status    string    `json:"status" db:"status"`
-	Password  string    `json:"password" db:"password"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	ID        string      `json:"id" db:"id"`
+	FirstName string      `json:"firstName" db:"first_name"`
+	LastName  string      `json:"lastName" db:"last_name"`
+	Email     string      `json:"email" db:"email"`
+	Type      string      `json:"type" db:"account_type"`
+	Balance   money.Money `json:"balance" db:"balance"`
+	// AvailableBalance is the balance less what pending holds reserve
+	AvailableBalance money.Money `json:"availableBalance" db:"available_balance"`
+	// OverdraftLimit is how far below zero the balance may go
+	OverdraftLimit money.Money `json:"overdraftLimit" db:"overdraft_limit"`
+	Status         string      `json:"status" db:"status"`
+	Password       string      `json:"-" db:"password"`
+	CreatedAt      time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updatedAt" db:"updated_at"`
+	// ClosedAt and ClosureReason are set when the account is closed
+	ClosedAt      *time.Time `json:"closedAt,omitempty" db:"closed_at"`
+	ClosureReason string     `json:"closureReason,omitempty" db:"closure_reason"`
 }
 
 type User struct {
@@ -27,7 +36,7 @@ type User struct {
 	Email     string    `json:"email" db:"email"`
 	Type      string    `json:"type" db:"type"`
 	Status    string    `json:"status" db:"status"`
-	Password  string    `json:"password" db:"password"`
+	Password  string    `json:"-" db:"password"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
@@ -39,23 +48,54 @@ func (a *Account) ToProto() *accountpb.Account {
 		LastName:    a.LastName,
 		Email:       a.Email,
 		AccountType: a.Type,
-		Balance:     a.Balance,
+		Balance:     a.Balance.ToProto(),
 		Status:      a.Status,
-		Password:    a.Password,
+
+		AvailableBalance: a.AvailableBalance.ToProto(),
 	}
 }
 
-func NewAccountFromProto(a *accountpb.CreateAccountRequest) *Account {
-	return &Account{
-		ID:        uuid.New().String(),
-		FirstName: a.FirstName,
-		LastName:  a.LastName,
-		Email:     a.Email,
-		Type:      a.Type,
-		Balance:   a.Balance,
-		Status:    a.Status,
-		Password:  a.Password,
+func NewAccountFromProto(a *accountpb.CreateAccountRequest) (*Account, error) {
+	currency := money.DefaultCurrency
+	if a.Currency != "" {
+		var err error
+		currency, err = money.ParseCurrency(a.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	balance := money.Zero(currency)
+	if a.Balance != nil {
+		var err error
+		balance, err = money.FromProto(a.Balance)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case balance.Currency == "":
+			balance.Currency = currency
+		case a.Currency == "":
+			// the opening balance decides the currency
+		case balance.Currency != currency:
+			return nil, fmt.Errorf("opening balance currency %s does not match account currency %s", balance.Currency, currency)
+		}
 	}
+
+	return &Account{
+		ID:               uuid.New().String(),
+		FirstName:        a.FirstName,
+		LastName:         a.LastName,
+		Email:            a.Email,
+		Type:             a.Type,
+		Balance:          balance,
+		AvailableBalance: balance,
+		OverdraftLimit:   money.Zero(balance.Currency),
+		Status:           AccountActive,
+		Password:         a.Password,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}, nil
 }
 
 func NewUserFromProto(u *accountpb.CreateUserRequest) *User {
@@ -64,6 +104,9 @@ func NewUserFromProto(u *accountpb.CreateUserRequest) *User {
 		LastName:  u.LastName,
 		Email:     u.Email,
 		Type:      u.Type,
+		Status:    UserPending,
 		Password:  u.Password,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 }
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Account statuses. Frozen accounts take credits but not debits, inactive
+// accounts have had no transactions for a while, and closed is final.
+const (
+	AccountActive   = "active"
+	AccountInactive = "inactive"
+	AccountFrozen   = "frozen"
+	AccountClosed   = "closed"
+)
+
+// accountTransitions lists the statuses each status can move to
+var accountTransitions = map[string][]string{
+	AccountActive:   {AccountInactive, AccountFrozen, AccountClosed},
+	AccountInactive: {AccountActive, AccountFrozen, AccountClosed},
+	AccountFrozen:   {AccountActive, AccountClosed},
+	AccountClosed:   {},
+}
+
+// Reason codes an account can be frozen with
+const (
+	FreezeFraudSuspected   = "fraud_suspected"
+	FreezeLegalOrder       = "legal_order"
+	FreezeCustomerRequest  = "customer_request"
+	FreezeComplianceReview = "compliance_review"
+)
+
+var freezeReasons = map[string]bool{
+	FreezeFraudSuspected:   true,
+	FreezeLegalOrder:       true,
+	FreezeCustomerRequest:  true,
+	FreezeComplianceReview: true,
+}
+
+// Reasons recorded for status changes the service makes on its own
+const (
+	ReasonDormant  = "dormant"
+	ReasonActivity = "activity"
+)
+
+// AccountStatusChange is one entry in an account's status history
+type AccountStatusChange struct {
+	ID        int64     `json:"id" db:"id"`
+	AccountID string    `json:"accountId" db:"account_id"`
+	From      string    `json:"from" db:"from_status"`
+	To        string    `json:"to" db:"to_status"`
+	Reason    string    `json:"reason,omitempty" db:"reason"`
+	Actor     string    `json:"actor" db:"actor"`
+	ChangedAt time.Time `json:"changedAt" db:"changed_at"`
+}
+
+// CheckAccountTransition returns an error unless an account may move from
+// one status to another
+func CheckAccountTransition(from string, to string) error {
+	if from == to {
+		return fmt.Errorf("account is already %s", to)
+	}
+	if from == AccountClosed {
+		return fmt.Errorf("account is closed")
+	}
+	for _, next := range accountTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("account cannot go from %s to %s", from, to)
+}
+
+// CheckFreezeReason returns an error unless code is a known freeze reason
+func CheckFreezeReason(code string) error {
+	if !freezeReasons[code] {
+		return fmt.Errorf("invalid freeze reason %q", code)
+	}
+	return nil
+}
+
+// CanDebit reports whether money may leave an account with the given status
+func CanDebit(status string) bool {
+	return status == AccountActive || status == AccountInactive
+}
+
+// CanCredit reports whether money may be paid into an account with the
+// given status
+func CanCredit(status string) bool {
+	return status == AccountActive || status == AccountInactive || status == AccountFrozen
+}
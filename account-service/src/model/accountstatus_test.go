@@ -0,0 +1,53 @@
+package model
+
+import "testing"
+
+func TestCheckAccountTransition(t *testing.T) {
+
+	tests := []struct {
+		from string
+		to   string
+		ok   bool
+	}{
+		{AccountActive, AccountFrozen, true},
+		{AccountFrozen, AccountActive, true},
+		{AccountInactive, AccountActive, true},
+		{AccountActive, AccountClosed, true},
+		{AccountFrozen, AccountInactive, false},
+		{AccountClosed, AccountActive, false},
+		{AccountActive, AccountActive, false},
+	}
+
+	for _, tt := range tests {
+		err := CheckAccountTransition(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("Expected %s -> %s allowed to be %v but got %v", tt.from, tt.to, tt.ok, err)
+		}
+	}
+}
+
+func TestFrozenAccountsTakeCreditsOnly(t *testing.T) {
+
+	if CanDebit(AccountFrozen) {
+		t.Errorf("Expected a frozen account not to be debited")
+	}
+	if !CanCredit(AccountFrozen) {
+		t.Errorf("Expected a frozen account to be credited")
+	}
+	if CanDebit(AccountClosed) || CanCredit(AccountClosed) {
+		t.Errorf("Expected a closed account to take no postings")
+	}
+	if !CanDebit(AccountInactive) || !CanCredit(AccountInactive) {
+		t.Errorf("Expected an inactive account to take postings")
+	}
+}
+
+func TestCheckFreezeReason(t *testing.T) {
+
+	if err := CheckFreezeReason(FreezeFraudSuspected); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	if err := CheckFreezeReason("because"); err == nil {
+		t.Errorf("Expected an unknown reason to be rejected")
+	}
+}
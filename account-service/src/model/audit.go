@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/google/uuid"
+)
+
+// Audit event types
+const (
+	AuditUserStatusChanged     = "user.status_changed"
+	AuditAccountStatusChanged  = "account.status_changed"
+	AuditOverdraftLimitChanged = "account.overdraft_limit_changed"
+	AuditAccountLimitsChanged  = "account.limits_changed"
+	AuditRiskReviewChanged     = "risk.review_changed"
+	AuditTransactionReversed   = "transaction.reversed"
+)
+
+// ActorSystem is the actor of changes the service makes on its own, such as
+// locking a user after repeated failed logins
+const ActorSystem = "system"
+
+// AuditEvent records a change made to a user or account, and who made it
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Subject   string    `json:"subject"`
+	Actor     string    `json:"actor"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewUserStatusChangedEvent(email string, from string, to string, actor string, reason string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditUserStatusChanged,
+		Subject:   email,
+		Actor:     actor,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+}
+
+func NewAccountStatusChangedEvent(accountID string, from string, to string, actor string, reason string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditAccountStatusChanged,
+		Subject:   accountID,
+		Actor:     actor,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+}
+
+func NewOverdraftLimitChangedEvent(accountID string, from money.Money, to money.Money, actor string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditOverdraftLimitChanged,
+		Subject:   accountID,
+		Actor:     actor,
+		From:      from.String(),
+		To:        to.String(),
+		Timestamp: time.Now(),
+	}
+}
+
+func NewAccountLimitsChangedEvent(accountID string, from Limits, to Limits, actor string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditAccountLimitsChanged,
+		Subject:   accountID,
+		Actor:     actor,
+		From:      from.String(),
+		To:        to.String(),
+		Timestamp: time.Now(),
+	}
+}
+
+// NewRiskReviewChangedEvent records a posting being held or blocked by the
+// risk rules, from no status, or a held posting being decided
+func NewRiskReviewChangedEvent(reviewID string, from string, to string, actor string, reason string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditRiskReviewChanged,
+		Subject:   reviewID,
+		Actor:     actor,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewTransactionReversedEvent records a transaction being reversed by
+// another
+func NewTransactionReversedEvent(transactionID string, reversalID string, actor string, reason string) *AuditEvent {
+	return &AuditEvent{
+		ID:        uuid.New().String(),
+		Type:      AuditTransactionReversed,
+		Subject:   transactionID,
+		Actor:     actor,
+		To:        reversalID,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// RefreshToken is a stored refresh token. Only the hash of the token is
+// kept, so a leaked table cannot be used to sign in.
+type RefreshToken struct {
+	Hash      string    `db:"token_hash"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expiresIn"`
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+	"github.com/banking-app/protos/money"
+
+	"github.com/google/uuid"
+)
+
+// Hold statuses. Only pending holds reserve funds.
+const (
+	HoldPending  = "pending"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// Hold reserves part of an account's balance for a payment that has been
+// authorised but not yet settled. The reserved amount is taken off the
+// account's available balance, but not its balance, until the hold is
+// captured, released or expires.
+type Hold struct {
+	ID        string      `json:"id" db:"id"`
+	AccountID string      `json:"accountId" db:"account_id"`
+	Amount    money.Money `json:"amount" db:"amount"`
+	// CapturedAmount is what was debited when the hold was captured
+	CapturedAmount *money.Money `json:"capturedAmount,omitempty" db:"captured_amount"`
+	// TransactionID is the debit posted when the hold was captured
+	TransactionID string    `json:"transactionId,omitempty" db:"transaction_id"`
+	Status        string    `json:"status" db:"status"`
+	Reference     string    `json:"reference,omitempty" db:"reference"`
+	CreatedBy     string    `json:"createdBy" db:"created_by"`
+	ExpiresAt     time.Time `json:"expiresAt" db:"expires_at"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// NewHoldFromProto builds a hold on accountID, expiring after the hours
+// asked for. A hold with no expiry asked for is given one by ApplyExpiry.
+func NewHoldFromProto(req *accountpb.PlaceHoldRequest, accountID string, createdBy string) (*Hold, error) {
+	if req.Amount == nil {
+		return nil, fmt.Errorf("amount is required")
+	}
+	amount, err := money.FromProto(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("hold amount must be positive")
+	}
+	if req.ExpiresIn < 0 {
+		return nil, fmt.Errorf("expires_in cannot be negative")
+	}
+
+	now := time.Now()
+	h := &Hold{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Amount:    amount,
+		Status:    HoldPending,
+		Reference: req.Reference,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.ExpiresIn > 0 {
+		h.ExpiresAt = now.Add(time.Hour * time.Duration(req.ExpiresIn))
+	}
+	return h, nil
+}
+
+// ApplyExpiry gives a hold with no expiry one defaultExpiry after it was
+// placed, and refuses one lasting longer than maxExpiry, if that is set
+func (h *Hold) ApplyExpiry(defaultExpiry time.Duration, maxExpiry time.Duration) error {
+	if h.ExpiresAt.IsZero() {
+		h.ExpiresAt = h.CreatedAt.Add(defaultExpiry)
+	}
+	if maxExpiry > 0 && h.ExpiresAt.Sub(h.CreatedAt) > maxExpiry {
+		return fmt.Errorf("holds cannot last longer than %s", maxExpiry)
+	}
+	return nil
+}
+
+// CaptureAmount checks what may be captured of a pending hold that has not
+// expired. A hold is captured once, for its whole amount when amount is nil
+// or for less; what is not captured is released.
+func (h *Hold) CaptureAmount(amount *money.Money, now time.Time) (money.Money, error) {
+	if err := h.checkPending(); err != nil {
+		return money.Money{}, err
+	}
+	if !now.Before(h.ExpiresAt) {
+		return money.Money{}, fmt.Errorf("hold has expired")
+	}
+	if amount == nil {
+		return h.Amount, nil
+	}
+
+	captured := *amount
+	if captured.Currency == "" {
+		captured.Currency = h.Amount.Currency
+	}
+	if !captured.IsPositive() {
+		return money.Money{}, fmt.Errorf("capture amount must be positive")
+	}
+	cmp, err := captured.Cmp(h.Amount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if cmp > 0 {
+		return money.Money{}, fmt.Errorf("cannot capture %s of a hold of %s", captured, h.Amount)
+	}
+	return captured, nil
+}
+
+// CheckRelease returns an error unless the hold may be released
+func (h *Hold) CheckRelease() error {
+	return h.checkPending()
+}
+
+func (h *Hold) checkPending() error {
+	if h.Status != HoldPending {
+		return fmt.Errorf("hold is already %s", h.Status)
+	}
+	return nil
+}
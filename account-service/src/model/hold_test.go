@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+	moneypb "github.com/banking-app/protos/generated/money"
+	"github.com/banking-app/protos/money"
+)
+
+func TestHoldApplyExpiry(t *testing.T) {
+
+	req := &accountpb.PlaceHoldRequest{Amount: &moneypb.Money{Value: "50.00", Currency: "USD"}}
+	hold, err := NewHoldFromProto(req, "account", "yash@gmail.com")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if hold.Status != HoldPending {
+		t.Errorf("Expected a new hold to be %s but got %s", HoldPending, hold.Status)
+	}
+	if err = hold.ApplyExpiry(time.Hour*24, time.Hour*24*30); err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if got := hold.ExpiresAt.Sub(hold.CreatedAt); got != time.Hour*24 {
+		t.Errorf("Expected the default expiry but got %v", got)
+	}
+
+	req.ExpiresIn = 24 * 31
+	hold, _ = NewHoldFromProto(req, "account", "yash@gmail.com")
+	if err = hold.ApplyExpiry(time.Hour*24, time.Hour*24*30); err == nil {
+		t.Errorf("Expected a hold longer than the maximum to be rejected")
+	}
+}
+
+func TestHoldCaptureAmount(t *testing.T) {
+
+	now := time.Now()
+	hold := &Hold{Amount: money.New(5000, "USD"), Status: HoldPending, ExpiresAt: now.Add(time.Hour)}
+
+	full, err := hold.CaptureAmount(nil, now)
+	if err != nil || full != hold.Amount {
+		t.Errorf("Expected the whole hold to be captured but got %v, %v", full, err)
+	}
+
+	partial := money.New(3000, "")
+	got, err := hold.CaptureAmount(&partial, now)
+	if err != nil || got != money.New(3000, "USD") {
+		t.Errorf("Expected 30.00 USD to be captured but got %v, %v", got, err)
+	}
+
+	over := money.New(5001, "USD")
+	if _, err := hold.CaptureAmount(&over, now); err == nil {
+		t.Errorf("Expected capturing more than the hold to be rejected")
+	}
+
+	if _, err := hold.CaptureAmount(nil, hold.ExpiresAt); err == nil {
+		t.Errorf("Expected an expired hold not to be captured")
+	}
+
+	hold.Status = HoldReleased
+	if _, err := hold.CaptureAmount(nil, now); err == nil {
+		t.Errorf("Expected a released hold not to be captured")
+	}
+}
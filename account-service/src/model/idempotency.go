@@ -0,0 +1,62 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the client's key on money-moving requests
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on a response replayed for a key that was
+// already used
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyKey identifies one attempt at a money-moving request. Keys are
+// scoped to the user who sends them, and RequestHash fingerprints the request
+// so that a key cannot be reused for a different one.
+type IdempotencyKey struct {
+	Key         string
+	UserEmail   string
+	Operation   Operation
+	RequestHash string
+	// Replayed is set when the request was already made with this key and
+	// its stored result was returned instead of posting again
+	Replayed  bool
+	CreatedAt time.Time
+}
+
+// NewIdempotencyKey validates a client's key and fingerprints the request it
+// was sent with. The request is hashed in its parsed form, so a retry that
+// only reorders fields or changes whitespace still matches.
+func NewIdempotencyKey(key string, userEmail string, operation Operation, request any) (*IdempotencyKey, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%s must not be empty", IdempotencyKeyHeader)
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+	hash := sha256.New()
+	hash.Write([]byte(operation))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return &IdempotencyKey{
+		Key:         key,
+		UserEmail:   userEmail,
+		Operation:   operation,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   time.Now(),
+	}, nil
+}
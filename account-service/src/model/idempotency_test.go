@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewIdempotencyKeyHashesRequest(t *testing.T) {
+
+	type request struct {
+		Id     string `json:"id"`
+		Amount string `json:"amount"`
+	}
+
+	first, err := NewIdempotencyKey("retry-1", "yash@gmail.com", OpDeposit, &request{Id: "123456", Amount: "10.00"})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	again, _ := NewIdempotencyKey("retry-1", "yash@gmail.com", OpDeposit, &request{Id: "123456", Amount: "10.00"})
+	if first.RequestHash != again.RequestHash {
+		t.Errorf("Expected the same request to hash the same")
+	}
+
+	changed, _ := NewIdempotencyKey("retry-1", "yash@gmail.com", OpDeposit, &request{Id: "123456", Amount: "20.00"})
+	if changed.RequestHash == first.RequestHash {
+		t.Errorf("Expected a different amount to hash differently")
+	}
+
+	// the same body sent to another endpoint is a different request
+	withdrawal, _ := NewIdempotencyKey("retry-1", "yash@gmail.com", OpWithdraw, &request{Id: "123456", Amount: "10.00"})
+	if withdrawal.RequestHash == first.RequestHash {
+		t.Errorf("Expected a withdrawal to hash differently from a deposit")
+	}
+}
+
+func TestNewIdempotencyKeyRejectsBadKeys(t *testing.T) {
+
+	if _, err := NewIdempotencyKey("", "yash@gmail.com", OpDeposit, nil); err == nil {
+		t.Errorf("Expected an empty key to be rejected")
+	}
+	if _, err := NewIdempotencyKey(strings.Repeat("k", 256), "yash@gmail.com", OpDeposit, nil); err == nil {
+		t.Errorf("Expected a long key to be rejected")
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+// DateLayout is how calendar dates are written in requests and responses
+const DateLayout = "2006-01-02"
+
+// ratePattern matches a rate written as a plain decimal
+var ratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// InterestTier is the annual rate paid on a balance of at least MinBalance.
+// Rates are decimal fractions, e.g. "0.035" for 3.5%.
+type InterestTier struct {
+	MinBalance money.Money
+	Rate       string
+}
+
+// RateTable holds the tiers of an account type, lowest balance first. The
+// rate of the highest tier a balance reaches applies to all of it.
+type RateTable []InterestTier
+
+// NewRateTable checks and sorts the tiers of a rate table
+func NewRateTable(tiers []InterestTier) (RateTable, error) {
+	table := make(RateTable, len(tiers))
+	for i, tier := range tiers {
+		if !ratePattern.MatchString(tier.Rate) {
+			return nil, fmt.Errorf("invalid interest rate %q", tier.Rate)
+		}
+		table[i] = tier
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].MinBalance.Minor < table[j].MinBalance.Minor
+	})
+	return table, nil
+}
+
+// RateFor returns the annual rate paid on balance, or "" if it earns nothing
+func (t RateTable) RateFor(balance money.Money) string {
+	rate := ""
+	for _, tier := range t {
+		if balance.Minor < tier.MinBalance.Minor {
+			break
+		}
+		rate = tier.Rate
+	}
+	return rate
+}
+
+// InterestRun reports what an accrual run over a range of days did
+type InterestRun struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Accrued int    `json:"accrued"`
+	Posted  int    `json:"posted"`
+}
+
+// StartOfDay truncates t to midnight UTC
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// StartOfMonth returns midnight UTC on the first day of t's month
+func StartOfMonth(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestRateTableRateFor(t *testing.T) {
+
+	table, err := NewRateTable([]InterestTier{
+		{MinBalance: money.New(1000000, ""), Rate: "0.035"},
+		{MinBalance: money.New(0, ""), Rate: "0.02"},
+	})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	tests := map[int64]string{
+		0:       "0.02",
+		999999:  "0.02",
+		1000000: "0.035",
+		-100:    "",
+	}
+	for minor, want := range tests {
+		if got := table.RateFor(money.New(minor, money.DefaultCurrency)); got != want {
+			t.Errorf("Expected rate %q for %d but got %q", want, minor, got)
+		}
+	}
+}
+
+func TestNewRateTableRejectsInvalidRates(t *testing.T) {
+
+	for _, rate := range []string{"", "-0.01", "1/3", "2%"} {
+		if _, err := NewRateTable([]InterestTier{{Rate: rate}}); err == nil {
+			t.Errorf("Expected rate %q to be rejected", rate)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/google/uuid"
+)
+
+// Ledger accounts. Every customer account is a ledger account of its own;
+// the rest are the bank's side of the money customers pay in and out.
+const (
+	// LedgerCustomer postings move a customer account's balance
+	LedgerCustomer = "customer"
+	// LedgerSuspense is money that came from, or went to, outside the bank:
+	// deposits, withdrawals, opening balances and adjustments
+	LedgerSuspense = "suspense"
+	// LedgerFeeIncome is what overdraft fees earned the bank
+	LedgerFeeIncome = "fee_income"
+	// LedgerInterestExpense is what interest cost the bank
+	LedgerInterestExpense = "interest_expense"
+	// LedgerTransferClearing links the legs of a transfer. It nets to zero
+	// in each currency for transfers that do not cross currencies; for those
+	// that do, it is the bank's currency position.
+	LedgerTransferClearing = "transfer_clearing"
+)
+
+// JournalAdjustment is the kind of journal entry that corrects a balance
+// without a transaction, such as an admin editing an account
+const JournalAdjustment = "adjustment"
+
+// Posting is one line of a journal entry. A positive amount raises the
+// balance of its ledger account and a negative one lowers it.
+type Posting struct {
+	Ledger string `json:"ledger"`
+	// AccountID is the customer account of a customer posting
+	AccountID string      `json:"accountId,omitempty"`
+	Amount    money.Money `json:"amount"`
+}
+
+// JournalEntry records one movement of money as postings that sum to zero in
+// each currency, so money is never created or lost, only moved between
+// ledger accounts
+type JournalEntry struct {
+	ID string `json:"id"`
+	// TransactionID is the transaction the entry posts, if there is one
+	TransactionID string    `json:"transactionId,omitempty"`
+	Kind          string    `json:"kind"`
+	Postings      []Posting `json:"postings"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ContraLedger is the bank ledger account on the other side of a transaction
+// of the given type. A reversal is posted against the same ledger account as
+// the transaction it reverses, so reversalOfType is that transaction's type.
+func ContraLedger(transactionType string, transfer bool, reversalOfType string) string {
+	if reversalOfType != "" {
+		return ContraLedger(reversalOfType, false, "")
+	}
+	switch {
+	case transfer:
+		return LedgerTransferClearing
+	case transactionType == "fee":
+		return LedgerFeeIncome
+	case transactionType == "interest":
+		return LedgerInterestExpense
+	default:
+		return LedgerSuspense
+	}
+}
+
+// NewTransactionJournal posts a transaction to its customer account against
+// the contra ledger account. The entry shares the transaction's id.
+func NewTransactionJournal(transaction *Transaction, contra string) *JournalEntry {
+	amount := transaction.Amount
+	if IsDebitType(transaction.Type) {
+		amount = amount.Neg()
+	}
+	return &JournalEntry{
+		ID:            transaction.ID,
+		TransactionID: transaction.ID,
+		Kind:          transaction.Type,
+		Postings: []Posting{
+			{Ledger: LedgerCustomer, AccountID: transaction.Account, Amount: amount},
+			{Ledger: contra, Amount: amount.Neg()},
+		},
+		CreatedAt: transaction.Timestamp,
+	}
+}
+
+// NewAdjustmentJournal moves an account's balance by delta against suspense
+func NewAdjustmentJournal(accountID string, delta money.Money) *JournalEntry {
+	return &JournalEntry{
+		ID:   uuid.New().String(),
+		Kind: JournalAdjustment,
+		Postings: []Posting{
+			{Ledger: LedgerCustomer, AccountID: accountID, Amount: delta},
+			{Ledger: LedgerSuspense, Amount: delta.Neg()},
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// Check returns an error unless the entry has postings, each customer
+// posting names its account, and the postings balance in every currency
+func (j *JournalEntry) Check() error {
+	if len(j.Postings) < 2 {
+		return fmt.Errorf("journal entry %s needs at least two postings", j.ID)
+	}
+	totals := map[string]money.Money{}
+	for _, p := range j.Postings {
+		if (p.Ledger == LedgerCustomer) != (p.AccountID != "") {
+			return fmt.Errorf("journal entry %s: only customer postings have an account", j.ID)
+		}
+		total, ok := totals[p.Amount.Currency]
+		if !ok {
+			total = money.Zero(p.Amount.Currency)
+		}
+		total, err := total.Add(p.Amount)
+		if err != nil {
+			return err
+		}
+		totals[p.Amount.Currency] = total
+	}
+	for currency, total := range totals {
+		if !total.IsZero() {
+			return fmt.Errorf("journal entry %s is out of balance by %s in %s", j.ID, total, currency)
+		}
+	}
+	return nil
+}
+
+// BalanceDrift is an account whose cached balance differs from the sum of
+// its postings
+type BalanceDrift struct {
+	AccountID string      `json:"accountId"`
+	Balance   money.Money `json:"balance"`
+	Ledger    money.Money `json:"ledger"`
+	Drift     money.Money `json:"drift"`
+}
+
+// LedgerBalance is a bank ledger account's balance in one currency
+type LedgerBalance struct {
+	Ledger  string      `json:"ledger"`
+	Balance money.Money `json:"balance"`
+}
+
+// LedgerReport is the outcome of checking account balances against the
+// ledger
+type LedgerReport struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Accounts  int       `json:"accounts"`
+	// Drift lists the accounts whose balance does not match the ledger
+	Drift []BalanceDrift `json:"drift"`
+	// Unbalanced lists journal entries whose postings do not sum to zero
+	Unbalanced []string `json:"unbalanced"`
+	// Ledgers are the balances of the bank's ledger accounts
+	Ledgers []LedgerBalance `json:"ledgers"`
+}
+
+// OK reports whether every balance matched and every entry balanced
+func (r *LedgerReport) OK() bool {
+	return len(r.Drift) == 0 && len(r.Unbalanced) == 0
+}
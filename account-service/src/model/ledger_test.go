@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewTransactionJournal(t *testing.T) {
+
+	tests := []struct {
+		transactionType string
+		transfer        bool
+		reversalOfType  string
+		contra          string
+		customer        int64
+	}{
+		{"credit", false, "", LedgerSuspense, 2500},
+		{"debit", false, "", LedgerSuspense, -2500},
+		{"fee", false, "", LedgerFeeIncome, -2500},
+		{"interest", false, "", LedgerInterestExpense, 2500},
+		{"debit", true, "", LedgerTransferClearing, -2500},
+		{"refund", false, "fee", LedgerFeeIncome, 2500},
+		{"reversal", false, "interest", LedgerInterestExpense, -2500},
+	}
+
+	for _, tt := range tests {
+		transaction := NewTransaction("account", money.New(2500, "USD"), tt.transactionType)
+		contra := ContraLedger(tt.transactionType, tt.transfer, tt.reversalOfType)
+		if contra != tt.contra {
+			t.Errorf("%s: expected contra %s but got %s", tt.transactionType, tt.contra, contra)
+		}
+
+		journal := NewTransactionJournal(transaction, contra)
+		if err := journal.Check(); err != nil {
+			t.Errorf("%s: expected the entry to balance but got %v", tt.transactionType, err)
+		}
+		if journal.ID != transaction.ID || journal.TransactionID != transaction.ID {
+			t.Errorf("%s: expected the entry to share the transaction's id", tt.transactionType)
+		}
+		customer := journal.Postings[0]
+		if customer.Ledger != LedgerCustomer || customer.AccountID != "account" || customer.Amount != money.New(tt.customer, "USD") {
+			t.Errorf("%s: expected a customer posting of %d but got %+v", tt.transactionType, tt.customer, customer)
+		}
+	}
+}
+
+func TestJournalEntryCheck(t *testing.T) {
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	eur := func(minor int64) money.Money { return money.New(minor, "EUR") }
+
+	// a cross-currency transfer balances in each currency through clearing
+	transfer := &JournalEntry{ID: "transfer", Postings: []Posting{
+		{Ledger: LedgerCustomer, AccountID: "from", Amount: usd(-10000)},
+		{Ledger: LedgerTransferClearing, Amount: usd(10000)},
+		{Ledger: LedgerCustomer, AccountID: "to", Amount: eur(9200)},
+		{Ledger: LedgerTransferClearing, Amount: eur(-9200)},
+	}}
+	if err := transfer.Check(); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+
+	unbalanced := &JournalEntry{ID: "unbalanced", Postings: []Posting{
+		{Ledger: LedgerCustomer, AccountID: "from", Amount: usd(-10000)},
+		{Ledger: LedgerCustomer, AccountID: "to", Amount: eur(10000)},
+	}}
+	if err := unbalanced.Check(); err == nil {
+		t.Errorf("Expected an entry out of balance in each currency to be refused")
+	}
+
+	single := &JournalEntry{ID: "single", Postings: []Posting{
+		{Ledger: LedgerSuspense, Amount: usd(0)},
+	}}
+	if err := single.Check(); err == nil {
+		t.Errorf("Expected an entry with one posting to be refused")
+	}
+
+	anonymous := &JournalEntry{ID: "anonymous", Postings: []Posting{
+		{Ledger: LedgerCustomer, Amount: usd(100)},
+		{Ledger: LedgerSuspense, Amount: usd(-100)},
+	}}
+	if err := anonymous.Check(); err == nil {
+		t.Errorf("Expected a customer posting without an account to be refused")
+	}
+
+	if err := NewAdjustmentJournal("account", usd(-500)).Check(); err != nil {
+		t.Errorf("Expected an adjustment to balance but got %v", err)
+	}
+}
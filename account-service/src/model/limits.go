@@ -0,0 +1,230 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+	moneypb "github.com/banking-app/protos/generated/money"
+	"github.com/banking-app/protos/money"
+)
+
+// LimitRule names one of the limits on an account
+type LimitRule string
+
+const (
+	LimitDailyWithdrawal    LimitRule = "daily_withdrawal"
+	LimitMonthlyWithdrawal  LimitRule = "monthly_withdrawal"
+	LimitWithdrawalsPerHour LimitRule = "withdrawals_per_hour"
+	LimitDailyDeposit       LimitRule = "daily_deposit"
+	LimitMonthlyDeposit     LimitRule = "monthly_deposit"
+	LimitDepositsPerHour    LimitRule = "deposits_per_hour"
+)
+
+// Limits caps how much may be withdrawn from or deposited to an account per
+// calendar day and month (UTC), and how many withdrawals or deposits may be
+// made in any hour. A nil limit does not apply. Amounts are in the account's
+// currency.
+type Limits struct {
+	DailyWithdrawal    *money.Money `json:"dailyWithdrawal,omitempty"`
+	MonthlyWithdrawal  *money.Money `json:"monthlyWithdrawal,omitempty"`
+	WithdrawalsPerHour *int         `json:"withdrawalsPerHour,omitempty"`
+	DailyDeposit       *money.Money `json:"dailyDeposit,omitempty"`
+	MonthlyDeposit     *money.Money `json:"monthlyDeposit,omitempty"`
+	DepositsPerHour    *int         `json:"depositsPerHour,omitempty"`
+}
+
+// LimitUsage is what an account has already withdrawn or deposited in the
+// periods its limits cover
+type LimitUsage struct {
+	Today     money.Money
+	ThisMonth money.Money
+	LastHour  int
+}
+
+// LimitError is returned when a posting would exceed one of an account's
+// limits. Remaining is what the limit still allows, an amount or a count.
+type LimitError struct {
+	Rule      LimitRule
+	Limit     string
+	Remaining string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %s exceeded, %s remaining", strings.ReplaceAll(string(e.Rule), "_", " "), e.Limit, e.Remaining)
+}
+
+// NewLimitsFromProto reads the limits an admin sets on an account. Limits
+// left out, and counts of 0, are left nil.
+func NewLimitsFromProto(req *accountpb.SetAccountLimitsRequest) (*Limits, error) {
+	var l Limits
+	for _, amount := range []struct {
+		rule  LimitRule
+		value *moneypb.Money
+		limit **money.Money
+	}{
+		{LimitDailyWithdrawal, req.DailyWithdrawal, &l.DailyWithdrawal},
+		{LimitMonthlyWithdrawal, req.MonthlyWithdrawal, &l.MonthlyWithdrawal},
+		{LimitDailyDeposit, req.DailyDeposit, &l.DailyDeposit},
+		{LimitMonthlyDeposit, req.MonthlyDeposit, &l.MonthlyDeposit},
+	} {
+		if amount.value == nil {
+			continue
+		}
+		parsed, err := money.FromProto(amount.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s limit: %v", amount.rule, err)
+		}
+		*amount.limit = &parsed
+	}
+	if req.WithdrawalsPerHour != 0 {
+		n := int(req.WithdrawalsPerHour)
+		l.WithdrawalsPerHour = &n
+	}
+	if req.DepositsPerHour != 0 {
+		n := int(req.DepositsPerHour)
+		l.DepositsPerHour = &n
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Override returns the limits with every limit set in o replacing its own
+func (l Limits) Override(o Limits) Limits {
+	if o.DailyWithdrawal != nil {
+		l.DailyWithdrawal = o.DailyWithdrawal
+	}
+	if o.MonthlyWithdrawal != nil {
+		l.MonthlyWithdrawal = o.MonthlyWithdrawal
+	}
+	if o.WithdrawalsPerHour != nil {
+		l.WithdrawalsPerHour = o.WithdrawalsPerHour
+	}
+	if o.DailyDeposit != nil {
+		l.DailyDeposit = o.DailyDeposit
+	}
+	if o.MonthlyDeposit != nil {
+		l.MonthlyDeposit = o.MonthlyDeposit
+	}
+	if o.DepositsPerHour != nil {
+		l.DepositsPerHour = o.DepositsPerHour
+	}
+	return l
+}
+
+// InCurrency returns the limits with their amounts in the given currency.
+// Limits are configured and stored without one and take the account's.
+func (l Limits) InCurrency(currency string) Limits {
+	for _, amount := range []**money.Money{&l.DailyWithdrawal, &l.MonthlyWithdrawal, &l.DailyDeposit, &l.MonthlyDeposit} {
+		if *amount != nil {
+			converted := money.New((*amount).Minor, currency)
+			*amount = &converted
+		}
+	}
+	return l
+}
+
+// Validate checks that no limit is negative
+func (l Limits) Validate() error {
+	amounts := map[LimitRule]*money.Money{
+		LimitDailyWithdrawal:   l.DailyWithdrawal,
+		LimitMonthlyWithdrawal: l.MonthlyWithdrawal,
+		LimitDailyDeposit:      l.DailyDeposit,
+		LimitMonthlyDeposit:    l.MonthlyDeposit,
+	}
+	for rule, amount := range amounts {
+		if amount != nil && amount.IsNegative() {
+			return fmt.Errorf("%s limit cannot be negative", rule)
+		}
+	}
+	counts := map[LimitRule]*int{
+		LimitWithdrawalsPerHour: l.WithdrawalsPerHour,
+		LimitDepositsPerHour:    l.DepositsPerHour,
+	}
+	for rule, count := range counts {
+		if count != nil && *count < 0 {
+			return fmt.Errorf("%s limit cannot be negative", rule)
+		}
+	}
+	return nil
+}
+
+// String summarises the limits that apply, e.g. for audit events
+func (l Limits) String() string {
+	var parts []string
+	for _, limit := range []struct {
+		rule   LimitRule
+		amount *money.Money
+		count  *int
+	}{
+		{LimitDailyWithdrawal, l.DailyWithdrawal, nil},
+		{LimitMonthlyWithdrawal, l.MonthlyWithdrawal, nil},
+		{LimitWithdrawalsPerHour, nil, l.WithdrawalsPerHour},
+		{LimitDailyDeposit, l.DailyDeposit, nil},
+		{LimitMonthlyDeposit, l.MonthlyDeposit, nil},
+		{LimitDepositsPerHour, nil, l.DepositsPerHour},
+	} {
+		switch {
+		case limit.amount != nil:
+			parts = append(parts, fmt.Sprintf("%s=%s", limit.rule, limit.amount.Decimal()))
+		case limit.count != nil:
+			parts = append(parts, fmt.Sprintf("%s=%d", limit.rule, *limit.count))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// CheckWithdrawal checks that withdrawing amount on top of usage stays within
+// the withdrawal limits
+func (l Limits) CheckWithdrawal(amount money.Money, usage LimitUsage) error {
+	return checkLimits(amount, usage,
+		LimitDailyWithdrawal, l.DailyWithdrawal,
+		LimitMonthlyWithdrawal, l.MonthlyWithdrawal,
+		LimitWithdrawalsPerHour, l.WithdrawalsPerHour)
+}
+
+// CheckDeposit checks that depositing amount on top of usage stays within
+// the deposit limits
+func (l Limits) CheckDeposit(amount money.Money, usage LimitUsage) error {
+	return checkLimits(amount, usage,
+		LimitDailyDeposit, l.DailyDeposit,
+		LimitMonthlyDeposit, l.MonthlyDeposit,
+		LimitDepositsPerHour, l.DepositsPerHour)
+}
+
+func checkLimits(amount money.Money, usage LimitUsage, dailyRule LimitRule, daily *money.Money, monthlyRule LimitRule, monthly *money.Money, hourlyRule LimitRule, hourly *int) error {
+	if hourly != nil && usage.LastHour >= *hourly {
+		return &LimitError{Rule: hourlyRule, Limit: strconv.Itoa(*hourly), Remaining: "0"}
+	}
+
+	for _, limit := range []struct {
+		rule  LimitRule
+		limit *money.Money
+		used  money.Money
+	}{
+		{dailyRule, daily, usage.Today},
+		{monthlyRule, monthly, usage.ThisMonth},
+	} {
+		if limit.limit == nil {
+			continue
+		}
+		remaining, err := limit.limit.Sub(limit.used)
+		if err != nil {
+			return err
+		}
+		if remaining.IsNegative() {
+			remaining = money.Zero(remaining.Currency)
+		}
+		cmp, err := amount.Cmp(remaining)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			return &LimitError{Rule: limit.rule, Limit: limit.limit.String(), Remaining: remaining.String()}
+		}
+	}
+	return nil
+}
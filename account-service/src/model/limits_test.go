@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestCheckWithdrawalLimits(t *testing.T) {
+
+	daily := money.New(50000, "USD")
+	monthly := money.New(200000, "USD")
+	perHour := 3
+	limits := Limits{DailyWithdrawal: &daily, MonthlyWithdrawal: &monthly, WithdrawalsPerHour: &perHour}
+
+	usage := LimitUsage{Today: money.New(30000, "USD"), ThisMonth: money.New(100000, "USD"), LastHour: 1}
+
+	if err := limits.CheckWithdrawal(money.New(20000, "USD"), usage); err != nil {
+		t.Errorf("Expected the rest of the daily limit to be allowed, but got %v", err)
+	}
+
+	err := limits.CheckWithdrawal(money.New(20001, "USD"), usage)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected a LimitError, but got %v", err)
+	}
+	if limitErr.Rule != LimitDailyWithdrawal || limitErr.Remaining != "200.00 USD" {
+		t.Errorf("Expected 200.00 USD of the daily limit to remain, but got %s of %s", limitErr.Remaining, limitErr.Rule)
+	}
+
+	usage.ThisMonth = money.New(190000, "USD")
+	if err = limits.CheckWithdrawal(money.New(15000, "USD"), usage); !errors.As(err, &limitErr) || limitErr.Rule != LimitMonthlyWithdrawal {
+		t.Errorf("Expected the monthly limit to be exceeded, but got %v", err)
+	}
+
+	usage.LastHour = 3
+	if err = limits.CheckWithdrawal(money.New(100, "USD"), usage); !errors.As(err, &limitErr) || limitErr.Rule != LimitWithdrawalsPerHour {
+		t.Errorf("Expected the hourly limit to be exceeded, but got %v", err)
+	}
+
+	// withdrawal limits do not cap deposits
+	if err = limits.CheckDeposit(money.New(1000000, "USD"), usage); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+}
+
+func TestLimitsOverride(t *testing.T) {
+
+	typeDaily := money.New(50000, "")
+	typeMonthly := money.New(200000, "")
+	accountDaily := money.New(10000, "")
+
+	limits := Limits{DailyWithdrawal: &typeDaily, MonthlyWithdrawal: &typeMonthly}.
+		Override(Limits{DailyWithdrawal: &accountDaily}).
+		InCurrency("EUR")
+
+	if *limits.DailyWithdrawal != money.New(10000, "EUR") {
+		t.Errorf("Expected the account's daily limit, but got %s", limits.DailyWithdrawal)
+	}
+	if *limits.MonthlyWithdrawal != money.New(200000, "EUR") {
+		t.Errorf("Expected the account type's monthly limit, but got %s", limits.MonthlyWithdrawal)
+	}
+	if typeDaily.Currency != "" {
+		t.Errorf("Expected the account type's limits to be left unchanged")
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kinds of outbox event. Each kind is published to its own topic.
+const (
+	EventTransaction = "transaction"
+	EventAudit       = "audit"
+)
+
+// OutboxEvent is a message waiting in the outbox to be published to kafka
+type OutboxEvent struct {
+	ID   string
+	Seq  int64
+	Kind string
+	// Key is the kafka message key. Events with the same key are published
+	// in the order they were written.
+	Key       string
+	Account   string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// NewOutboxEvent wraps a transaction so it can be queued for publishing
+func NewOutboxEvent(transaction *Transaction) (*OutboxEvent, error) {
+	payload, err := json.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		ID:        transaction.ID,
+		Kind:      EventTransaction,
+		Key:       transaction.Account,
+		Account:   transaction.Account,
+		Payload:   payload,
+		CreatedAt: transaction.Timestamp,
+	}, nil
+}
+
+// NewAuditOutboxEvent wraps an audit event so it can be queued for publishing
+func NewAuditOutboxEvent(event *AuditEvent) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		ID:        event.ID,
+		Kind:      EventAudit,
+		Key:       event.Subject,
+		Payload:   payload,
+		CreatedAt: event.Timestamp,
+	}, nil
+}
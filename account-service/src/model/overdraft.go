@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/banking-app/protos/money"
+)
+
+// Account types
+const (
+	AccountSavings  = "savings"
+	AccountChecking = "checking"
+	AccountCredit   = "credit"
+)
+
+// CheckOverdraftLimit returns an error unless an account of the given type
+// may be given limit. Savings accounts cannot go below zero.
+func CheckOverdraftLimit(accountType string, limit money.Money) error {
+	if limit.IsNegative() {
+		return fmt.Errorf("overdraft limit cannot be negative")
+	}
+	if accountType == AccountSavings && !limit.IsZero() {
+		return fmt.Errorf("savings accounts cannot be overdrawn")
+	}
+	return nil
+}
+
+// ChargesOverdraftFee reports whether an account of the given type pays a
+// fee for each debit that leaves it overdrawn. A negative balance is the
+// normal state of a credit account, so it pays none.
+func ChargesOverdraftFee(accountType string) bool {
+	return accountType != AccountCredit
+}
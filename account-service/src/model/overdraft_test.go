@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestCheckOverdraftLimit(t *testing.T) {
+
+	if err := CheckOverdraftLimit(AccountChecking, money.New(10000, money.DefaultCurrency)); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	if err := CheckOverdraftLimit(AccountSavings, money.New(10000, money.DefaultCurrency)); err == nil {
+		t.Errorf("Expected a savings overdraft to be rejected")
+	}
+	if err := CheckOverdraftLimit(AccountCredit, money.New(-100, money.DefaultCurrency)); err == nil {
+		t.Errorf("Expected a negative limit to be rejected")
+	}
+}
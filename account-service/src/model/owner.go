@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Roles a user can hold on an account
+const (
+	RolePrimary   = "primary"
+	RoleJoint     = "joint"
+	RoleSignatory = "signatory"
+)
+
+// Operations on an account that are granted by role
+type Operation string
+
+const (
+	OpView         Operation = "view"
+	OpDeposit      Operation = "deposit"
+	OpWithdraw     Operation = "withdraw"
+	OpTransfer     Operation = "transfer"
+	OpUpdate       Operation = "update"
+	OpChangeStatus Operation = "change status"
+	OpManageOwners Operation = "manage owners"
+)
+
+// rolePermissions lists what each role may do. Joint owners run the account
+// alongside the primary owner; signatories may only move money.
+var rolePermissions = map[string][]Operation{
+	RolePrimary:   {OpView, OpDeposit, OpWithdraw, OpTransfer, OpUpdate, OpChangeStatus, OpManageOwners},
+	RoleJoint:     {OpView, OpDeposit, OpWithdraw, OpTransfer, OpUpdate, OpChangeStatus},
+	RoleSignatory: {OpView, OpDeposit, OpWithdraw, OpTransfer},
+}
+
+// AccountOwner links a user to an account with a role
+type AccountOwner struct {
+	AccountID string    `json:"accountId" db:"account_id"`
+	Email     string    `json:"email" db:"user_email"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// OwnedAccount is an account together with the role a user holds on it
+type OwnedAccount struct {
+	Account
+	Role string `json:"role"`
+}
+
+// RolePermits reports whether role allows op
+func RolePermits(role string, op Operation) bool {
+	for _, permitted := range rolePermissions[role] {
+		if permitted == op {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAccountOwner links email to an account as a joint owner or signatory.
+// Every account has exactly one primary owner, set when it is opened.
+func NewAccountOwner(accountID string, email string, role string) (*AccountOwner, error) {
+	if role != RoleJoint && role != RoleSignatory {
+		return nil, fmt.Errorf("role must be %s or %s", RoleJoint, RoleSignatory)
+	}
+	return &AccountOwner{
+		AccountID: accountID,
+		Email:     email,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestRolePermits(t *testing.T) {
+
+	tests := []struct {
+		role string
+		op   Operation
+		want bool
+	}{
+		{RolePrimary, OpManageOwners, true},
+		{RoleJoint, OpWithdraw, true},
+		{RoleJoint, OpManageOwners, false},
+		{RoleSignatory, OpWithdraw, true},
+		{RoleSignatory, OpChangeStatus, false},
+		{"", OpView, false},
+	}
+
+	for _, tt := range tests {
+		if got := RolePermits(tt.role, tt.op); got != tt.want {
+			t.Errorf("Expected RolePermits(%q, %q) to be %v but got %v", tt.role, tt.op, tt.want, got)
+		}
+	}
+}
+
+func TestNewAccountOwnerRejectsPrimary(t *testing.T) {
+
+	if _, err := NewAccountOwner("account", "yash@gmail.com", RolePrimary); err == nil {
+		t.Errorf("Expected a second primary owner to be rejected")
+	}
+	if _, err := NewAccountOwner("account", "yash@gmail.com", RoleJoint); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+}
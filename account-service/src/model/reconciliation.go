@@ -0,0 +1,133 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+// TransactionSummary is what transaction-service's history of an account
+// adds up to
+type TransactionSummary struct {
+	Account string `json:"account"`
+	// Balance is the sum of the stored transactions, debits taken away. Its
+	// currency is empty when nothing is stored.
+	Balance        money.Money `json:"balance"`
+	TransactionIDs []string    `json:"transactionIds"`
+}
+
+// AccountRecord is an account's balance and the transactions posted to it,
+// as this service recorded them
+type AccountRecord struct {
+	AccountID    string
+	Balance      money.Money
+	Transactions []Transaction
+	// Adjustments is how much of the balance was posted by ledger
+	// adjustments, which are not transactions and so are never published
+	Adjustments money.Money
+}
+
+// AccountAdjustment is how much ledger adjustments have moved an account's
+// balance without a transaction
+type AccountAdjustment struct {
+	AccountID string      `json:"accountId"`
+	Amount    money.Money `json:"amount"`
+}
+
+// AccountMismatch is an account whose balance and transaction history
+// disagree
+type AccountMismatch struct {
+	AccountID string      `json:"accountId"`
+	Balance   money.Money `json:"balance"`
+	// HistoryBalance is what transaction-service's history adds up to
+	HistoryBalance money.Money `json:"historyBalance"`
+	// Missing are transactions posted here that transaction-service does
+	// not have
+	Missing []string `json:"missing"`
+	// Extra are transactions transaction-service has that were never
+	// posted here
+	Extra []string `json:"extra"`
+	// Republished is how many missing transactions were queued to be
+	// published again
+	Republished int `json:"republished,omitempty"`
+}
+
+// ReconciliationReport is the outcome of reconciling accounts against their
+// transaction history
+type ReconciliationReport struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Accounts   int               `json:"accounts"`
+	Mismatches []AccountMismatch `json:"mismatches"`
+	// Failed maps accounts that could not be reconciled to why
+	Failed      map[string]string `json:"failed,omitempty"`
+	Republished int               `json:"republished"`
+	// Adjusted are the accounts whose balance ledger adjustments moved.
+	// transaction-service never sees adjustments, so they are left out of
+	// the balance compared and listed here instead.
+	Adjusted []AccountAdjustment `json:"adjusted"`
+}
+
+// Reconcile compares an account's record with its transaction history and
+// returns the mismatch, or nil if they agree. Transactions posted at or
+// after settledAfter may not have reached transaction-service yet, so they
+// are neither reported missing nor counted in the balance compared, and
+// neither are ledger adjustments.
+func Reconcile(record *AccountRecord, summary *TransactionSummary, settledAfter time.Time) (*AccountMismatch, error) {
+	mismatch := &AccountMismatch{
+		AccountID:      record.AccountID,
+		Balance:        record.Balance,
+		HistoryBalance: summary.Balance,
+		Missing:        []string{},
+		Extra:          []string{},
+	}
+	if mismatch.HistoryBalance.Currency == "" {
+		mismatch.HistoryBalance = money.Zero(record.Balance.Currency)
+	}
+
+	stored := make(map[string]bool, len(summary.TransactionIDs))
+	for _, id := range summary.TransactionIDs {
+		stored[id] = true
+	}
+	posted := make(map[string]bool, len(record.Transactions))
+	expected := record.Balance
+	if !record.Adjustments.IsZero() {
+		var err error
+		if expected, err = expected.Sub(record.Adjustments); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range record.Transactions {
+		posted[t.ID] = true
+		if stored[t.ID] {
+			continue
+		}
+		if t.Timestamp.Before(settledAfter) {
+			mismatch.Missing = append(mismatch.Missing, t.ID)
+			continue
+		}
+		// still on its way, so take it back out of the balance compared
+		amount := t.Amount
+		if IsDebitType(t.Type) {
+			amount = amount.Neg()
+		}
+		var err error
+		if expected, err = expected.Sub(amount); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range summary.TransactionIDs {
+		if !posted[id] {
+			mismatch.Extra = append(mismatch.Extra, id)
+		}
+	}
+
+	cmp, err := expected.Cmp(mismatch.HistoryBalance)
+	if err != nil {
+		return nil, err
+	}
+	if cmp == 0 && len(mismatch.Missing) == 0 && len(mismatch.Extra) == 0 {
+		return nil, nil
+	}
+	return mismatch, nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestReconcile(t *testing.T) {
+
+	now := time.Now()
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	record := &AccountRecord{
+		AccountID: "account",
+		Balance:   usd(9000),
+		Transactions: []Transaction{
+			{ID: "opening", Amount: usd(10000), Type: "opening", Timestamp: now.Add(-time.Hour)},
+			{ID: "debit", Amount: usd(4000), Type: "debit", Timestamp: now.Add(-time.Hour)},
+			{ID: "credit", Amount: usd(3000), Type: "credit", Timestamp: now},
+		},
+	}
+	settledAfter := now.Add(-time.Minute)
+
+	// the credit is still on its way
+	agreed := &TransactionSummary{Balance: usd(6000), TransactionIDs: []string{"opening", "debit"}}
+	if mismatch, err := Reconcile(record, agreed, settledAfter); err != nil || mismatch != nil {
+		t.Errorf("Expected the account to reconcile but got %+v, %v", mismatch, err)
+	}
+
+	// the debit was lost, and something never posted here was stored
+	diverged := &TransactionSummary{Balance: usd(10500), TransactionIDs: []string{"opening", "stray"}}
+	mismatch, err := Reconcile(record, diverged, settledAfter)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if mismatch == nil {
+		t.Fatalf("Expected a mismatch")
+	}
+	if len(mismatch.Missing) != 1 || mismatch.Missing[0] != "debit" {
+		t.Errorf("Expected the debit to be missing but got %v", mismatch.Missing)
+	}
+	if len(mismatch.Extra) != 1 || mismatch.Extra[0] != "stray" {
+		t.Errorf("Expected the stray transaction to be extra but got %v", mismatch.Extra)
+	}
+
+	// nothing stored at all
+	empty := &TransactionSummary{TransactionIDs: []string{}}
+	mismatch, err = Reconcile(record, empty, settledAfter)
+	if err != nil || mismatch == nil || mismatch.HistoryBalance != usd(0) {
+		t.Errorf("Expected a mismatch against an empty history but got %+v, %v", mismatch, err)
+	}
+
+	// an adjustment of 25.00 moved the balance, but was never published
+	adjusted := *record
+	adjusted.Balance = usd(11500)
+	adjusted.Adjustments = usd(2500)
+	if mismatch, err := Reconcile(&adjusted, agreed, settledAfter); err != nil || mismatch != nil {
+		t.Errorf("Expected the adjusted account to reconcile but got %+v, %v", mismatch, err)
+	}
+}
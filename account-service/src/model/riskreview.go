@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+
+	"github.com/google/uuid"
+)
+
+// Risk review statuses. Held postings wait as pending until an admin
+// approves or rejects them; blocked ones are kept for the record only.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+	ReviewBlocked  = "blocked"
+)
+
+// RiskReview is a deposit or withdrawal the risk rules held or blocked
+// instead of posting it
+type RiskReview struct {
+	ID          string      `json:"id" db:"id"`
+	AccountID   string      `json:"accountId" db:"account_id"`
+	Operation   Operation   `json:"operation" db:"operation"`
+	Amount      money.Money `json:"amount" db:"amount"`
+	Rule        string      `json:"rule" db:"rule"`
+	Reason      string      `json:"reason" db:"reason"`
+	Status      string      `json:"status" db:"status"`
+	RequestedBy string      `json:"requestedBy" db:"requested_by"`
+	// TransactionID is the transaction posted when the review was approved
+	TransactionID  string     `json:"transactionId,omitempty" db:"transaction_id"`
+	DecidedBy      string     `json:"decidedBy,omitempty" db:"decided_by"`
+	DecisionReason string     `json:"decisionReason,omitempty" db:"decision_reason"`
+	DecidedAt      *time.Time `json:"decidedAt,omitempty" db:"decided_at"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NewRiskReview records a posting the risk engine did not allow. A posting
+// held for review starts pending, a blocked one is blocked for good.
+func NewRiskReview(accountID string, op Operation, amount money.Money, decision risk.Decision, requestedBy string) *RiskReview {
+	status := ReviewPending
+	if decision.Outcome == risk.Block {
+		status = ReviewBlocked
+	}
+	return &RiskReview{
+		ID:          uuid.New().String(),
+		AccountID:   accountID,
+		Operation:   op,
+		Amount:      amount,
+		Rule:        decision.Rule,
+		Reason:      decision.Reason,
+		Status:      status,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// CheckReviewDecision returns an error unless a review in status from may be
+// decided as to. Only pending reviews can be approved or rejected.
+func CheckReviewDecision(from string, to string) error {
+	if to != ReviewApproved && to != ReviewRejected {
+		return fmt.Errorf("invalid review decision %q", to)
+	}
+	if from != ReviewPending {
+		return fmt.Errorf("review is already %s", from)
+	}
+	return nil
+}
+
+// RiskError is returned when the risk rules hold a posting for review or
+// block it. Nothing is posted either way.
+type RiskError struct {
+	ReviewID string       `json:"reviewId"`
+	Outcome  risk.Outcome `json:"outcome"`
+}
+
+func (e *RiskError) Error() string {
+	if e.Outcome == risk.Block {
+		return "transaction was blocked"
+	}
+	return "transaction is held for review"
+}
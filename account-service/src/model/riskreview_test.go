@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+)
+
+func TestNewRiskReviewStatus(t *testing.T) {
+
+	amount := money.New(100000, money.DefaultCurrency)
+
+	held := NewRiskReview("account", OpWithdraw, amount, risk.Decision{Outcome: risk.Review, Rule: "unusual_amount"}, "yash@gmail.com")
+	if held.Status != ReviewPending {
+		t.Errorf("Expected a held posting to be %s but got %s", ReviewPending, held.Status)
+	}
+	blocked := NewRiskReview("account", OpWithdraw, amount, risk.Decision{Outcome: risk.Block, Rule: "unusual_amount"}, "yash@gmail.com")
+	if blocked.Status != ReviewBlocked {
+		t.Errorf("Expected a blocked posting to be %s but got %s", ReviewBlocked, blocked.Status)
+	}
+}
+
+func TestCheckReviewDecision(t *testing.T) {
+
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{ReviewPending, ReviewApproved, true},
+		{ReviewPending, ReviewRejected, true},
+		{ReviewPending, ReviewBlocked, false},
+		{ReviewApproved, ReviewRejected, false},
+		{ReviewBlocked, ReviewApproved, false},
+	}
+
+	for _, tt := range tests {
+		if err := CheckReviewDecision(tt.from, tt.to); (err == nil) != tt.ok {
+			t.Errorf("Expected CheckReviewDecision(%q, %q) ok to be %v but got %v", tt.from, tt.to, tt.ok, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+	"github.com/banking-app/protos/money"
+
+	"github.com/google/uuid"
+)
+
+// How often a standing order pays
+const (
+	FrequencyOnce    = "once"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// Standing order statuses. Only active orders are executed.
+const (
+	OrderActive    = "active"
+	OrderCompleted = "completed"
+	OrderCancelled = "cancelled"
+	OrderFailed    = "failed"
+)
+
+// StandingOrder pays a fixed amount from one account to another on a
+// schedule, starting on StartDate and stopping after EndDate or
+// MaxOccurrences payments, whichever comes first
+type StandingOrder struct {
+	ID             string      `json:"id" db:"id"`
+	FromAccount    string      `json:"fromAccount" db:"from_account"`
+	ToAccount      string      `json:"toAccount" db:"to_account"`
+	Amount         money.Money `json:"amount" db:"amount"`
+	Frequency      string      `json:"frequency" db:"frequency"`
+	StartDate      time.Time   `json:"startDate" db:"start_date"`
+	EndDate        *time.Time  `json:"endDate,omitempty" db:"end_date"`
+	MaxOccurrences int         `json:"maxOccurrences,omitempty" db:"max_occurrences"`
+	// Occurrences counts the occurrences done, paid or missed
+	Occurrences int       `json:"occurrences" db:"occurrences"`
+	NextRunDate time.Time `json:"nextRunDate" db:"next_run_date"`
+	Status      string    `json:"status" db:"status"`
+	// FailedAttempts counts the failed attempts at the next occurrence
+	FailedAttempts int       `json:"failedAttempts" db:"failed_attempts"`
+	LastError      string    `json:"lastError,omitempty" db:"last_error"`
+	Reference      string    `json:"reference,omitempty" db:"reference"`
+	CreatedBy      string    `json:"createdBy" db:"created_by"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// NewStandingOrderFromProto builds a standing order paying out of
+// fromAccount. A missing start date means today, and a one-off order is a
+// single occurrence.
+func NewStandingOrderFromProto(req *accountpb.CreateStandingOrderRequest, fromAccount string, createdBy string) (*StandingOrder, error) {
+	if req.ToAccount == "" {
+		return nil, fmt.Errorf("to_account is required")
+	}
+	if req.ToAccount == fromAccount {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+	if req.Amount == nil {
+		return nil, fmt.Errorf("amount is required")
+	}
+	amount, err := money.FromProto(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("standing order amount must be positive")
+	}
+
+	o := &StandingOrder{
+		ID:             uuid.New().String(),
+		FromAccount:    fromAccount,
+		ToAccount:      req.ToAccount,
+		Amount:         amount,
+		Frequency:      req.Frequency,
+		StartDate:      StartOfDay(time.Now()),
+		MaxOccurrences: int(req.MaxOccurrences),
+		Status:         OrderActive,
+		Reference:      req.Reference,
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	switch o.Frequency {
+	case FrequencyOnce:
+		o.MaxOccurrences = 1
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return nil, fmt.Errorf("frequency must be once, daily, weekly or monthly")
+	}
+
+	if req.StartDate != "" {
+		start, err := time.Parse(DateLayout, req.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("start_date must be a date like 2024-01-31")
+		}
+		if start.Before(o.StartDate) {
+			return nil, fmt.Errorf("start_date cannot be in the past")
+		}
+		o.StartDate = start
+	}
+	if err = o.SetLimits(req.EndDate, int(req.MaxOccurrences)); err != nil {
+		return nil, err
+	}
+	o.NextRunDate = o.StartDate
+	return o, nil
+}
+
+// SetLimits sets when an order stops: after endDate, if given, and after
+// maxOccurrences payments, if positive
+func (o *StandingOrder) SetLimits(endDate string, maxOccurrences int) error {
+	if maxOccurrences < 0 {
+		return fmt.Errorf("max_occurrences cannot be negative")
+	}
+	if o.Frequency != FrequencyOnce {
+		o.MaxOccurrences = maxOccurrences
+	}
+	if o.MaxOccurrences > 0 && o.MaxOccurrences < o.Occurrences {
+		return fmt.Errorf("order has already run %d times", o.Occurrences)
+	}
+
+	o.EndDate = nil
+	if endDate != "" {
+		end, err := time.Parse(DateLayout, endDate)
+		if err != nil {
+			return fmt.Errorf("end_date must be a date like 2024-01-31")
+		}
+		if end.Before(o.StartDate) {
+			return fmt.Errorf("end_date cannot be before start_date")
+		}
+		o.EndDate = &end
+	}
+	return nil
+}
+
+// OccurrenceDate returns the date of the nth occurrence, counting from 0.
+// Monthly orders started late in a month fall on the last day of shorter
+// months.
+func (o *StandingOrder) OccurrenceDate(n int) time.Time {
+	switch o.Frequency {
+	case FrequencyDaily:
+		return o.StartDate.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		return o.StartDate.AddDate(0, 0, 7*n)
+	case FrequencyMonthly:
+		month := StartOfMonth(o.StartDate).AddDate(0, n, 0)
+		lastDay := month.AddDate(0, 1, -1).Day()
+		day := o.StartDate.Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return month.AddDate(0, 0, day-1)
+	}
+	return o.StartDate
+}
+
+// Advance moves an order past its next occurrence, completing it if that
+// was its last
+func (o *StandingOrder) Advance() {
+	o.Occurrences++
+	o.FailedAttempts = 0
+	o.NextRunDate = o.OccurrenceDate(o.Occurrences)
+	if o.Finished() {
+		o.Status = OrderCompleted
+	}
+}
+
+// Finished reports whether an order has no occurrences left
+func (o *StandingOrder) Finished() bool {
+	if o.MaxOccurrences > 0 && o.Occurrences >= o.MaxOccurrences {
+		return true
+	}
+	return o.EndDate != nil && o.NextRunDate.After(*o.EndDate)
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	accountpb "github.com/banking-app/protos/generated/account"
+	moneypb "github.com/banking-app/protos/generated/money"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(DateLayout, s)
+	return t
+}
+
+func TestOccurrenceDateClampsMonthEnds(t *testing.T) {
+
+	o := &StandingOrder{Frequency: FrequencyMonthly, StartDate: date("2024-01-31")}
+
+	want := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}
+	for n, w := range want {
+		if got := o.OccurrenceDate(n).Format(DateLayout); got != w {
+			t.Errorf("Expected occurrence %d on %s but got %s", n, w, got)
+		}
+	}
+}
+
+func TestAdvanceCompletesAfterLastOccurrence(t *testing.T) {
+
+	end := date("2024-01-15")
+	o := &StandingOrder{Frequency: FrequencyWeekly, StartDate: date("2024-01-01"), EndDate: &end, Status: OrderActive}
+	o.NextRunDate = o.StartDate
+
+	o.Advance()
+	if o.Status != OrderActive || o.NextRunDate.Format(DateLayout) != "2024-01-08" {
+		t.Errorf("Expected the order to run again on 2024-01-08 but got %s %s", o.Status, o.NextRunDate.Format(DateLayout))
+	}
+	o.Advance()
+	o.Advance()
+	if o.Status != OrderCompleted {
+		t.Errorf("Expected the order to be completed after its end date but got %s", o.Status)
+	}
+
+	once := &StandingOrder{Frequency: FrequencyOnce, MaxOccurrences: 1, Status: OrderActive}
+	once.Advance()
+	if once.Status != OrderCompleted {
+		t.Errorf("Expected a one-off order to complete after one payment but got %s", once.Status)
+	}
+}
+
+func TestNewStandingOrderFromProto(t *testing.T) {
+
+	req := &accountpb.CreateStandingOrderRequest{
+		ToAccount: "to",
+		Amount:    &moneypb.Money{Value: "25.00"},
+		Frequency: "fortnightly",
+	}
+	if _, err := NewStandingOrderFromProto(req, "from", "yash@gmail.com"); err == nil {
+		t.Errorf("Expected an unknown frequency to be rejected")
+	}
+
+	req.Frequency = FrequencyOnce
+	req.MaxOccurrences = 5
+	o, err := NewStandingOrderFromProto(req, "from", "yash@gmail.com")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if o.MaxOccurrences != 1 || !o.NextRunDate.Equal(StartOfDay(time.Now())) {
+		t.Errorf("Expected a single occurrence today but got %d on %s", o.MaxOccurrences, o.NextRunDate)
+	}
+
+	req.StartDate = "2000-01-01"
+	if _, err = NewStandingOrderFromProto(req, "from", "yash@gmail.com"); err == nil {
+		t.Errorf("Expected a start date in the past to be rejected")
+	}
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+// MonthLayout is how statement months are written, e.g. 2024-03
+const MonthLayout = "2006-01"
+
+// ErrStatementNotFound is returned when no statement has been stored for a
+// month
+var ErrStatementNotFound = errors.New("statement not found")
+
+// ErrMonthNotEnded is returned when a statement is asked for before its month
+// is over
+var ErrMonthNotEnded = errors.New("the month has not ended")
+
+// ErrStatementNotReady is returned when a month's history is not complete
+// enough yet to be stored as its statement
+var ErrStatementNotReady = errors.New("the statement is not ready yet")
+
+// Statement is an account's transactions for a calendar month, with the
+// balance before and after each of them
+type Statement struct {
+	AccountID      string          `json:"accountId"`
+	AccountHolder  string          `json:"accountHolder"`
+	AccountType    string          `json:"accountType"`
+	Month          string          `json:"month"`
+	OpeningBalance money.Money     `json:"openingBalance"`
+	Lines          []StatementLine `json:"lines"`
+	TotalCredits   money.Money     `json:"totalCredits"`
+	TotalDebits    money.Money     `json:"totalDebits"`
+	ClosingBalance money.Money     `json:"closingBalance"`
+	GeneratedAt    time.Time       `json:"generatedAt"`
+}
+
+// StatementLine is one transaction on a statement. Amount is negative for
+// debits, and Balance is the running balance after it.
+type StatementLine struct {
+	TransactionID string      `json:"transactionId"`
+	Date          time.Time   `json:"date"`
+	Description   string      `json:"description"`
+	Amount        money.Money `json:"amount"`
+	Balance       money.Money `json:"balance"`
+}
+
+// StatementBalances is what the ledger records of an account's month: its
+// balance at either end, the adjustments posted in it, which are not
+// transactions, and how many of its transactions are not published yet
+type StatementBalances struct {
+	Opening     money.Money
+	Closing     money.Money
+	Adjustments []LedgerAdjustment
+	Unsent      int
+}
+
+// LedgerAdjustment is a ledger adjustment posted to an account
+type LedgerAdjustment struct {
+	ID     string
+	Amount money.Money
+	At     time.Time
+}
+
+// StoredStatement is a statement as it was first generated, with its
+// renderings, so that downloading it again gives the same files even after
+// the account's history is corrected
+type StoredStatement struct {
+	Statement Statement
+	CSV       []byte
+	PDF       []byte
+}
+
+// NewStatement builds the statement of an account for month from the
+// ledger's balances at either end of it and the month's transactions. The
+// transactions, and any adjustments, must add up from the opening to the
+// closing balance, so that a statement missing a transaction transaction-
+// service has not stored yet is never produced.
+func NewStatement(account *Account, month time.Time, balances *StatementBalances, transactions []Transaction, now time.Time) (*Statement, error) {
+	start := StartOfMonth(month)
+	end := start.AddDate(0, 1, 0)
+	if end.After(now) {
+		return nil, fmt.Errorf("no statement for %s yet: %w", start.Format(MonthLayout), ErrMonthNotEnded)
+	}
+	if balances.Unsent > 0 {
+		return nil, fmt.Errorf("%d transactions up to the end of %s are still being published: %w", balances.Unsent, start.Format(MonthLayout), ErrStatementNotReady)
+	}
+
+	currency := account.Balance.Currency
+	s := &Statement{
+		AccountID:      account.ID,
+		AccountHolder:  account.FirstName + " " + account.LastName,
+		AccountType:    account.Type,
+		Month:          start.Format(MonthLayout),
+		OpeningBalance: balances.Opening,
+		Lines:          []StatementLine{},
+		TotalCredits:   money.Zero(currency),
+		TotalDebits:    money.Zero(currency),
+		ClosingBalance: balances.Closing,
+		GeneratedAt:    now,
+	}
+
+	for _, t := range transactions {
+		if t.Timestamp.Before(start) || !t.Timestamp.Before(end) {
+			continue
+		}
+		s.Lines = append(s.Lines, StatementLine{
+			TransactionID: t.ID,
+			Date:          t.Timestamp,
+			Description:   describeTransaction(t),
+			Amount:        signedAmount(t),
+		})
+	}
+	for _, a := range balances.Adjustments {
+		s.Lines = append(s.Lines, StatementLine{
+			TransactionID: a.ID,
+			Date:          a.At,
+			Description:   "Balance adjustment",
+			Amount:        a.Amount,
+		})
+	}
+	sort.SliceStable(s.Lines, func(i, j int) bool {
+		if s.Lines[i].Date.Equal(s.Lines[j].Date) {
+			return s.Lines[i].TransactionID < s.Lines[j].TransactionID
+		}
+		return s.Lines[i].Date.Before(s.Lines[j].Date)
+	})
+
+	balance := s.OpeningBalance
+	var err error
+	for i, line := range s.Lines {
+		if line.Amount.Currency != currency {
+			return nil, fmt.Errorf("transaction %s currency %s does not match account currency %s", line.TransactionID, line.Amount.Currency, currency)
+		}
+		if line.Amount.IsNegative() {
+			s.TotalDebits, err = s.TotalDebits.Add(line.Amount.Neg())
+		} else {
+			s.TotalCredits, err = s.TotalCredits.Add(line.Amount)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if balance, err = balance.Add(line.Amount); err != nil {
+			return nil, err
+		}
+		s.Lines[i].Balance = balance
+	}
+
+	cmp, err := balance.Cmp(s.ClosingBalance)
+	if err != nil {
+		return nil, err
+	}
+	if cmp != 0 {
+		return nil, fmt.Errorf("the transactions stored for %s come to %s, not the closing balance of %s: %w", s.Month, balance, s.ClosingBalance, ErrStatementNotReady)
+	}
+	return s, nil
+}
+
+// signedAmount is a transaction's amount, negative if it is a debit
+func signedAmount(t Transaction) money.Money {
+	if IsDebitType(t.Type) {
+		return t.Amount.Neg()
+	}
+	return t.Amount
+}
+
+// describeTransaction is how a transaction is described on a statement
+func describeTransaction(t Transaction) string {
+	switch {
+	case t.ReversalOf != "":
+		return fmt.Sprintf("%s of %s", titles[t.Type], shortID(t.ReversalOf))
+	case t.Type == "closing":
+		return titles[t.Type]
+	case t.TransferID != "" && IsDebitType(t.Type):
+		return fmt.Sprintf("Transfer out %s", shortID(t.TransferID))
+	case t.TransferID != "":
+		return fmt.Sprintf("Transfer in %s", shortID(t.TransferID))
+	}
+	if title, ok := titles[t.Type]; ok {
+		return title
+	}
+	return t.Type
+}
+
+var titles = map[string]string{
+	"opening":  "Opening deposit",
+	"credit":   "Deposit",
+	"debit":    "Withdrawal",
+	"fee":      "Overdraft fee",
+	"interest": "Interest",
+	"closing":  "Account closed",
+	"refund":   "Refund",
+	"reversal": "Reversal",
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
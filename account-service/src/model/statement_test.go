@@ -0,0 +1,79 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewStatement(t *testing.T) {
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	at := func(day int) time.Time { return time.Date(2024, time.March, day, 12, 0, 0, 0, time.UTC) }
+	account := &Account{ID: "account", FirstName: "Ada", LastName: "Lovelace", Type: "checking", Balance: usd(12500)}
+	balances := &StatementBalances{
+		Opening:     usd(8000),
+		Closing:     usd(9500),
+		Adjustments: []LedgerAdjustment{{ID: "adjustment", Amount: usd(-500), At: at(20)}},
+	}
+	transactions := []Transaction{
+		// after the month, so left off
+		{ID: "april", Amount: usd(2500), Type: "credit", Timestamp: time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "debit", Amount: usd(3000), Type: "debit", Timestamp: at(10)},
+		{ID: "credit", Amount: usd(5000), Type: "credit", Timestamp: at(3)},
+	}
+
+	s, err := NewStatement(account, at(1), balances, transactions, time.Date(2024, time.April, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if s.Month != "2024-03" || s.AccountHolder != "Ada Lovelace" {
+		t.Errorf("Expected the statement of Ada Lovelace for 2024-03, but got %s for %s", s.AccountHolder, s.Month)
+	}
+	if s.ClosingBalance != usd(9500) || s.OpeningBalance != usd(8000) {
+		t.Errorf("Expected balances of 80.00 to 95.00, but got %s to %s", s.OpeningBalance, s.ClosingBalance)
+	}
+	if s.TotalCredits != usd(5000) || s.TotalDebits != usd(3500) {
+		t.Errorf("Expected totals of 50.00 in and 35.00 out, but got %s and %s", s.TotalCredits, s.TotalDebits)
+	}
+	if len(s.Lines) != 3 || s.Lines[0].TransactionID != "credit" || s.Lines[1].TransactionID != "debit" || s.Lines[2].Description != "Balance adjustment" {
+		t.Fatalf("Expected the month's transactions and adjustment oldest first, but got %+v", s.Lines)
+	}
+	if s.Lines[0].Balance != usd(13000) || s.Lines[1].Amount != usd(-3000) || s.Lines[1].Balance != usd(10000) || s.Lines[2].Balance != usd(9500) {
+		t.Errorf("Expected running balances of 130.00, 100.00 and 95.00, but got %+v", s.Lines)
+	}
+}
+
+func TestNewStatementNotReady(t *testing.T) {
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	account := &Account{ID: "account", Balance: usd(0)}
+	month := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.April, 5, 0, 0, 0, 0, time.UTC)
+	credit := Transaction{ID: "credit", Amount: usd(5000), Type: "credit", Timestamp: month.Add(time.Hour)}
+
+	// an event of the month is still in the outbox
+	_, err := NewStatement(account, month, &StatementBalances{Opening: usd(0), Closing: usd(5000), Unsent: 1}, []Transaction{credit}, now)
+	if !errors.Is(err, ErrStatementNotReady) {
+		t.Errorf("Expected ErrStatementNotReady while events are unsent, but got %v", err)
+	}
+
+	// transaction-service has not stored every transaction of the month
+	_, err = NewStatement(account, month, &StatementBalances{Opening: usd(0), Closing: usd(7500)}, []Transaction{credit}, now)
+	if !errors.Is(err, ErrStatementNotReady) {
+		t.Errorf("Expected ErrStatementNotReady when the history falls short, but got %v", err)
+	}
+}
+
+func TestNewStatementBeforeMonthEnds(t *testing.T) {
+
+	account := &Account{ID: "account", Balance: money.New(0, "USD")}
+	month := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := NewStatement(account, month, &StatementBalances{}, nil, time.Date(2024, time.March, 31, 23, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrMonthNotEnded) {
+		t.Errorf("Expected ErrMonthNotEnded, but got %v", err)
+	}
+}
@@ -1,25 +1,130 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/banking-app/protos/money"
 	"github.com/google/uuid"
 )
 
+// debitTypes are the transaction types that take money out of an account.
+// Every other type pays money in.
+var debitTypes = []string{"debit", "closing", "fee", "reversal"}
+
+// reversalTypes maps each type of transaction that can be reversed to the
+// type of the transaction that compensates it. A refund pays back a debit;
+// a reversal takes back a credit.
+var reversalTypes = map[string]string{
+	"debit":    "refund",
+	"fee":      "refund",
+	"credit":   "reversal",
+	"interest": "reversal",
+}
+
+// ErrTransactionReversed is returned when a transaction has already been
+// reversed
+var ErrTransactionReversed = errors.New("transaction has already been reversed")
+
+// DebitTypes returns the transaction types that take money out of an account
+func DebitTypes() []string {
+	return append([]string(nil), debitTypes...)
+}
+
+// IsDebitType reports whether transactions of the given type take money out
+// of an account
+func IsDebitType(transactionType string) bool {
+	for _, t := range debitTypes {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
+
 type Transaction struct {
-	ID        string    `json:"id"`
-	Account    string    `json:"account"`
-	Amount    float64       `json:"amount"`
-	Type      string    `json:"type"`
-	Timestamp time.Time `json:"timestamp"`
+	ID         string      `json:"id"`
+	Account    string      `json:"account"`
+	Amount     money.Money `json:"amount"`
+	Type       string      `json:"type"`
+	TransferID string      `json:"transferId,omitempty"`
+	FxRate     string      `json:"fxRate,omitempty"`
+	// Reference is free text the customer gave the payment, such as a hold's
+	// or a standing order's reference
+	Reference string `json:"reference,omitempty"`
+	// ReversalOf is the transaction this one reverses
+	ReversalOf string `json:"reversalOf,omitempty"`
+	// ReversedBy is the transaction that reversed this one, as recorded by
+	// the transaction service
+	ReversedBy string `json:"reversedBy,omitempty"`
+	// Seq numbers an account's transactions in the order they were posted,
+	// from 1, and BalanceAfter is the account's balance once this one was
+	BalanceAfter *money.Money `json:"balanceAfter,omitempty"`
+	Seq          int64        `json:"seq,omitempty"`
+	Timestamp    time.Time    `json:"timestamp"`
 }
 
-func NewTransaction(Account string, Amount float64, Type string) *Transaction {
+// TransactionPage is one page of a transaction search, as transaction-service
+// returns it. NextCursor is opaque and empty on the last page.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"nextCursor,omitempty"`
+}
+
+func NewTransaction(Account string, Amount money.Money, Type string) *Transaction {
 	return &Transaction{
 		ID:        uuid.New().String(),
 		Account:   Account,
-		Amount:    Amount,	
+		Amount:    Amount,
 		Type:      Type,
 		Timestamp: time.Now(),
 	}
 }
+
+// Sequence numbers a transaction seq on its account and works out the
+// balance after it from the balance before
+func (t *Transaction) Sequence(seq int64, balance money.Money) error {
+	after, err := balance.Add(signedAmount(*t))
+	if err != nil {
+		return fmt.Errorf("transaction %s: %w", t.ID, err)
+	}
+	t.Seq = seq
+	t.BalanceAfter = &after
+	return nil
+}
+
+// NewTransferTransactions returns the debit and credit legs of a transfer,
+// linked by a shared transfer id. The legs differ in amount when the transfer
+// crosses currencies.
+func NewTransferTransactions(fromAccount string, toAccount string, debitAmount money.Money, creditAmount money.Money) (*Transaction, *Transaction) {
+	transferID := uuid.New().String()
+
+	debit := NewTransaction(fromAccount, debitAmount, "debit")
+	debit.TransferID = transferID
+
+	credit := NewTransaction(toAccount, creditAmount, "credit")
+	credit.TransferID = transferID
+	credit.Timestamp = debit.Timestamp
+
+	return debit, credit
+}
+
+// NewReversal returns the transaction that compensates the original, for the
+// same amount in the opposite direction. Transfer legs cannot be reversed on
+// their own, and neither can reversals.
+func NewReversal(original *Transaction) (*Transaction, error) {
+	if original.ReversedBy != "" {
+		return nil, ErrTransactionReversed
+	}
+	if original.TransferID != "" {
+		return nil, fmt.Errorf("transfer legs cannot be reversed, transfer the funds back instead")
+	}
+	reversalType, ok := reversalTypes[original.Type]
+	if !ok {
+		return nil, fmt.Errorf("%s transactions cannot be reversed", original.Type)
+	}
+	reversal := NewTransaction(original.Account, original.Amount, reversalType)
+	reversal.ReversalOf = original.ID
+	return reversal, nil
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewReversal(t *testing.T) {
+
+	tests := []struct {
+		originalType string
+		reversalType string
+	}{
+		{"debit", "refund"},
+		{"fee", "refund"},
+		{"credit", "reversal"},
+		{"interest", "reversal"},
+	}
+
+	for _, tt := range tests {
+		original := NewTransaction("account", money.New(2500, "USD"), tt.originalType)
+		reversal, err := NewReversal(original)
+		if err != nil {
+			t.Errorf("%s: expected error to be nil, but got %v", tt.originalType, err)
+			continue
+		}
+		if reversal.Type != tt.reversalType {
+			t.Errorf("%s: expected a %s but got %s", tt.originalType, tt.reversalType, reversal.Type)
+		}
+		if reversal.ReversalOf != original.ID || reversal.Account != original.Account || reversal.Amount != original.Amount {
+			t.Errorf("%s: expected the reversal to undo the original but got %+v", tt.originalType, reversal)
+		}
+		if IsDebitType(reversal.Type) == IsDebitType(original.Type) {
+			t.Errorf("%s: expected the reversal to move money the other way", tt.originalType)
+		}
+	}
+}
+
+func TestNewReversalRefused(t *testing.T) {
+
+	reversed := NewTransaction("account", money.New(2500, "USD"), "debit")
+	reversed.ReversedBy = "refund"
+	if _, err := NewReversal(reversed); !errors.Is(err, ErrTransactionReversed) {
+		t.Errorf("Expected a reversed transaction not to be reversed again but got %v", err)
+	}
+
+	leg, _ := NewTransferTransactions("from", "to", money.New(2500, "USD"), money.New(2500, "USD"))
+	if _, err := NewReversal(leg); err == nil {
+		t.Errorf("Expected a transfer leg not to be reversed")
+	}
+
+	for _, transactionType := range []string{"opening", "closing", "refund", "reversal"} {
+		if _, err := NewReversal(NewTransaction("account", money.New(2500, "USD"), transactionType)); err == nil {
+			t.Errorf("Expected %s transactions not to be reversed", transactionType)
+		}
+	}
+}
+
+func TestSequence(t *testing.T) {
+
+	debit := NewTransaction("account", money.New(2500, "USD"), "debit")
+	if err := debit.Sequence(7, money.New(10000, "USD")); err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if debit.Seq != 7 || debit.BalanceAfter == nil || *debit.BalanceAfter != money.New(7500, "USD") {
+		t.Errorf("Expected seq 7 and a balance of 75.00 after, but got %d and %v", debit.Seq, debit.BalanceAfter)
+	}
+
+	credit := NewTransaction("account", money.New(2500, "EUR"), "credit")
+	if err := credit.Sequence(1, money.New(0, "USD")); err == nil {
+		t.Errorf("Expected a transaction in another currency than its account to be refused")
+	}
+}
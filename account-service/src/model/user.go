@@ -0,0 +1,39 @@
+package model
+
+import "fmt"
+
+// User statuses. A user is pending until their first login, and is locked
+// after too many failed logins in a row.
+const (
+	UserPending  = "pending"
+	UserActive   = "active"
+	UserDisabled = "disabled"
+	UserLocked   = "locked"
+)
+
+// userTransitions lists the statuses each status can move to
+var userTransitions = map[string][]string{
+	UserPending:  {UserActive, UserDisabled, UserLocked},
+	UserActive:   {UserDisabled, UserLocked},
+	UserLocked:   {UserActive, UserDisabled},
+	UserDisabled: {UserActive},
+}
+
+// CheckUserTransition returns an error unless a user may move from one
+// status to another
+func CheckUserTransition(from string, to string) error {
+	if from == to {
+		return fmt.Errorf("user is already %s", to)
+	}
+	for _, next := range userTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("user cannot go from %s to %s", from, to)
+}
+
+// CanSignIn reports whether a user with the given status may log in
+func CanSignIn(status string) bool {
+	return status == UserPending || status == UserActive
+}
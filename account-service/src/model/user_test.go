@@ -0,0 +1,41 @@
+package model
+
+import "testing"
+
+func TestCheckUserTransition(t *testing.T) {
+
+	tests := []struct {
+		from string
+		to   string
+		ok   bool
+	}{
+		{UserPending, UserActive, true},
+		{UserActive, UserLocked, true},
+		{UserLocked, UserActive, true},
+		{UserDisabled, UserActive, true},
+		{UserDisabled, UserLocked, false},
+		{UserActive, UserPending, false},
+		{UserActive, UserActive, false},
+	}
+
+	for _, tt := range tests {
+		err := CheckUserTransition(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("Expected %s -> %s allowed to be %v but got %v", tt.from, tt.to, tt.ok, err)
+		}
+	}
+}
+
+func TestCanSignIn(t *testing.T) {
+
+	for status, want := range map[string]bool{
+		UserPending:  true,
+		UserActive:   true,
+		UserDisabled: false,
+		UserLocked:   false,
+	} {
+		if got := CanSignIn(status); got != want {
+			t.Errorf("Expected CanSignIn(%q) to be %v but got %v", status, want, got)
+		}
+	}
+}
@@ -14,18 +14,70 @@ import (
 func NewGinServer(accountHandler handler.Handler) *gin.Engine {
 	r := gin.Default()
 	bankingApp := r.Group("/bankingapp")
+
+	authGroup := bankingApp.Group("/auth")
+	authGroup.POST("/register", accountHandler.CreateUser)
+	authGroup.POST("/login", accountHandler.Login)
+	authGroup.POST("/refresh", accountHandler.RefreshToken)
+	authGroup.POST("/logout", accountHandler.Logout)
+
+	// everything under /accounts acts on the signed-in user's accounts
 	accountGroup := bankingApp.Group("/accounts")
+	accountGroup.Use(accountHandler.Authenticate)
 
 	accountGroup.POST("", accountHandler.CreateAccount)
 	accountGroup.GET("/:accountId", accountHandler.GetAccountbyId)
 	accountGroup.PUT("/:accountId", accountHandler.UpdateAccount)
 	accountGroup.DELETE("/:accountId", accountHandler.DisableAccount)
 	accountGroup.PATCH("/:accountId", accountHandler.ActivateAccount)
+	accountGroup.POST("/:accountId/freeze", accountHandler.FreezeAccount)
+	accountGroup.POST("/:accountId/unfreeze", accountHandler.UnfreezeAccount)
+	accountGroup.PUT("/:accountId/overdraft-limit", accountHandler.SetOverdraftLimit)
+	accountGroup.GET("/:accountId/limits", accountHandler.GetAccountLimits)
+	accountGroup.PUT("/:accountId/limits", accountHandler.SetAccountLimits)
+	accountGroup.GET("/:accountId/history", accountHandler.GetAccountStatusHistory)
+	accountGroup.GET("/:accountId/statements/:month", accountHandler.GetStatement)
+	accountGroup.POST("/:accountId/standing-orders", accountHandler.CreateStandingOrder)
+	accountGroup.GET("/:accountId/standing-orders", accountHandler.ListStandingOrders)
+	accountGroup.GET("/:accountId/standing-orders/:orderId", accountHandler.GetStandingOrder)
+	accountGroup.PUT("/:accountId/standing-orders/:orderId", accountHandler.UpdateStandingOrder)
+	accountGroup.DELETE("/:accountId/standing-orders/:orderId", accountHandler.CancelStandingOrder)
+	accountGroup.POST("/:accountId/holds", accountHandler.PlaceHold)
+	accountGroup.GET("/:accountId/holds", accountHandler.ListHolds)
+	accountGroup.GET("/:accountId/holds/:holdId", accountHandler.GetHold)
+	accountGroup.POST("/:accountId/holds/:holdId/capture", accountHandler.CaptureHold)
+	accountGroup.POST("/:accountId/holds/:holdId/release", accountHandler.ReleaseHold)
 	accountGroup.POST("/deposit", accountHandler.Deposit)
 	accountGroup.POST("/withdraw", accountHandler.Withdraw)
+	accountGroup.POST("/transfer", accountHandler.Transfer)
 	accountGroup.GET("/transactions/history/:account/:count", accountHandler.GetTransactionsbyAccount)
 	accountGroup.GET("/transactions/range/:account/:startMonth/:endMonth", accountHandler.GetTransactionsbyMonthRange)
 	accountGroup.GET("/transactions/id/:transactionId", accountHandler.GetTransactionbyId)
+
+	transactionGroup := bankingApp.Group("/transactions")
+	transactionGroup.Use(accountHandler.Authenticate)
+	transactionGroup.GET("", accountHandler.SearchTransactions)
+	transactionGroup.POST("/:transactionId/reverse", accountHandler.ReverseTransaction)
+
+	userGroup := bankingApp.Group("/users")
+	userGroup.Use(accountHandler.Authenticate)
+	userGroup.GET("/:userEmail", accountHandler.GetUserbyEmail)
+	userGroup.PUT("/:userEmail", accountHandler.UpdateUser)
+	userGroup.DELETE("/:userEmail", accountHandler.DisableUser)
+	userGroup.PATCH("/:userEmail", accountHandler.ActivateUser)
+	userGroup.POST("/:userEmail/lock", accountHandler.LockUser)
+	userGroup.GET("/:userEmail/accounts", accountHandler.ListUserAccounts)
+	userGroup.POST("/:userEmail/accounts", accountHandler.AttachAccount)
+	userGroup.DELETE("/:userEmail/accounts/:accountId", accountHandler.DetachAccount)
+
+	adminGroup := bankingApp.Group("/admin")
+	adminGroup.Use(accountHandler.Authenticate)
+	adminGroup.POST("/interest/accrue", accountHandler.AccrueInterest)
+	adminGroup.GET("/reviews", accountHandler.ListRiskReviews)
+	adminGroup.POST("/reviews/:reviewId/approve", accountHandler.ApproveRiskReview)
+	adminGroup.POST("/reviews/:reviewId/reject", accountHandler.RejectRiskReview)
+	adminGroup.GET("/ledger/verify", accountHandler.VerifyLedger)
+	adminGroup.POST("/reconciliation", accountHandler.ReconcileAccounts)
 	
 
 	return r
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	issuer = "account-service"
+
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+
+	// minSecretLength is the shortest secret accepted for HS256
+	minSecretLength = 32
+)
+
+// placeholderSecrets are example secrets that were once shipped in config,
+// so are known to everyone and refused
+var placeholderSecrets = map[string]bool{
+	"change-me-to-a-long-random-secret-in-production": true,
+}
+
+type AuthService interface {
+	// Login checks a user's credentials and issues a token pair
+	Login(email string, password string) (*model.TokenPair, error)
+	// Refresh exchanges a refresh token for a new pair. The old refresh token
+	// is revoked, so each one can be used once.
+	Refresh(refreshToken string) (*model.TokenPair, error)
+	// Logout revokes a refresh token
+	Logout(refreshToken string) error
+	// VerifyAccessToken checks an access token, and that its user may still
+	// sign in, and returns the email it was issued to
+	VerifyAccessToken(accessToken string) (string, error)
+	// IsAdmin reports whether a user may manage other users
+	IsAdmin(email string) bool
+}
+
+type authService struct {
+	banking    bankingService.BankingService
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	admins     map[string]bool
+	now        func() time.Time
+}
+
+func NewAuthService(cfg *config.Config, banking bankingService.BankingService) (AuthService, error) {
+	if cfg.Auth.JwtSecret == "" {
+		return nil, fmt.Errorf("no token secret is set: set JWT_SECRET")
+	}
+	if len(cfg.Auth.JwtSecret) < minSecretLength {
+		return nil, fmt.Errorf("auth.jwt_secret must be at least %d characters", minSecretLength)
+	}
+	if placeholderSecrets[cfg.Auth.JwtSecret] {
+		return nil, fmt.Errorf("auth.jwt_secret is the example secret: set JWT_SECRET to a random one")
+	}
+
+	a := &authService{
+		banking:    banking,
+		secret:     []byte(cfg.Auth.JwtSecret),
+		accessTTL:  time.Minute * time.Duration(cfg.Auth.AccessTokenTTL),
+		refreshTTL: time.Minute * time.Duration(cfg.Auth.RefreshTokenTTL),
+		admins:     map[string]bool{},
+		now:        time.Now,
+	}
+	for _, email := range cfg.Auth.Admins {
+		a.admins[email] = true
+	}
+	if a.accessTTL <= 0 {
+		a.accessTTL = defaultAccessTokenTTL
+	}
+	if a.refreshTTL <= 0 {
+		a.refreshTTL = defaultRefreshTokenTTL
+	}
+	return a, nil
+}
+
+func (a *authService) Login(email string, password string) (*model.TokenPair, error) {
+	user, err := a.banking.AuthenticateUser(email, password)
+	if err != nil {
+		return nil, err
+	}
+	return a.issue(user.Email)
+}
+
+func (a *authService) Refresh(refreshToken string) (*model.TokenPair, error) {
+	token, err := a.banking.RevokeRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkUser(token.Email); err != nil {
+		return nil, err
+	}
+	return a.issue(token.Email)
+}
+
+func (a *authService) Logout(refreshToken string) error {
+	_, err := a.banking.RevokeRefreshToken(hashToken(refreshToken))
+	return err
+}
+
+func (a *authService) VerifyAccessToken(accessToken string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now),
+	)
+	if err != nil || claims.Subject == "" {
+		return "", bankingService.ErrInvalidToken
+	}
+	if err = a.checkUser(claims.Subject); err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func (a *authService) IsAdmin(email string) bool {
+	return a.admins[email]
+}
+
+// checkUser makes sure a user disabled or locked since their token was
+// issued cannot go on using it
+func (a *authService) checkUser(email string) error {
+	user, err := a.banking.GetUserbyEmail(email)
+	if err != nil {
+		return bankingService.ErrInvalidToken
+	}
+	switch {
+	case user.Status == model.UserDisabled:
+		return bankingService.ErrUserDisabled
+	case !model.CanSignIn(user.Status):
+		return bankingService.ErrUserLocked
+	}
+	return nil
+}
+
+// issue signs an access token for email and stores a new refresh token
+func (a *authService) issue(email string) (*model.TokenPair, error) {
+	now := a.now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   email,
+		ID:        uuid.New().String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTTL)),
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %v", err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+	err = a.banking.SaveRefreshToken(&model.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		Email:     email,
+		ExpiresAt: now.Add(a.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.accessTTL.Seconds()),
+	}, nil
+}
+
+// hashToken is how refresh tokens are stored. The token is random, so a
+// plain hash is enough, unlike a password.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockBankingService keeps refresh tokens in memory
+type mockBankingService struct {
+	bankingService.BankingService
+	passwords map[string]string
+	statuses  map[string]string
+	tokens    map[string]*model.RefreshToken
+}
+
+func newMockBankingService() *mockBankingService {
+	return &mockBankingService{
+		passwords: map[string]string{"yash@gmail.com": "123456"},
+		statuses:  map[string]string{},
+		tokens:    map[string]*model.RefreshToken{},
+	}
+}
+
+func (m *mockBankingService) AuthenticateUser(email string, password string) (*model.User, error) {
+	if stored, ok := m.passwords[email]; !ok || stored != password {
+		return nil, bankingService.ErrInvalidCredentials
+	}
+	return &model.User{Email: email}, nil
+}
+
+func (m *mockBankingService) GetUserbyEmail(email string) (*model.User, error) {
+	if _, ok := m.passwords[email]; !ok {
+		return nil, errors.New("user not found")
+	}
+	status := m.statuses[email]
+	if status == "" {
+		status = model.UserActive
+	}
+	return &model.User{Email: email, Status: status}, nil
+}
+
+func (m *mockBankingService) SaveRefreshToken(token *model.RefreshToken) error {
+	m.tokens[token.Hash] = token
+	return nil
+}
+
+func (m *mockBankingService) RevokeRefreshToken(hash string) (*model.RefreshToken, error) {
+	token, ok := m.tokens[hash]
+	if !ok {
+		return nil, bankingService.ErrInvalidToken
+	}
+	delete(m.tokens, hash)
+	return token, nil
+}
+
+func newTestAuthService(t *testing.T, banking bankingService.BankingService) *authService {
+	cfg := &config.Config{}
+	cfg.Auth.JwtSecret = "0123456789abcdef0123456789abcdef"
+	a, err := NewAuthService(cfg, banking)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	return a.(*authService)
+}
+
+func TestLoginIssuesVerifiableToken(t *testing.T) {
+
+	a := newTestAuthService(t, newMockBankingService())
+
+	tokens, err := a.Login("yash@gmail.com", "123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	email, err := a.VerifyAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	if email != "yash@gmail.com" {
+		t.Errorf("Expected subject yash@gmail.com but got %s", email)
+	}
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+
+	a := newTestAuthService(t, newMockBankingService())
+
+	_, err := a.Login("yash@gmail.com", "wrong")
+	if !errors.Is(err, bankingService.ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials but got %v", err)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+
+	a := newTestAuthService(t, newMockBankingService())
+
+	tokens, err := a.Login("yash@gmail.com", "123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	a.now = func() time.Time { return time.Now().Add(defaultAccessTokenTTL + time.Minute) }
+	if _, err = a.VerifyAccessToken(tokens.AccessToken); err == nil {
+		t.Errorf("Expected an expired token to be rejected")
+	}
+}
+
+func TestVerifyRejectsForeignTokens(t *testing.T) {
+
+	a := newTestAuthService(t, newMockBankingService())
+
+	claims := jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "yash@gmail.com",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	otherSecret, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("another-secret-another-secret-xx"))
+	if _, err := a.VerifyAccessToken(otherSecret); err == nil {
+		t.Errorf("Expected a token signed with another secret to be rejected")
+	}
+
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := a.VerifyAccessToken(unsigned); err == nil {
+		t.Errorf("Expected an unsigned token to be rejected")
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+
+	a := newTestAuthService(t, newMockBankingService())
+
+	tokens, err := a.Login("yash@gmail.com", "123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	refreshed, err := a.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Errorf("Expected a new refresh token")
+	}
+
+	// the old refresh token was used up
+	if _, err = a.Refresh(tokens.RefreshToken); !errors.Is(err, bankingService.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken but got %v", err)
+	}
+}
+
+func TestVerifyRejectsDisabledUser(t *testing.T) {
+
+	banking := newMockBankingService()
+	a := newTestAuthService(t, banking)
+
+	tokens, err := a.Login("yash@gmail.com", "123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	// a token issued before the user was disabled stops working
+	banking.statuses["yash@gmail.com"] = model.UserDisabled
+	if _, err = a.VerifyAccessToken(tokens.AccessToken); !errors.Is(err, bankingService.ErrUserDisabled) {
+		t.Errorf("Expected ErrUserDisabled but got %v", err)
+	}
+	if _, err = a.Refresh(tokens.RefreshToken); !errors.Is(err, bankingService.ErrUserDisabled) {
+		t.Errorf("Expected ErrUserDisabled but got %v", err)
+	}
+}
+
+func TestNewAuthServiceRequiresSecret(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Auth.JwtSecret = "short"
+
+	if _, err := NewAuthService(cfg, newMockBankingService()); err == nil {
+		t.Errorf("Expected a short secret to be rejected")
+	}
+
+	for _, secret := range []string{"", "change-me-to-a-long-random-secret-in-production"} {
+		cfg.Auth.JwtSecret = secret
+		if _, err := NewAuthService(cfg, newMockBankingService()); err == nil {
+			t.Errorf("Expected secret %q to be rejected", secret)
+		}
+	}
+}
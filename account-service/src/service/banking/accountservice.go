@@ -6,25 +6,34 @@ import (
 	"fmt"
 
 	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
 )
 
 // Account methods
 func (s *bankingService) GetAccountbyId(accountId string) (*model.Account, error) {
 	var account model.Account
-	err := s.db.QueryRow("SELECT * FROM accounts WHERE id = $1", accountId).Scan(
+	err := s.db.QueryRow(`
+		SELECT id, first_name, last_name, email, account_type,
+			balance, currency, available_balance, overdraft_limit, status, password, created_at, updated_at, 
+			closed_at, COALESCE(closure_reason, '')
+		FROM accounts WHERE id = $1`, accountId).Scan(
 		&account.ID, &account.FirstName, &account.LastName, &account.Email,
-		&account.Type, &account.Balance, &account.Status, &account.Password,
-		&account.CreatedAt, &account.UpdatedAt)
+		&account.Type, &account.Balance, &account.Balance.Currency, &account.AvailableBalance, &account.OverdraftLimit, &account.Status, &account.Password,
+		&account.CreatedAt, &account.UpdatedAt, &account.ClosedAt, &account.ClosureReason)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("account not found")
 		}
 		return nil, err
 	}
+	account.AvailableBalance.Currency = account.Balance.Currency
+	account.OverdraftLimit.Currency = account.Balance.Currency
 	return &account, nil
 }
 
-func (s *bankingService) CreateAccount(account *model.Account) error {
+// CreateAccount opens an account with ownerEmail as its primary owner
+func (s *bankingService) CreateAccount(account *model.Account, ownerEmail string) error {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -33,14 +42,19 @@ func (s *bankingService) CreateAccount(account *model.Account) error {
 
 	defer tx.Rollback()
 
+	account.Password, err = hashPassword(account.Password)
+	if err != nil {
+		return err
+	}
+
 	// Execute insert within transaction
 	res, err := tx.Exec(`
 		INSERT INTO accounts (
 			id, first_name, last_name, email, account_type, 
-			balance, status, password, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			balance, currency, available_balance, status, password, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
 		account.ID, account.FirstName, account.LastName, account.Email,
-		account.Type, account.Balance, account.Status, account.Password,
+		account.Type, money.Zero(account.Balance.Currency), account.Balance.Currency, money.Zero(account.Balance.Currency), account.Status, account.Password,
 		account.CreatedAt, account.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to insert account: %v", err)
@@ -54,6 +68,17 @@ func (s *bankingService) CreateAccount(account *model.Account) error {
 		return fmt.Errorf("account not created")
 	}
 
+	if err = addPrimaryOwner(tx, account.ID, ownerEmail); err != nil {
+		return err
+	}
+
+	// the opening balance is posted like any other transaction, which
+	// credits it to the account inserted with nothing
+	err = recordTransaction(tx, model.NewTransaction(account.ID, account.Balance, "opening"))
+	if err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %v", err)
@@ -72,24 +97,35 @@ func (s *bankingService) UpdateAccount(account *model.Account) error {
 	defer tx.Rollback()
 
 	// First verify account exists
-	var currentBalance float64
-	err = tx.QueryRow("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE", account.ID).Scan(&currentBalance)
+	var currentStatus string
+	var currentPassword string
+	err = tx.QueryRow("SELECT status, password FROM accounts WHERE id = $1 FOR UPDATE", account.ID).Scan(&currentStatus, &currentPassword)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("account not found")
 		}
 		return fmt.Errorf("failed to query account: %v", err)
 	}
+	if currentStatus == model.AccountClosed {
+		return fmt.Errorf("account is closed")
+	}
+
+	account.Password, err = resolvePassword(currentPassword, account.Password)
+	if err != nil {
+		return err
+	}
 
-	// Execute update within transaction
+	// Execute update within transaction. Status only changes through
+	// ChangeAccountStatus, so that every change is checked and recorded.
+	// The balance only changes when a transaction is recorded, so the one
+	// given is ignored.
 	res, err := tx.Exec(`
 		UPDATE accounts 
 		SET first_name = $1, last_name = $2, email = $3, 
-			account_type = $4, balance = $5, status = $6, 
-			password = $7, updated_at = $8 
-		WHERE id = $9`,
+			account_type = $4, password = $5, updated_at = $6 
+		WHERE id = $7`,
 		account.FirstName, account.LastName, account.Email,
-		account.Type, account.Balance, account.Status,
+		account.Type,
 		account.Password, account.UpdatedAt, account.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update account: %v", err)
@@ -111,89 +147,317 @@ func (s *bankingService) UpdateAccount(account *model.Account) error {
 	return nil
 }
 
-// Deposit amount to an account
-func (s *bankingService) Deposit(accountID string, amount float64) error {
+// Deposit amount to an account on behalf of actor. An amount without a
+// currency is taken to be in the account's currency. A deposit the risk rules
+// do not allow is recorded for review and a *model.RiskError returned. If
+// key was already used for the same deposit, its outcome is returned and
+// nothing is posted again.
+func (s *bankingService) Deposit(accountID string, amount money.Money, actor string, key *model.IdempotencyKey) (*model.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("deposit amount must be positive")
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
 	defer tx.Rollback()
 
+	replayed, err := claimIdempotencyKey(tx, key)
+	if err != nil {
+		return nil, err
+	}
+	if replayed != nil {
+		return replayed.transaction()
+	}
+
 	// Get current balance with row lock
-	var account model.Account
-	err = tx.QueryRow(`
-		SELECT id, balance, status 
-		FROM accounts 
-		WHERE id = $1 
-		FOR UPDATE`, accountID).Scan(&account.ID, &account.Balance, &account.Status)
+	account, err := lockAccount(tx, accountID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("account not found")
-		}
-		return fmt.Errorf("failed to query account: %v", err)
+		return nil, err
 	}
 
-	if account.Status != "active" {
-		return fmt.Errorf("account is not active")
+	if err = postingError(account, false); err != nil {
+		return nil, err
 	}
 
-	// Update balance
-	newBalance := account.Balance + amount
-	_, err = tx.Exec(`
-		UPDATE accounts 
-		SET balance = $1, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = $2`,
-		newBalance, accountID)
+	amount, err = inAccountCurrency(account, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLimits(tx, account, amount, false); err != nil {
+		return nil, err
+	}
+
+	decision, err := s.assessRisk(tx, account, amount, false)
+	if err != nil {
+		return nil, err
+	}
+	if decision.Outcome != risk.Allow {
+		return nil, s.holdForReview(tx, key, model.NewRiskReview(accountID, model.OpDeposit, amount, decision, actor))
+	}
+
+	transaction, err := postDeposit(tx, account, amount)
 	if err != nil {
-		return fmt.Errorf("failed to update balance: %v", err)
+		return nil, err
+	}
+	if err = saveIdempotentResponse(tx, key, transaction); err != nil {
+		return nil, err
 	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	return nil
+	return transaction, nil
 }
 
-func (s *bankingService) Withdraw(accountID string, amount float64) error {
+// Withdraw amount from an account on behalf of actor. An amount without a
+// currency is taken to be in the account's currency. A withdrawal the risk
+// rules do not allow is recorded for review and a *model.RiskError returned.
+// If key was already used for the same withdrawal, its outcome is returned
+// and nothing is posted again.
+func (s *bankingService) Withdraw(accountID string, amount money.Money, actor string, key *model.IdempotencyKey) (*model.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("withdrawal amount must be positive")
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
 	defer tx.Rollback()
 
+	replayed, err := claimIdempotencyKey(tx, key)
+	if err != nil {
+		return nil, err
+	}
+	if replayed != nil {
+		return replayed.transaction()
+	}
+
 	// Get current balance with row lock
-	var account model.Account
-	err = tx.QueryRow(`
-		SELECT id, balance, status 
-		FROM accounts 
-		WHERE id = $1 
-		FOR UPDATE`, accountID).Scan(&account.ID, &account.Balance, &account.Status)
+	account, err := lockAccount(tx, accountID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("account not found")
+		return nil, err
+	}
+
+	if err = postingError(account, true); err != nil {
+		return nil, err
+	}
+
+	amount, err = inAccountCurrency(account, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLimits(tx, account, amount, true); err != nil {
+		return nil, err
+	}
+
+	decision, err := s.assessRisk(tx, account, amount, true)
+	if err != nil {
+		return nil, err
+	}
+	if decision.Outcome != risk.Allow {
+		return nil, s.holdForReview(tx, key, model.NewRiskReview(accountID, model.OpWithdraw, amount, decision, actor))
+	}
+
+	transaction, err := s.postWithdrawal(tx, account, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = saveIdempotentResponse(tx, key, transaction); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+// Transfer moves amount from one account to another in a single database
+// transaction, so either both legs are applied or neither is. The amount is in
+// the source account's currency and is converted at the provider's rate when
+// the destination account holds a different currency. If key was already used
+// for the same transfer, the legs it posted are returned instead.
+func (s *bankingService) Transfer(fromAccountID string, toAccountID string, amount money.Money, key *model.IdempotencyKey) (*model.Transaction, *model.Transaction, error) {
+	if fromAccountID == toAccountID {
+		return nil, nil, fmt.Errorf("cannot transfer to the same account")
+	}
+	if !amount.IsPositive() {
+		return nil, nil, fmt.Errorf("transfer amount must be positive")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	replayed, err := claimIdempotencyKey(tx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if replayed != nil {
+		return replayed.transfer()
+	}
+
+	from, to, err := lockAccounts(tx, fromAccountID, toAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// frozen accounts may still be paid into, and only the source account
+	// counts as used by its owners
+	if err = postingError(from, true); err != nil {
+		return nil, nil, err
+	}
+	if err = postingError(to, false); err != nil {
+		return nil, nil, err
+	}
+	if err = s.checkTransferLimits(tx, from, amount); err != nil {
+		return nil, nil, err
+	}
+	if err = reactivate(tx, from); err != nil {
+		return nil, nil, err
+	}
+
+	debit, credit, err := s.moveFunds(tx, from, to, amount, "debit", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = saveIdempotentResponse(tx, key, debit, credit); err != nil {
+		return nil, nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return debit, credit, nil
+}
+
+// CloseAccount closes an account for good, recording why and when. Any
+// remaining balance is paid out to payoutAccountID in the same database
+// transaction, and a closing transaction is published either way.
+func (s *bankingService) CloseAccount(accountID string, payoutAccountID string, actor string, reason string) (*model.Transaction, error) {
+	if accountID == payoutAccountID {
+		return nil, fmt.Errorf("cannot pay out to the account being closed")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var account, payout *model.Account
+	if payoutAccountID == "" {
+		account, err = lockAccount(tx, accountID)
+	} else {
+		account, payout, err = lockAccounts(tx, accountID, payoutAccountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = model.CheckAccountTransition(account.Status, model.AccountClosed); err != nil {
+		return nil, err
+	}
+	if account.Balance.IsNegative() {
+		return nil, fmt.Errorf("account is overdrawn by %s and cannot be closed", account.Balance.Neg())
+	}
+	if held := heldBalance(account); held.IsPositive() {
+		return nil, fmt.Errorf("account has %s held by pending holds and cannot be closed", held)
+	}
+
+	var closing *model.Transaction
+	if account.Balance.IsZero() {
+		closing = model.NewTransaction(account.ID, money.Zero(account.Balance.Currency), "closing")
+		if err = recordTransaction(tx, closing); err != nil {
+			return nil, err
+		}
+	} else {
+		if payout == nil {
+			return nil, fmt.Errorf("account has a balance of %s, give a payout account to close it", account.Balance)
+		}
+		if err = postingError(account, true); err != nil {
+			return nil, err
+		}
+		if err = postingError(payout, false); err != nil {
+			return nil, err
+		}
+		closing, _, err = s.moveFunds(tx, account, payout, account.Balance, "closing", "")
+		if err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("failed to query account: %v", err)
 	}
 
-	if account.Status != "active" {
-		return fmt.Errorf("account is not active")
+	if err = setAccountStatus(tx, account.ID, account.Status, model.AccountClosed, actor, reason); err != nil {
+		return nil, err
 	}
 
-	if account.Balance < amount {
-		return fmt.Errorf("insufficient funds")
+	var closureReason sql.NullString
+	if reason != "" {
+		closureReason = sql.NullString{String: reason, Valid: true}
+	}
+	_, err = tx.Exec("UPDATE accounts SET closed_at = $1, closure_reason = $2 WHERE id = $3", closing.Timestamp, closureReason, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record closure: %v", err)
 	}
 
-	// Update balance
-	newBalance := account.Balance - amount
-	_, err = tx.Exec(`
-		UPDATE accounts 
-		SET balance = $1, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = $2`,
-		newBalance, accountID)
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return closing, nil
+}
+
+// SetOverdraftLimit sets how far below zero an account's balance may go. A
+// limit cannot be lowered past an account's current overdraft.
+func (s *bankingService) SetOverdraftLimit(accountID string, limit money.Money, actor string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return err
+	}
+	if account.Status == model.AccountClosed {
+		return fmt.Errorf("account is closed")
+	}
+
+	limit, err = inAccountCurrency(account, limit)
+	if err != nil {
+		return err
+	}
+	if err = model.CheckOverdraftLimit(account.Type, limit); err != nil {
+		return err
+	}
+	if cmp, err := account.Balance.Cmp(limit.Neg()); err != nil || cmp < 0 {
+		return fmt.Errorf("account is overdrawn by %s, more than the new limit", account.Balance.Neg())
+	}
+	if cmp, err := account.AvailableBalance.Cmp(limit.Neg()); err != nil || cmp < 0 {
+		return fmt.Errorf("pending holds reserve more than the new limit allows")
+	}
+
+	_, err = tx.Exec("UPDATE accounts SET overdraft_limit = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", limit, accountID)
 	if err != nil {
-		return fmt.Errorf("failed to update balance: %v", err)
+		return fmt.Errorf("failed to update overdraft limit: %v", err)
+	}
+
+	err = recordAudit(tx, model.NewOverdraftLimitChangedEvent(accountID, account.OverdraftLimit, limit, actor))
+	if err != nil {
+		return err
 	}
 
 	// Commit transaction
@@ -202,4 +466,193 @@ func (s *bankingService) Withdraw(accountID string, amount float64) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// postDeposit credits amount to a locked account whose status, currency and
+// limits the caller has checked, and records the transaction
+func postDeposit(tx *sql.Tx, account *model.Account, amount money.Money) (*model.Transaction, error) {
+	if err := reactivate(tx, account); err != nil {
+		return nil, err
+	}
+
+	transaction := model.NewTransaction(account.ID, amount, "credit")
+	if err := recordTransaction(tx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// postWithdrawal debits amount from a locked account whose status, currency
+// and limits the caller has checked, and records the transaction and any
+// overdraft fee
+func (s *bankingService) postWithdrawal(tx *sql.Tx, account *model.Account, amount money.Money) (*model.Transaction, error) {
+	if err := reactivate(tx, account); err != nil {
+		return nil, err
+	}
+
+	// the debit and its fee move the balance when they are recorded
+	_, fee, err := s.debitBalance(account, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := model.NewTransaction(account.ID, amount, "debit")
+	if err = recordTransaction(tx, transaction); err != nil {
+		return nil, err
+	}
+	if err = recordFee(tx, fee); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// lockAccounts locks two accounts in id order, so that concurrent transfers
+// in opposite directions always acquire the locks in the same order and
+// cannot deadlock, and returns them in the order asked for
+func lockAccounts(tx *sql.Tx, firstID string, secondID string) (*model.Account, *model.Account, error) {
+	lowID, highID := firstID, secondID
+	if highID < lowID {
+		lowID, highID = highID, lowID
+	}
+	low, err := lockAccount(tx, lowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	high, err := lockAccount(tx, highID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if low.ID != firstID {
+		return high, low, nil
+	}
+	return low, high, nil
+}
+
+// moveFunds posts amount from one locked account to another, converting it
+// when the accounts hold different currencies, and records both legs. The
+// debit leg is recorded with debitType, and both with reference. Callers
+// check the accounts' statuses.
+func (s *bankingService) moveFunds(tx *sql.Tx, from *model.Account, to *model.Account, amount money.Money, debitType string, reference string) (*model.Transaction, *model.Transaction, error) {
+	debitAmount, err := inAccountCurrency(from, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rate, err := s.rates.Rate(from.Balance.Currency, to.Balance.Currency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get exchange rate: %v", err)
+	}
+	creditAmount := debitAmount.Convert(to.Balance.Currency, rate.Value)
+	if !creditAmount.IsPositive() {
+		return nil, nil, fmt.Errorf("transfer amount is too small to convert to %s", to.Balance.Currency)
+	}
+
+	_, fee, err := s.debitBalance(from, debitAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	debit, credit := model.NewTransferTransactions(from.ID, to.ID, debitAmount, creditAmount)
+	debit.Type = debitType
+	debit.Reference = reference
+	credit.Reference = reference
+	if from.Balance.Currency != to.Balance.Currency {
+		debit.FxRate = rate.String()
+		credit.FxRate = rate.String()
+	}
+	for _, transaction := range []*model.Transaction{debit, credit} {
+		if err = recordTransaction(tx, transaction); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err = recordFee(tx, fee); err != nil {
+		return nil, nil, err
+	}
+
+	return debit, credit, nil
+}
+
+// debitBalance works out an account's balance after debiting amount and the
+// overdraft fee the debit incurs, if any. The debit is refused if the two
+// together would take the available balance, what pending holds leave of
+// the balance, past the account's overdraft limit.
+func (s *bankingService) debitBalance(account *model.Account, amount money.Money) (money.Money, *model.Transaction, error) {
+	balance, err := account.Balance.Sub(amount)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+
+	var fee *model.Transaction
+	if balance.IsNegative() && model.ChargesOverdraftFee(account.Type) && s.overdraftFee != "" {
+		feeAmount, err := money.Parse(s.overdraftFee, account.Balance.Currency)
+		if err != nil {
+			return money.Money{}, nil, fmt.Errorf("invalid overdraft fee: %v", err)
+		}
+		if feeAmount.IsPositive() {
+			if balance, err = balance.Sub(feeAmount); err != nil {
+				return money.Money{}, nil, err
+			}
+			fee = model.NewTransaction(account.ID, feeAmount, "fee")
+		}
+	}
+
+	// pending holds keep what they reserve, so the available balance falls
+	// by as much as the balance
+	available, err := balance.Sub(heldBalance(account))
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+	floor := account.OverdraftLimit.Neg()
+	if cmp, err := available.Cmp(floor); err != nil || cmp < 0 {
+		return money.Money{}, nil, fmt.Errorf("insufficient funds")
+	}
+	return balance, fee, nil
+}
+
+// heldBalance is how much of a locked account's balance pending holds
+// reserve
+func heldBalance(account *model.Account) money.Money {
+	held, _ := account.Balance.Sub(account.AvailableBalance)
+	return held
+}
+
+// recordFee records an overdraft fee, if there is one, after the debit that
+// incurred it
+func recordFee(tx *sql.Tx, fee *model.Transaction) error {
+	if fee == nil {
+		return nil
+	}
+	return recordTransaction(tx, fee)
+}
+
+// inAccountCurrency checks that amount is in the account's currency, filling
+// it in when the caller did not give one
+func inAccountCurrency(account *model.Account, amount money.Money) (money.Money, error) {
+	if amount.Currency == "" {
+		amount.Currency = account.Balance.Currency
+	}
+	if amount.Currency != account.Balance.Currency {
+		return money.Money{}, fmt.Errorf("amount currency %s does not match account currency %s", amount.Currency, account.Balance.Currency)
+	}
+	return amount, nil
+}
+
+// lockAccount reads an account's type, balances, overdraft limit and status,
+// holding a row lock until the surrounding transaction ends
+func lockAccount(tx *sql.Tx, accountID string) (*model.Account, error) {
+	var account model.Account
+	err := tx.QueryRow(`
+		SELECT id, account_type, balance, currency, available_balance, overdraft_limit, status 
+		FROM accounts 
+		WHERE id = $1 
+		FOR UPDATE`, accountID).Scan(&account.ID, &account.Type, &account.Balance, &account.Balance.Currency, &account.AvailableBalance, &account.OverdraftLimit, &account.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("account %s not found", accountID)
+		}
+		return nil, fmt.Errorf("failed to query account: %v", err)
+	}
+	account.AvailableBalance.Currency = account.Balance.Currency
+	account.OverdraftLimit.Currency = account.Balance.Currency
+	return &account, nil
+}
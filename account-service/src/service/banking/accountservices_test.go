@@ -1,12 +1,19 @@
 package service
 
 import (
+	"database/sql"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/banking-app/account-service/src/model"
+	exchange "github.com/banking-app/account-service/src/service/exchange"
+	"github.com/banking-app/protos/money"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
 )
 
 type mockAccountService struct {
@@ -19,8 +26,8 @@ func (m *mockAccountService) GetAccountbyId(accountId string) (*model.Account, e
 	return args.Get(0).(*model.Account), args.Error(1)
 }
 
-func (m *mockAccountService) CreateAccount(account *model.Account) error {
-	args := m.Called(account)
+func (m *mockAccountService) CreateAccount(account *model.Account, ownerEmail string) error {
+	args := m.Called(account, ownerEmail)
 	return args.Error(0)
 }
 
@@ -29,14 +36,9 @@ func (m *mockAccountService) UpdateAccount(account *model.Account) error {
 	return args.Error(0)
 }
 
-func (m *mockAccountService) Deposit(accountID string, amount float64) error {
-	args := m.Called(accountID, amount)
-	return args.Error(0)
-}
-
-func (m *mockAccountService) Withdraw(accountID string, amount float64) error {
-	args := m.Called(accountID, amount)
-	return args.Error(0)
+func (m *mockAccountService) CloseAccount(accountID string, payoutAccountID string, actor string, reason string) (*model.Transaction, error) {
+	args := m.Called(accountID, payoutAccountID, actor, reason)
+	return args.Get(0).(*model.Transaction), args.Error(1)
 }
 
 func NewMockAccountService(t *testing.T) *mockAccountService {
@@ -54,7 +56,7 @@ func TestGetAccountbyId(t *testing.T) {
 		LastName:  "Doe",
 		Email:     "johndoe@example.com",
 		Type:      "personal",
-		Balance:   100,
+		Balance:   money.New(10000, money.DefaultCurrency),
 		Status:    "active",
 		Password:  "password",
 		CreatedAt: time.Now(),
@@ -86,7 +88,7 @@ func TestCreateAccount(t *testing.T) {
 		LastName:  "Doe",
 		Email:     "johndoe@example.com",
 		Type:      "personal",
-		Balance:   100,
+		Balance:   money.New(10000, money.DefaultCurrency),
 		Status:    "active",
 		Password:  "password",
 		CreatedAt: time.Now(),
@@ -94,10 +96,10 @@ func TestCreateAccount(t *testing.T) {
 	}
 
 	// add the account to the mock account service
-	mockAccountService.On("CreateAccount", &account).Return(nil)
+	mockAccountService.On("CreateAccount", &account, account.Email).Return(nil)
 
 	// call the CreateAccount method
-	err := mockAccountService.CreateAccount(&account)
+	err := mockAccountService.CreateAccount(&account, account.Email)
 
 	if err != nil {
 		t.Errorf("Expected error to be nil, but got %v", err)
@@ -114,7 +116,7 @@ func TestUpdateAccount(t *testing.T) {
 		LastName:  "Doe",
 		Email:     "johndoe@example.com",
 		Type:      "personal",
-		Balance:   100,
+		Balance:   money.New(10000, money.DefaultCurrency),
 		Status:    "active",
 		Password:  "password",
 		CreatedAt: time.Now(),
@@ -132,56 +134,284 @@ func TestUpdateAccount(t *testing.T) {
 	}
 }
 
-func TestDeposit(t *testing.T) {
+func TestUpdateAccountLeavesBalance(t *testing.T) {
 
-	mockAccountService := NewMockAccountService(t)
+	service, mock := newSQLMockService(t)
 
-	account := model.Account{
-		ID:        uuid.New().String(),
-		FirstName: "John",
-		LastName:  "Doe",
-		Email:     "johndoe@example.com",
-		Type:      "personal",
-		Balance:   100,
-		Status:    "active",
-		Password:  "password",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	// the handler read the account at 100.00, and a deposit has taken it to
+	// 150.00 since
+	account := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	account.FirstName = "Jane"
+	account.UpdatedAt = time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, password FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(account.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "password"}).AddRow(model.AccountActive, "$2a$10$hash"))
+	mock.ExpectExec(`UPDATE accounts\s+SET first_name = \$1, last_name = \$2, email = \$3,\s+account_type = \$4, password = \$5, updated_at = \$6\s+WHERE id = \$7`).
+		WithArgs(account.FirstName, account.LastName, account.Email, account.Type, "$2a$10$hash", account.UpdatedAt, account.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// no journal entry, posting or balance update is expected
+	if err := service.UpdateAccount(account); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+}
+
+// newPostingAccount returns an active account holding balance, with
+// nothing held and no overdraft
+func newPostingAccount(id string, balance money.Money) *model.Account {
+	return &model.Account{
+		ID:               id,
+		Type:             model.AccountSavings,
+		Balance:          balance,
+		AvailableBalance: balance,
+		OverdraftLimit:   money.Zero(balance.Currency),
+		Status:           model.AccountActive,
 	}
+}
 
-	// add the account to the mock account service
-	mockAccountService.On("Deposit", account.ID, float64(100)).Return(nil)
+func TestDeposit(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	account := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	amount := money.New(2500, "USD")
 
-	// call the Deposit method
-	err := mockAccountService.Deposit(account.ID, 100)
+	mock.ExpectBegin()
+	expectLockAccount(mock, account)
+	expectNoLimits(mock, account.ID)
+	expectRecordTransaction(mock, account.ID, 7, account.Balance, amount)
+	mock.ExpectCommit()
 
+	transaction, err := service.Deposit(account.ID, amount, "johndoe@example.com", nil)
 	if err != nil {
-		t.Errorf("Expected error to be nil, but got %v", err)
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if transaction.Type != "credit" || transaction.Amount != amount {
+		t.Errorf("Expected a credit of %s, but got a %s of %s", amount, transaction.Type, transaction.Amount)
+	}
+	if transaction.Seq != 7 || transaction.BalanceAfter == nil || *transaction.BalanceAfter != money.New(12500, "USD") {
+		t.Errorf("Expected the deposit to be numbered 7 and leave 125.00, but got %d and %v", transaction.Seq, transaction.BalanceAfter)
+	}
+}
+
+func TestDepositRejected(t *testing.T) {
+
+	account := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	closed := newPostingAccount(uuid.New().String(), money.Zero("USD"))
+	closed.Status = model.AccountClosed
+
+	tests := []struct {
+		name    string
+		account *model.Account
+		amount  money.Money
+		locked  bool
+		want    string
+	}{
+		{"zero amount", account, money.Zero("USD"), false, "must be positive"},
+		{"other currency", account, money.New(2500, "EUR"), true, "does not match account currency"},
+		{"closed account", closed, money.New(2500, "USD"), true, "cannot be credited"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newSQLMockService(t)
+			if tt.locked {
+				mock.ExpectBegin()
+				expectLockAccount(mock, tt.account)
+				mock.ExpectRollback()
+			}
+
+			_, err := service.Deposit(tt.account.ID, tt.amount, "johndoe@example.com", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDepositUnknownAccount(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	accountID := uuid.New().String()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM accounts\s+WHERE id = \$1\s+FOR UPDATE`).WithArgs(accountID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := service.Deposit(accountID, money.New(2500, "USD"), "johndoe@example.com", nil)
+	if err == nil || err.Error() != fmt.Sprintf("account %s not found", accountID) {
+		t.Errorf("Expected the account not to be found, but got %v", err)
 	}
 }
 
 func TestWithdraw(t *testing.T) {
 
-	mockAccountService := NewMockAccountService(t)
+	service, mock := newSQLMockService(t)
+	account := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	amount := money.New(4000, "USD")
 
-	account := model.Account{
-		ID:        uuid.New().String(),
-		FirstName: "John",
-		LastName:  "Doe",
-		Email:     "johndoe@example.com",
-		Type:      "personal",
-		Balance:   100,
-		Status:    "active",
-		Password:  "password",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	mock.ExpectBegin()
+	expectLockAccount(mock, account)
+	expectNoLimits(mock, account.ID)
+	expectRecordTransaction(mock, account.ID, 3, account.Balance, amount.Neg())
+	mock.ExpectCommit()
+
+	transaction, err := service.Withdraw(account.ID, amount, "johndoe@example.com", nil)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if transaction.Type != "debit" || transaction.Amount != amount {
+		t.Errorf("Expected a debit of %s, but got a %s of %s", amount, transaction.Type, transaction.Amount)
 	}
+	if transaction.BalanceAfter == nil || *transaction.BalanceAfter != money.New(6000, "USD") {
+		t.Errorf("Expected the withdrawal to leave 60.00, but got %v", transaction.BalanceAfter)
+	}
+}
 
-	// add the account to the mock account service
-	mockAccountService.On("Withdraw", account.ID, float64(100)).Return(nil)
+func TestWithdrawRejected(t *testing.T) {
+
+	account := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	held := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	held.AvailableBalance = money.New(2000, "USD")
+	frozen := newPostingAccount(uuid.New().String(), money.New(10000, "USD"))
+	frozen.Status = model.AccountFrozen
+
+	tests := []struct {
+		name    string
+		account *model.Account
+		amount  money.Money
+		limits  bool
+		want    string
+	}{
+		{"other currency", account, money.New(2500, "EUR"), false, "does not match account currency"},
+		{"frozen account", frozen, money.New(2500, "USD"), false, "cannot be debited"},
+		{"more than the balance", account, money.New(10001, "USD"), true, "insufficient funds"},
+		{"more than holds leave", held, money.New(2001, "USD"), true, "insufficient funds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newSQLMockService(t)
+			mock.ExpectBegin()
+			expectLockAccount(mock, tt.account)
+			if tt.limits {
+				expectNoLimits(mock, tt.account.ID)
+			}
+			mock.ExpectRollback()
+
+			_, err := service.Withdraw(tt.account.ID, tt.amount, "johndoe@example.com", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// noRates returns a rate provider that only converts a currency to itself
+func noRates(t *testing.T) exchange.RateProvider {
+	t.Helper()
+	rates, err := exchange.NewStaticRateProvider("USD", nil)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	return rates
+}
+
+func TestTransfer(t *testing.T) {
 
-	// call the Withdraw method
-	err := mockAccountService.Withdraw(account.ID, 100)
+	service, mock := newSQLMockService(t)
+	service.rates = noRates(t)
+
+	// the source account has the higher id, so it is locked second
+	low := newPostingAccount("00000000-0000-0000-0000-000000000001", money.New(5000, "USD"))
+	high := newPostingAccount("00000000-0000-0000-0000-000000000002", money.New(10000, "USD"))
+	amount := money.New(4000, "USD")
+
+	mock.ExpectBegin()
+	expectLockAccount(mock, low)
+	expectLockAccount(mock, high)
+	expectNoLimits(mock, high.ID)
+	expectRecordTransaction(mock, high.ID, 4, high.Balance, amount.Neg())
+	expectRecordTransaction(mock, low.ID, 9, low.Balance, amount)
+	mock.ExpectCommit()
+
+	debit, credit, err := service.Transfer(high.ID, low.ID, amount, nil)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if debit.Account != high.ID || credit.Account != low.ID {
+		t.Errorf("Expected the legs in the order asked for, but got %s and %s", debit.Account, credit.Account)
+	}
+	if debit.TransferID == "" || debit.TransferID != credit.TransferID {
+		t.Errorf("Expected both legs to share a transfer id, but got %q and %q", debit.TransferID, credit.TransferID)
+	}
+	if *debit.BalanceAfter != money.New(6000, "USD") || *credit.BalanceAfter != money.New(9000, "USD") {
+		t.Errorf("Expected balances of 60.00 and 90.00 after, but got %s and %s", debit.BalanceAfter, credit.BalanceAfter)
+	}
+}
+
+func TestTransferRejected(t *testing.T) {
+
+	from := newPostingAccount("00000000-0000-0000-0000-000000000001", money.New(5000, "USD"))
+	to := newPostingAccount("00000000-0000-0000-0000-000000000002", money.New(10000, "USD"))
+	closed := newPostingAccount("00000000-0000-0000-0000-000000000003", money.Zero("USD"))
+	closed.Status = model.AccountClosed
+
+	tests := []struct {
+		name   string
+		to     *model.Account
+		amount money.Money
+		locked bool
+		limits bool
+		want   string
+	}{
+		{"same account", from, money.New(100, "USD"), false, false, "cannot transfer to the same account"},
+		{"zero amount", to, money.Zero("USD"), false, false, "must be positive"},
+		{"closed destination", closed, money.New(100, "USD"), true, false, "cannot be credited"},
+		{"other currency", to, money.New(100, "EUR"), true, false, "does not match account currency"},
+		{"more than the balance", to, money.New(5001, "USD"), true, true, "insufficient funds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newSQLMockService(t)
+			service.rates = noRates(t)
+			if tt.locked {
+				mock.ExpectBegin()
+				expectLockAccount(mock, from)
+				expectLockAccount(mock, tt.to)
+				if tt.limits {
+					expectNoLimits(mock, from.ID)
+				}
+				mock.ExpectRollback()
+			}
+
+			_, _, err := service.Transfer(from.ID, tt.to.ID, tt.amount, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCloseAccount(t *testing.T) {
+
+	mockAccountService := NewMockAccountService(t)
+
+	account := uuid.New().String()
+	payout := uuid.New().String()
+
+	closing, _ := model.NewTransferTransactions(account, payout, money.New(2500, money.DefaultCurrency), money.New(2500, money.DefaultCurrency))
+	closing.Type = "closing"
+
+	// add the closure to the mock account service
+	mockAccountService.On("CloseAccount", account, payout, "yash@gmail.com", "moving banks").Return(closing, nil)
+
+	// call the CloseAccount method
+	transaction, err := mockAccountService.CloseAccount(account, payout, "yash@gmail.com", "moving banks")
+
+	if transaction.Type != "closing" {
+		t.Errorf("Expected transaction.Type to be closing, but got %s", transaction.Type)
+	}
 
 	if err != nil {
 		t.Errorf("Expected error to be nil, but got %v", err)
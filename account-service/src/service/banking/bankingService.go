@@ -4,9 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/banking-app/account-service/src/config"
+	exchange "github.com/banking-app/account-service/src/service/exchange"
 	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/riskengine"
 
 	_ "github.com/lib/pq"
 )
@@ -14,29 +18,109 @@ import (
 type BankingService interface {
 	// Account methods
 	GetAccountbyId(accountId string) (*model.Account, error)
-	CreateAccount(account *model.Account) error
+	CreateAccount(account *model.Account, ownerEmail string) error
 	UpdateAccount(account *model.Account) error
-	Deposit(accountID string, amount float64) error
-	Withdraw(accountID string, amount float64) error
+	Deposit(accountID string, amount money.Money, actor string, key *model.IdempotencyKey) (*model.Transaction, error)
+	Withdraw(accountID string, amount money.Money, actor string, key *model.IdempotencyKey) (*model.Transaction, error)
+	Transfer(fromAccountID string, toAccountID string, amount money.Money, key *model.IdempotencyKey) (*model.Transaction, *model.Transaction, error)
+	PurgeIdempotencyKeys(before time.Time) (int, error)
+	ReverseTransaction(original *model.Transaction, actor string, reason string) (*model.Transaction, error)
+
+	// Hold methods
+	PlaceHold(hold *model.Hold) error
+	GetHold(accountID string, holdID string) (*model.Hold, error)
+	ListHolds(accountID string) ([]model.Hold, error)
+	CaptureHold(accountID string, holdID string, amount *money.Money) (*model.Hold, *model.Transaction, error)
+	ReleaseHold(accountID string, holdID string) (*model.Hold, error)
+	ExpireHolds(now time.Time, limit int) (int, error)
+
+	// Account status methods
+	ChangeAccountStatus(accountID string, status string, actor string, reason string) error
+	GetAccountStatusHistory(accountID string) ([]model.AccountStatusChange, error)
+	MarkInactiveAccounts(idleSince time.Time) (int, error)
+	CloseAccount(accountID string, payoutAccountID string, actor string, reason string) (*model.Transaction, error)
+	SetOverdraftLimit(accountID string, limit money.Money, actor string) error
+	GetAccountLimits(accountID string) (*model.Limits, error)
+	SetAccountLimits(accountID string, limits model.Limits, actor string) error
+
+	// Interest methods
+	AccrueInterestRange(from time.Time, to time.Time) (*model.InterestRun, error)
+
+	// Standing order methods
+	CreateStandingOrder(order *model.StandingOrder) error
+	GetStandingOrder(accountID string, orderID string) (*model.StandingOrder, error)
+	ListStandingOrders(accountID string) ([]model.StandingOrder, error)
+	UpdateStandingOrder(order *model.StandingOrder) error
+	CancelStandingOrder(accountID string, orderID string) error
+	ExecuteStandingOrders(now time.Time, limit int) (int, error)
+
+	// Risk review methods
+	ListRiskReviews(status string, limit int) ([]model.RiskReview, error)
+	ApproveRiskReview(reviewID string, actor string, reason string) (*model.Transaction, error)
+	RejectRiskReview(reviewID string, actor string, reason string) error
+
+	// Ledger methods
+	VerifyLedger() (*model.LedgerReport, error)
+
+	// Reconciliation methods
+	ListAccountIDs(after string, limit int) ([]string, error)
+	GetAccountRecord(accountID string) (*model.AccountRecord, error)
+	RepublishTransactions(ids []string) (int, error)
+
+	// Statement methods
+	GetStatement(accountID string, month string) (*model.StoredStatement, error)
+	SaveStatement(statement *model.StoredStatement) (*model.StoredStatement, error)
+	GetStatementBalances(accountID string, start time.Time, end time.Time) (*model.StatementBalances, error)
+
+	// Owner methods
+	GetAccountRole(accountID string, email string) (string, error)
+	GetUserAccounts(email string) ([]model.OwnedAccount, error)
+	AddAccountOwner(owner *model.AccountOwner) error
+	RemoveAccountOwner(accountID string, email string) error
 
 	// User methods
 	GetUserbyEmail(userId string) (*model.User, error)
 	CreateUser(user *model.User) error
 	UpdateUser(user *model.User) error
+	AuthenticateUser(email string, password string) (*model.User, error)
+	ChangeUserStatus(email string, status string, actor string, reason string) error
+
+	// Refresh token methods
+	SaveRefreshToken(token *model.RefreshToken) error
+	RevokeRefreshToken(hash string) (*model.RefreshToken, error)
 
-	// Transaction methods
-	CreateTransaction(transaction *model.Transaction) error
-	GetTransactions() ([]model.Transaction, error)
-	DeleteTransactionsById(id string) error
-	DeleteTransactionsByIds(ids []string) error
-	// PublishTransaction(transaction *model.Transaction) error
+	// Outbox methods
+	RelayOutbox(limit int, publish func([]model.OutboxEvent) error) (int, error)
 }
 
 type bankingService struct {
-	db *sql.DB
+	db              *sql.DB
+	rates           exchange.RateProvider
+	maxFailedLogins int
+	// overdraftFee is charged, in the account's currency, for each debit
+	// that leaves a non-credit account overdrawn
+	overdraftFee string
+	// interestRates holds the rate table of each account type that earns
+	// interest, and dayCount the days a year's rate is spread over
+	interestRates map[string]model.RateTable
+	dayCount      int
+	// orderRetryInterval and orderMaxRetries govern retries of standing
+	// order payments that fail
+	orderRetryInterval time.Duration
+	orderMaxRetries    int
+	// limits holds the withdrawal and deposit limits of each account type
+	// that has them
+	limits map[string]model.Limits
+	// holdExpiry is how long a hold lasts when no expiry is asked for, and
+	// maxHoldExpiry the longest one may last
+	holdExpiry    time.Duration
+	maxHoldExpiry time.Duration
+	// risk runs the rules that may hold deposits and withdrawals for review
+	// or block them
+	risk *riskengine.Engine
 }
 
-func NewService(cfg *config.Config) (BankingService, error) {
+func NewService(cfg *config.Config, rates exchange.RateProvider) (BankingService, error) {
 	db, err := sql.Open("postgres", cfg.Postgres.Uri)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %v", err)
@@ -57,7 +141,58 @@ func NewService(cfg *config.Config) (BankingService, error) {
 		return nil, fmt.Errorf("error creating schema: %v", err)
 	}
 
+	if cfg.Accounts.OverdraftFee != "" {
+		if _, err = money.Parse(cfg.Accounts.OverdraftFee, money.DefaultCurrency); err != nil {
+			return nil, fmt.Errorf("invalid overdraft fee: %v", err)
+		}
+	}
+
+	interestRates, err := newInterestRates(cfg.Interest)
+	if err != nil {
+		return nil, err
+	}
+	dayCount := cfg.Interest.DayCount
+	if dayCount <= 0 {
+		dayCount = defaultDayCount
+	}
+
+	orderRetryInterval := time.Minute * time.Duration(cfg.StandingOrders.RetryInterval)
+	if orderRetryInterval <= 0 {
+		orderRetryInterval = defaultOrderRetryInterval
+	}
+
+	limits, err := newLimits(cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	holdExpiry := time.Hour * time.Duration(cfg.Holds.DefaultExpiry)
+	if holdExpiry <= 0 {
+		holdExpiry = defaultHoldExpiry
+	}
+
+	riskEngine, err := riskengine.NewEngineFromConfig(cfg.Risk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid risk rules: %v", err)
+	}
+
+	if err = migratePasswords(db); err != nil {
+		return nil, fmt.Errorf("error migrating passwords: %v", err)
+	}
+
 	return &bankingService{
-		db: db,
+		db:              db,
+		rates:           rates,
+		maxFailedLogins: cfg.Auth.MaxFailedLogins,
+		overdraftFee:    cfg.Accounts.OverdraftFee,
+		interestRates:   interestRates,
+		dayCount:        dayCount,
+
+		orderRetryInterval: orderRetryInterval,
+		orderMaxRetries:    cfg.StandingOrders.MaxRetries,
+		limits:             limits,
+		holdExpiry:         holdExpiry,
+		maxHoldExpiry:      time.Hour * time.Duration(cfg.Holds.MaxExpiry),
+		risk:               riskEngine,
 	}, nil
 }
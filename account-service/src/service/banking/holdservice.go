@@ -0,0 +1,356 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+)
+
+// defaultHoldExpiry is how long holds last when neither the request nor the
+// config says
+const defaultHoldExpiry = time.Hour * 24 * 7
+
+const holdColumns = `id, account_id, amount, currency, captured_amount, COALESCE(transaction_id::text, ''), status,
+	COALESCE(reference, ''), created_by, expires_at, created_at, updated_at`
+
+func scanHold(row rowScanner) (*model.Hold, error) {
+	var h model.Hold
+	var captured sql.NullString
+	err := row.Scan(&h.ID, &h.AccountID, &h.Amount, &h.Amount.Currency, &captured, &h.TransactionID, &h.Status,
+		&h.Reference, &h.CreatedBy, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if captured.Valid {
+		amount, err := money.Parse(captured.String, h.Amount.Currency)
+		if err != nil {
+			return nil, err
+		}
+		h.CapturedAmount = &amount
+	}
+	return &h, nil
+}
+
+// PlaceHold reserves a hold's amount out of its account's available balance.
+// The amount counts against the account's withdrawal limits now, and must be
+// available without going past the account's overdraft limit. An amount
+// without a currency is taken to be in the account's currency.
+func (s *bankingService) PlaceHold(hold *model.Hold) error {
+	if err := hold.ApplyExpiry(s.holdExpiry, s.maxHoldExpiry); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, hold.AccountID)
+	if err != nil {
+		return err
+	}
+	if err = postingError(account, true); err != nil {
+		return err
+	}
+	hold.Amount, err = inAccountCurrency(account, hold.Amount)
+	if err != nil {
+		return err
+	}
+	if err = s.checkLimits(tx, account, hold.Amount, true); err != nil {
+		return err
+	}
+
+	available, err := account.AvailableBalance.Sub(hold.Amount)
+	if err != nil {
+		return err
+	}
+	if cmp, err := available.Cmp(account.OverdraftLimit.Neg()); err != nil || cmp < 0 {
+		return fmt.Errorf("insufficient funds")
+	}
+	if err = reactivate(tx, account); err != nil {
+		return err
+	}
+	if err = updateAvailableBalance(tx, account.ID, available); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO holds (id, account_id, amount, currency, status, reference, created_by, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		hold.ID, hold.AccountID, hold.Amount, hold.Amount.Currency, hold.Status, nullString(hold.Reference),
+		hold.CreatedBy, hold.ExpiresAt, hold.CreatedAt, hold.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert hold: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetHold gets a hold on an account
+func (s *bankingService) GetHold(accountID string, holdID string) (*model.Hold, error) {
+	hold, err := scanHold(s.db.QueryRow(`SELECT `+holdColumns+` FROM holds WHERE id = $1 AND account_id = $2`, holdID, accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("hold not found")
+		}
+		return nil, fmt.Errorf("failed to query hold: %v", err)
+	}
+	return hold, nil
+}
+
+// ListHolds lists the holds on an account, newest first
+func (s *bankingService) ListHolds(accountID string) ([]model.Hold, error) {
+	rows, err := s.db.Query(`SELECT `+holdColumns+` FROM holds WHERE account_id = $1 ORDER BY created_at DESC`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holds: %v", err)
+	}
+	defer rows.Close()
+
+	holds := []model.Hold{}
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan hold: %v", err)
+		}
+		holds = append(holds, *hold)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating holds: %v", err)
+	}
+	return holds, nil
+}
+
+// CaptureHold settles a pending hold, debiting amount, or the whole hold if
+// amount is nil, and releasing the rest of what it reserved. The debit is
+// posted like a withdrawal, overdraft fee included, but the funds the hold
+// reserved count as available to it. Limits are not checked again: the hold
+// was counted against them when it was placed, and keeps counting for what
+// is captured.
+func (s *bankingService) CaptureHold(accountID string, holdID string, amount *money.Money) (*model.Hold, *model.Transaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// the account is locked before its hold, like everywhere else
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	hold, err := lockHold(tx, accountID, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+	captured, err := hold.CaptureAmount(amount, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = postingError(account, true); err != nil {
+		return nil, nil, err
+	}
+	if captured, err = inAccountCurrency(account, captured); err != nil {
+		return nil, nil, err
+	}
+
+	if err = releaseFunds(tx, account, hold.Amount); err != nil {
+		return nil, nil, err
+	}
+	_, fee, err := s.debitBalance(account, captured)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction := model.NewTransaction(account.ID, captured, "debit")
+	transaction.Reference = hold.Reference
+	if err = recordTransaction(tx, transaction); err != nil {
+		return nil, nil, err
+	}
+	if err = recordFee(tx, fee); err != nil {
+		return nil, nil, err
+	}
+
+	hold.Status = model.HoldCaptured
+	hold.CapturedAmount = &captured
+	hold.TransactionID = transaction.ID
+	_, err = tx.Exec(`
+		UPDATE holds SET status = $1, captured_amount = $2, transaction_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`,
+		hold.Status, captured, transaction.ID, hold.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update hold: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return hold, transaction, nil
+}
+
+// ReleaseHold cancels a pending hold, making what it reserved available
+// again
+func (s *bankingService) ReleaseHold(accountID string, holdID string) (*model.Hold, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	hold, err := lockHold(tx, accountID, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err = hold.CheckRelease(); err != nil {
+		return nil, err
+	}
+	if err = endHold(tx, account, hold, model.HoldReleased); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds releases up to limit pending holds that expired by now,
+// returning how many it released
+func (s *bankingService) ExpireHolds(now time.Time, limit int) (int, error) {
+	rows, err := s.db.Query(`
+		SELECT id, account_id FROM holds
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3`, model.HoldPending, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired holds: %v", err)
+	}
+	var expired []model.Hold
+	for rows.Next() {
+		var hold model.Hold
+		if err = rows.Scan(&hold.ID, &hold.AccountID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan hold: %v", err)
+		}
+		expired = append(expired, hold)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating holds: %v", err)
+	}
+
+	released := 0
+	for _, hold := range expired {
+		ok, err := s.expireHold(hold.AccountID, hold.ID, now)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+	return released, nil
+}
+
+// expireHold releases one expired hold in its own database transaction. It
+// reports false if the hold was captured or released in the meantime.
+func (s *bankingService) expireHold(accountID string, holdID string, now time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return false, err
+	}
+	hold, err := lockHold(tx, accountID, holdID)
+	if err != nil {
+		return false, err
+	}
+	if hold.Status != model.HoldPending || now.Before(hold.ExpiresAt) {
+		return false, nil
+	}
+	if err = endHold(tx, account, hold, model.HoldExpired); err != nil {
+		return false, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+func lockHold(tx *sql.Tx, accountID string, holdID string) (*model.Hold, error) {
+	hold, err := scanHold(tx.QueryRow(`
+		SELECT `+holdColumns+`
+		FROM holds
+		WHERE id = $1 AND account_id = $2
+		FOR UPDATE`, holdID, accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("hold not found")
+		}
+		return nil, fmt.Errorf("failed to query hold: %v", err)
+	}
+	return hold, nil
+}
+
+// endHold releases what a pending hold reserved and leaves it in status
+func endHold(tx *sql.Tx, account *model.Account, hold *model.Hold, status string) error {
+	if err := releaseFunds(tx, account, hold.Amount); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE holds SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", status, hold.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update hold: %v", err)
+	}
+	hold.Status = status
+	return nil
+}
+
+// releaseFunds makes amount reserved by a hold available again on a locked
+// account
+func releaseFunds(tx *sql.Tx, account *model.Account, amount money.Money) error {
+	available, err := account.AvailableBalance.Add(amount)
+	if err != nil {
+		return err
+	}
+	if err = updateAvailableBalance(tx, account.ID, available); err != nil {
+		return err
+	}
+	account.AvailableBalance = available
+	return nil
+}
+
+func updateAvailableBalance(tx *sql.Tx, accountID string, available money.Money) error {
+	_, err := tx.Exec(`
+		UPDATE accounts
+		SET available_balance = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		available, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update available balance: %v", err)
+	}
+	return nil
+}
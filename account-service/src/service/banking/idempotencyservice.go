@@ -0,0 +1,129 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// idempotentResponse is the outcome of a request made with an idempotency
+// key: the transactions it posted, or the risk review that stopped it
+type idempotentResponse struct {
+	Transactions []model.Transaction `json:"transactions,omitempty"`
+	Review       *model.RiskError    `json:"review,omitempty"`
+}
+
+// transaction returns the outcome of a deposit or withdrawal
+func (r *idempotentResponse) transaction() (*model.Transaction, error) {
+	if r.Review != nil {
+		return nil, r.Review
+	}
+	if len(r.Transactions) != 1 {
+		return nil, fmt.Errorf("idempotent response holds %d transactions, not one", len(r.Transactions))
+	}
+	return &r.Transactions[0], nil
+}
+
+// transfer returns the outcome of a transfer
+func (r *idempotentResponse) transfer() (*model.Transaction, *model.Transaction, error) {
+	if r.Review != nil {
+		return nil, nil, r.Review
+	}
+	if len(r.Transactions) != 2 {
+		return nil, nil, fmt.Errorf("idempotent response holds %d transactions, not a transfer", len(r.Transactions))
+	}
+	return &r.Transactions[0], &r.Transactions[1], nil
+}
+
+// claimIdempotencyKey reserves key for a request inside the database
+// transaction that posts it, so the key is stored if and only if the posting
+// is committed. A request racing another with the same key waits on the
+// insert until the first commits or rolls back. If the key was already used
+// for the same request its stored response is returned, and the caller must
+// return that instead of posting again. A nil key claims nothing.
+func claimIdempotencyKey(tx *sql.Tx, key *model.IdempotencyKey) (*idempotentResponse, error) {
+	if key == nil {
+		return nil, nil
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (user_email, idempotency_key, operation, request_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_email, idempotency_key) DO NOTHING`,
+		key.UserEmail, key.Key, key.Operation, key.RequestHash, key.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %v", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %v", err)
+	}
+	if claimed == 1 {
+		return nil, nil
+	}
+
+	var requestHash string
+	var response []byte
+	err = tx.QueryRow("SELECT request_hash, response FROM idempotency_keys WHERE user_email = $1 AND idempotency_key = $2",
+		key.UserEmail, key.Key).Scan(&requestHash, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %v", err)
+	}
+	if requestHash != key.RequestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	var replayed idempotentResponse
+	if err = json.Unmarshal(response, &replayed); err != nil {
+		return nil, fmt.Errorf("failed to decode idempotent response: %v", err)
+	}
+	key.Replayed = true
+	return &replayed, nil
+}
+
+// saveIdempotentResponse stores the transactions a request posted against
+// the key it claimed, in the same database transaction
+func saveIdempotentResponse(tx *sql.Tx, key *model.IdempotencyKey, transactions ...*model.Transaction) error {
+	response := idempotentResponse{Transactions: make([]model.Transaction, len(transactions))}
+	for i, transaction := range transactions {
+		response.Transactions[i] = *transaction
+	}
+	return storeIdempotentResponse(tx, key, &response)
+}
+
+func storeIdempotentResponse(tx *sql.Tx, key *model.IdempotencyKey, r *idempotentResponse) error {
+	if key == nil {
+		return nil
+	}
+
+	response, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %v", err)
+	}
+	_, err = tx.Exec("UPDATE idempotency_keys SET response = $1 WHERE user_email = $2 AND idempotency_key = $3",
+		response, key.UserEmail, key.Key)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %v", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys forgets keys used before the given time, so they may
+// be used again. It returns the number of keys purged.
+func (s *bankingService) PurgeIdempotencyKeys(before time.Time) (int, error) {
+	result, err := s.db.Exec("DELETE FROM idempotency_keys WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %v", err)
+	}
+	return int(n), nil
+}
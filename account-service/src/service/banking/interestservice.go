@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	"github.com/lib/pq"
+)
+
+const defaultDayCount = 365
+
+// AccrueInterestRange accrues interest for every day from from to to, and
+// posts each month in the range whose last day has been accrued. Days and
+// months already done are skipped, so a range can be run again safely.
+func (s *bankingService) AccrueInterestRange(from time.Time, to time.Time) (*model.InterestRun, error) {
+	from, to = model.StartOfDay(from), model.StartOfDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("range ends before it starts")
+	}
+	if !to.Before(model.StartOfDay(time.Now())) {
+		return nil, fmt.Errorf("interest can only be accrued for days that have ended")
+	}
+
+	run := &model.InterestRun{From: from.Format(model.DateLayout), To: to.Format(model.DateLayout)}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		n, err := s.accrueInterest(day)
+		if err != nil {
+			return nil, err
+		}
+		run.Accrued += n
+
+		// the last day of a month has been accrued, so the month is complete
+		if next := day.AddDate(0, 0, 1); next.Day() == 1 {
+			n, err = s.postInterest(model.StartOfMonth(day))
+			if err != nil {
+				return nil, err
+			}
+			run.Posted += n
+		}
+	}
+	return run, nil
+}
+
+// accrueInterest records a day's interest for every account whose type has a
+// rate table and that has not yet accrued for that day. The balance used is
+// the one the account had at the end of the day.
+func (s *bankingService) accrueInterest(day time.Time) (int, error) {
+	if len(s.interestRates) == 0 {
+		return 0, nil
+	}
+	types := make([]string, 0, len(s.interestRates))
+	for t := range s.interestRates {
+		types = append(types, t)
+	}
+	end := day.AddDate(0, 0, 1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// work back from the current balance through everything posted since
+	rows, err := tx.Query(`
+		SELECT a.id, a.account_type, a.balance - COALESCE((
+			SELECT SUM(CASE WHEN t.type = ANY($3) THEN -t.amount ELSE t.amount END) 
+			FROM transactions t 
+			WHERE t.account = a.id AND t.timestamp >= $2
+		), 0) 
+		FROM accounts a 
+		WHERE a.account_type = ANY($1) 
+			AND a.created_at < $2 
+			AND (a.closed_at IS NULL OR a.closed_at >= $2) 
+			AND NOT EXISTS (
+				SELECT 1 FROM interest_accruals i WHERE i.account_id = a.id AND i.accrual_date = $4
+			)`, pq.Array(types), end, pq.Array(model.DebitTypes()), day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query balances: %v", err)
+	}
+
+	type accrual struct {
+		accountID string
+		balance   money.Money
+		rate      string
+	}
+	var accruals []accrual
+	for rows.Next() {
+		var a accrual
+		var accountType string
+		if err = rows.Scan(&a.accountID, &accountType, &a.balance); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan balance: %v", err)
+		}
+		if !a.balance.IsPositive() {
+			continue
+		}
+		if a.rate = s.interestRates[accountType].RateFor(a.balance); a.rate == "" {
+			continue
+		}
+		accruals = append(accruals, a)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating balances: %v", err)
+	}
+
+	accrued := 0
+	for _, a := range accruals {
+		res, err := tx.Exec(`
+			INSERT INTO interest_accruals (account_id, accrual_date, balance, rate, amount) 
+			VALUES ($1, $2, $3, $4, ROUND($3::numeric * $4::numeric / $5, 10)) 
+			ON CONFLICT DO NOTHING`,
+			a.accountID, day, a.balance, a.rate, s.dayCount)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record accrual: %v", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			accrued += int(n)
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return accrued, nil
+}
+
+// postInterest pays each account the interest it accrued over a month,
+// rounded to the cent, as an interest transaction. Each account is posted in
+// its own database transaction, and at most once per month.
+func (s *bankingService) postInterest(month time.Time) (int, error) {
+	rows, err := s.db.Query(`
+		SELECT i.account_id, ROUND(SUM(i.amount), 2) 
+		FROM interest_accruals i 
+		WHERE i.accrual_date >= $1 AND i.accrual_date < $2 
+			AND NOT EXISTS (
+				SELECT 1 FROM interest_postings p WHERE p.account_id = i.account_id AND p.period = $1
+			) 
+		GROUP BY i.account_id`, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query accruals: %v", err)
+	}
+
+	type posting struct {
+		accountID string
+		amount    money.Money
+	}
+	var postings []posting
+	for rows.Next() {
+		var p posting
+		if err = rows.Scan(&p.accountID, &p.amount); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan accruals: %v", err)
+		}
+		postings = append(postings, p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating accruals: %v", err)
+	}
+
+	posted := 0
+	for _, p := range postings {
+		ok, err := s.postAccountInterest(p.accountID, month, p.amount)
+		if err != nil {
+			return posted, err
+		}
+		if ok {
+			posted++
+		}
+	}
+	return posted, nil
+}
+
+// postAccountInterest pays one account its interest for a month. It reports
+// false if the month was already posted. Interest that cannot be paid, to a
+// closed account or under a cent, is recorded as posted with no transaction.
+func (s *bankingService) postAccountInterest(accountID string, month time.Time, amount money.Money) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return false, err
+	}
+	amount.Currency = account.Balance.Currency
+
+	var transaction *model.Transaction
+	if amount.IsPositive() && model.CanCredit(account.Status) {
+		transaction = model.NewTransaction(accountID, amount, "interest")
+	}
+
+	// claiming the period first means a concurrent run cannot post it too
+	var transactionID *string
+	if transaction != nil {
+		transactionID = &transaction.ID
+	}
+	res, err := tx.Exec(`
+		INSERT INTO interest_postings (account_id, period, amount, transaction_id) 
+		VALUES ($1, $2, $3, $4) 
+		ON CONFLICT DO NOTHING`,
+		accountID, month, amount, transactionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record interest posting: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if transaction != nil {
+		if err = recordTransaction(tx, transaction); err != nil {
+			return false, err
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// newInterestRates builds the rate tables of each account type from config
+func newInterestRates(cfg config.Interest) (map[string]model.RateTable, error) {
+	rates := map[string]model.RateTable{}
+	for accountType, tiers := range cfg.Rates {
+		modelTiers := make([]model.InterestTier, len(tiers))
+		for i, tier := range tiers {
+			minBalance, err := money.Parse(tier.MinBalance, "")
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s min_balance: %v", accountType, err)
+			}
+			modelTiers[i] = model.InterestTier{MinBalance: minBalance, Rate: tier.Rate}
+		}
+		table, err := model.NewRateTable(modelTiers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s rates: %v", accountType, err)
+		}
+		rates[accountType] = table
+	}
+	return rates, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewInterestRates(t *testing.T) {
+
+	rates, err := newInterestRates(config.Interest{
+		Rates: map[string][]config.InterestTier{
+			"savings": {
+				{MinBalance: "0.00", Rate: "0.0200"},
+				{MinBalance: "10000.00", Rate: "0.0350"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	if got := rates["savings"].RateFor(money.New(1500000, "EUR")); got != "0.0350" {
+		t.Errorf("Expected 0.0350 but got %q", got)
+	}
+	if _, ok := rates["checking"]; ok {
+		t.Errorf("Expected checking accounts to earn nothing")
+	}
+
+	_, err = newInterestRates(config.Interest{
+		Rates: map[string][]config.InterestTier{
+			"savings": {{MinBalance: "lots", Rate: "0.02"}},
+		},
+	})
+	if err == nil {
+		t.Errorf("Expected an invalid min_balance to be rejected")
+	}
+}
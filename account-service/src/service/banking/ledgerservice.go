@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+// postJournal writes a journal entry and moves the balances of the customer
+// accounts it posts to. Account balances are a cache of their postings and
+// only change here. Pending holds keep what they reserve, so the available
+// balance moves by the same amount.
+func postJournal(tx *sql.Tx, journal *model.JournalEntry) error {
+	if err := journal.Check(); err != nil {
+		return err
+	}
+	_, err := tx.Exec("INSERT INTO journal_entries (id, transaction_id, kind, created_at) VALUES ($1, $2, $3, $4)",
+		journal.ID, nullString(journal.TransactionID), journal.Kind, journal.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert journal entry: %v", err)
+	}
+
+	for _, p := range journal.Postings {
+		_, err = tx.Exec("INSERT INTO postings (journal_id, ledger, account_id, amount, currency) VALUES ($1, $2, $3, $4, $5)",
+			journal.ID, p.Ledger, nullString(p.AccountID), p.Amount, p.Amount.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to insert posting: %v", err)
+		}
+		if p.Ledger != model.LedgerCustomer {
+			continue
+		}
+		res, err := tx.Exec(`
+			UPDATE accounts
+			SET balance = balance + $1, available_balance = available_balance + $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND currency = $3`,
+			p.Amount, p.AccountID, p.Amount.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to update balance: %v", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return fmt.Errorf("failed to update balance of account %s in %s", p.AccountID, p.Amount.Currency)
+		}
+	}
+	return nil
+}
+
+// transactionJournal builds the journal entry that posts a transaction
+func transactionJournal(tx *sql.Tx, transaction *model.Transaction) (*model.JournalEntry, error) {
+	var reversalOfType string
+	if transaction.ReversalOf != "" {
+		err := tx.QueryRow("SELECT type FROM transactions WHERE id = $1", transaction.ReversalOf).Scan(&reversalOfType)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("transaction %s not found", transaction.ReversalOf)
+			}
+			return nil, fmt.Errorf("failed to query transaction: %v", err)
+		}
+	}
+	contra := model.ContraLedger(transaction.Type, transaction.TransferID != "", reversalOfType)
+	return model.NewTransactionJournal(transaction, contra), nil
+}
+
+// VerifyLedger recomputes every account's balance from its postings and
+// reports the accounts whose cached balance has drifted from it, along with
+// any journal entry that does not balance and the balances of the bank's
+// ledger accounts
+func (s *bankingService) VerifyLedger() (*model.LedgerReport, error) {
+	// one snapshot, so postings made while the checks run cannot show up
+	// as drift
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	report := &model.LedgerReport{
+		CheckedAt:  time.Now(),
+		Drift:      []model.BalanceDrift{},
+		Unbalanced: []string{},
+		Ledgers:    []model.LedgerBalance{},
+	}
+	if err = tx.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&report.Accounts); err != nil {
+		return nil, fmt.Errorf("failed to count accounts: %v", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT a.id, a.balance, COALESCE(p.total, 0), a.currency
+		FROM accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(amount) AS total
+			FROM postings
+			WHERE account_id IS NOT NULL
+			GROUP BY account_id
+		) p ON p.account_id = a.id
+		WHERE a.balance <> COALESCE(p.total, 0)
+		ORDER BY a.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balances: %v", err)
+	}
+	for rows.Next() {
+		var d model.BalanceDrift
+		if err = rows.Scan(&d.AccountID, &d.Balance, &d.Ledger, &d.Balance.Currency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan balance: %v", err)
+		}
+		d.Ledger.Currency = d.Balance.Currency
+		if d.Drift, err = d.Balance.Sub(d.Ledger); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Drift = append(report.Drift, d)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balances: %v", err)
+	}
+
+	rows, err = tx.Query(`
+		SELECT DISTINCT journal_id
+		FROM postings
+		GROUP BY journal_id, currency
+		HAVING SUM(amount) <> 0
+		ORDER BY journal_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal entries: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journal entry: %v", err)
+		}
+		report.Unbalanced = append(report.Unbalanced, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating journal entries: %v", err)
+	}
+
+	rows, err = tx.Query(`
+		SELECT ledger, SUM(amount), currency
+		FROM postings
+		WHERE account_id IS NULL
+		GROUP BY ledger, currency
+		ORDER BY ledger, currency`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger balances: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b model.LedgerBalance
+		if err = rows.Scan(&b.Ledger, &b.Balance, &b.Balance.Currency); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger balance: %v", err)
+		}
+		report.Ledgers = append(report.Ledgers, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger balances: %v", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+)
+
+// rowQuerier runs a query returning at most one row, in or out of a
+// database transaction
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// GetAccountLimits returns the limits that apply to an account: its own
+// where it has them, and its account type's otherwise
+func (s *bankingService) GetAccountLimits(accountID string) (*model.Limits, error) {
+	var account model.Account
+	err := s.db.QueryRow("SELECT id, account_type, currency FROM accounts WHERE id = $1", accountID).
+		Scan(&account.ID, &account.Type, &account.Balance.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("account %s not found", accountID)
+		}
+		return nil, fmt.Errorf("failed to query account: %v", err)
+	}
+
+	limits, err := s.accountLimits(s.db, &account)
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// SetAccountLimits replaces an account's own limits. A limit left nil falls
+// back to the account type's.
+func (s *bankingService) SetAccountLimits(accountID string, limits model.Limits, actor string) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return err
+	}
+	if account.Status == model.AccountClosed {
+		return fmt.Errorf("account is closed")
+	}
+	for _, amount := range []*money.Money{limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.DailyDeposit, limits.MonthlyDeposit} {
+		if amount == nil {
+			continue
+		}
+		if _, err = inAccountCurrency(account, *amount); err != nil {
+			return err
+		}
+	}
+
+	previous, err := ownLimits(tx, accountID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO account_limits (account_id, daily_withdrawal, monthly_withdrawal, withdrawals_per_hour,
+			daily_deposit, monthly_deposit, deposits_per_hour, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP)
+		ON CONFLICT (account_id) DO UPDATE SET
+			daily_withdrawal = EXCLUDED.daily_withdrawal,
+			monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+			withdrawals_per_hour = EXCLUDED.withdrawals_per_hour,
+			daily_deposit = EXCLUDED.daily_deposit,
+			monthly_deposit = EXCLUDED.monthly_deposit,
+			deposits_per_hour = EXCLUDED.deposits_per_hour,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`,
+		accountID, limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.WithdrawalsPerHour,
+		limits.DailyDeposit, limits.MonthlyDeposit, limits.DepositsPerHour, actor)
+	if err != nil {
+		return fmt.Errorf("failed to update account limits: %v", err)
+	}
+
+	err = recordAudit(tx, model.NewAccountLimitsChangedEvent(accountID, previous, limits, actor))
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// checkLimits refuses a withdrawal or deposit of amount that would take a
+// locked account past one of its limits. It must run in the database
+// transaction holding the account's lock, so that concurrent postings are
+// counted one after the other.
+func (s *bankingService) checkLimits(tx *sql.Tx, account *model.Account, amount money.Money, withdrawal bool) error {
+	limits, err := s.accountLimits(tx, account)
+	if err != nil {
+		return err
+	}
+	if limits == (model.Limits{}) {
+		return nil
+	}
+
+	usage, err := limitUsage(tx, account, withdrawal, time.Now())
+	if err != nil {
+		return err
+	}
+	if withdrawal {
+		return limits.CheckWithdrawal(amount, usage)
+	}
+	return limits.CheckDeposit(amount, usage)
+}
+
+// checkTransferLimits checks a transfer of amount out of a locked account
+// against its withdrawal limits
+func (s *bankingService) checkTransferLimits(tx *sql.Tx, from *model.Account, amount money.Money) error {
+	amount, err := inAccountCurrency(from, amount)
+	if err != nil {
+		return err
+	}
+	return s.checkLimits(tx, from, amount, true)
+}
+
+// accountLimits returns the account type's limits overridden by the
+// account's own, in the account's currency
+func (s *bankingService) accountLimits(q rowQuerier, account *model.Account) (model.Limits, error) {
+	own, err := ownLimits(q, account.ID)
+	if err != nil {
+		return model.Limits{}, err
+	}
+	return s.limits[account.Type].Override(own).InCurrency(account.Balance.Currency), nil
+}
+
+// ownLimits reads the limits set on an account itself, if any
+func ownLimits(q rowQuerier, accountID string) (model.Limits, error) {
+	var limits model.Limits
+	err := q.QueryRow(`
+		SELECT daily_withdrawal, monthly_withdrawal, withdrawals_per_hour, daily_deposit, monthly_deposit, deposits_per_hour
+		FROM account_limits
+		WHERE account_id = $1`, accountID).Scan(&limits.DailyWithdrawal, &limits.MonthlyWithdrawal, &limits.WithdrawalsPerHour,
+		&limits.DailyDeposit, &limits.MonthlyDeposit, &limits.DepositsPerHour)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.Limits{}, fmt.Errorf("failed to query account limits: %v", err)
+	}
+	return limits, nil
+}
+
+// limitUsage totals what an account has withdrawn, or deposited, today and
+// this month and counts how many times in the last hour. Withdrawals are
+// every debit made by the account's owners: withdrawals, transfers out and
+// standing orders, and holds. A hold counts from when it was placed, for
+// what it still reserves or what was captured, so its capture is not
+// counted again; released and expired holds do not count. Deposits are
+// credits that are not the leg of a transfer.
+func limitUsage(tx *sql.Tx, account *model.Account, withdrawal bool, now time.Time) (model.LimitUsage, error) {
+	filter := "type = 'debit' AND id NOT IN (SELECT transaction_id FROM holds WHERE account_id = $1 AND transaction_id IS NOT NULL)"
+	if !withdrawal {
+		filter = "type = 'credit' AND transfer_id IS NULL"
+	}
+
+	today := model.StartOfDay(now)
+	month := model.StartOfMonth(now)
+	hourAgo := now.Add(-time.Hour)
+	since := month
+	if hourAgo.Before(since) {
+		since = hourAgo
+	}
+
+	usage := model.LimitUsage{}
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(amount) FILTER (WHERE timestamp >= $2), 0),
+			COALESCE(SUM(amount) FILTER (WHERE timestamp >= $3), 0),
+			COUNT(*) FILTER (WHERE timestamp >= $4)
+		FROM transactions
+		WHERE account = $1 AND timestamp >= $5 AND `+filter,
+		account.ID, today, month, hourAgo, since).Scan(&usage.Today, &usage.ThisMonth, &usage.LastHour)
+	if err != nil {
+		return model.LimitUsage{}, fmt.Errorf("failed to query account usage: %v", err)
+	}
+	usage.Today.Currency = account.Balance.Currency
+	usage.ThisMonth.Currency = account.Balance.Currency
+	if !withdrawal {
+		return usage, nil
+	}
+
+	held := model.LimitUsage{}
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(held) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(held) FILTER (WHERE created_at >= $3), 0),
+			COUNT(*) FILTER (WHERE created_at >= $4)
+		FROM (
+			SELECT CASE WHEN status = $7 THEN captured_amount ELSE amount END AS held, created_at
+			FROM holds
+			WHERE account_id = $1 AND status IN ($6, $7) AND created_at >= $5
+		) h`,
+		account.ID, today, month, hourAgo, since, model.HoldPending, model.HoldCaptured).Scan(&held.Today, &held.ThisMonth, &held.LastHour)
+	if err != nil {
+		return model.LimitUsage{}, fmt.Errorf("failed to query holds: %v", err)
+	}
+	held.Today.Currency = account.Balance.Currency
+	held.ThisMonth.Currency = account.Balance.Currency
+	if usage.Today, err = usage.Today.Add(held.Today); err != nil {
+		return model.LimitUsage{}, err
+	}
+	if usage.ThisMonth, err = usage.ThisMonth.Add(held.ThisMonth); err != nil {
+		return model.LimitUsage{}, err
+	}
+	usage.LastHour += held.LastHour
+	return usage, nil
+}
+
+func newLimits(cfg map[string]config.Limits) (map[string]model.Limits, error) {
+	limits := map[string]model.Limits{}
+	for accountType, typeLimits := range cfg {
+		var l model.Limits
+		for _, amount := range []struct {
+			rule  model.LimitRule
+			value string
+			limit **money.Money
+		}{
+			{model.LimitDailyWithdrawal, typeLimits.DailyWithdrawal, &l.DailyWithdrawal},
+			{model.LimitMonthlyWithdrawal, typeLimits.MonthlyWithdrawal, &l.MonthlyWithdrawal},
+			{model.LimitDailyDeposit, typeLimits.DailyDeposit, &l.DailyDeposit},
+			{model.LimitMonthlyDeposit, typeLimits.MonthlyDeposit, &l.MonthlyDeposit},
+		} {
+			if amount.value == "" {
+				continue
+			}
+			parsed, err := money.Parse(amount.value, "")
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s limit: %v", accountType, amount.rule, err)
+			}
+			*amount.limit = &parsed
+		}
+		if typeLimits.WithdrawalsPerHour > 0 {
+			n := typeLimits.WithdrawalsPerHour
+			l.WithdrawalsPerHour = &n
+		}
+		if typeLimits.DepositsPerHour > 0 {
+			n := typeLimits.DepositsPerHour
+			l.DepositsPerHour = &n
+		}
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s limits: %v", accountType, err)
+		}
+		limits[accountType] = l
+	}
+	return limits, nil
+}
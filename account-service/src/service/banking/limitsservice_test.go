@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+	"github.com/google/uuid"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectUsage expects an account's withdrawal usage to be read: debits other
+// than hold captures, then holds still counting
+func expectUsage(mock sqlmock.Sqlmock, accountID string, debited string, held string, heldCount int) {
+	expectNoLimits(mock, accountID)
+	mock.ExpectQuery(`FROM transactions\s+WHERE account = \$1 AND timestamp >= \$5 AND type = 'debit' AND id NOT IN \(SELECT transaction_id FROM holds`).
+		WithArgs(accountID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"today", "month", "hour"}).AddRow(debited, debited, 0))
+	mock.ExpectQuery(`FROM holds\s+WHERE account_id = \$1 AND status IN \(\$6, \$7\)`).
+		WithArgs(accountID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), model.HoldPending, model.HoldCaptured).
+		WillReturnRows(sqlmock.NewRows([]string{"today", "month", "hour"}).AddRow(held, held, heldCount))
+}
+
+func TestPlaceHoldCountsPendingHoldsAgainstLimits(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	daily := money.New(100000, "USD")
+	service.limits = map[string]model.Limits{"checking": {DailyWithdrawal: &daily}}
+	service.holdExpiry = time.Hour
+
+	account := &model.Account{
+		ID:               uuid.New().String(),
+		Type:             "checking",
+		Balance:          money.New(500000, "USD"),
+		AvailableBalance: money.New(410000, "USD"),
+		OverdraftLimit:   money.Zero("USD"),
+		Status:           model.AccountActive,
+	}
+	now := time.Now()
+	hold := &model.Hold{ID: uuid.New().String(), AccountID: account.ID, Amount: money.New(90000, "USD"),
+		Status: model.HoldPending, CreatedAt: now, UpdatedAt: now}
+
+	// a 900.00 hold is already pending, so another does not fit in 1000.00
+	mock.ExpectBegin()
+	expectLockAccount(mock, account)
+	expectUsage(mock, account.ID, "0.00", "900.00", 1)
+	mock.ExpectRollback()
+
+	err := service.PlaceHold(hold)
+	var limitErr *model.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Rule != model.LimitDailyWithdrawal {
+		t.Errorf("Expected the daily withdrawal limit to refuse the hold, but got %v", err)
+	}
+}
+
+func TestLimitUsageAddsHolds(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	account := &model.Account{ID: uuid.New().String(), Balance: money.Zero("USD")}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"today", "month", "hour"}).AddRow("100.00", "250.00", 1))
+	mock.ExpectQuery(`FROM holds`).
+		WillReturnRows(sqlmock.NewRows([]string{"today", "month", "hour"}).AddRow("900.00", "900.00", 2))
+	mock.ExpectRollback()
+
+	tx, err := service.db.Begin()
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	defer tx.Rollback()
+	usage, err := limitUsage(tx, account, true, time.Now())
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if usage.Today != money.New(100000, "USD") || usage.ThisMonth != money.New(115000, "USD") || usage.LastHour != 3 {
+		t.Errorf("Expected withdrawals and holds to be added up, but got %+v", usage)
+	}
+}
+
+func TestLimitUsageOfDepositsIgnoresHolds(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	account := &model.Account{ID: uuid.New().String(), Balance: money.Zero("USD")}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM transactions\s+WHERE account = \$1 AND timestamp >= \$5 AND type = 'credit' AND transfer_id IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"today", "month", "hour"}).AddRow("50.00", "50.00", 1))
+	mock.ExpectRollback()
+
+	tx, err := service.db.Begin()
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	defer tx.Rollback()
+	usage, err := limitUsage(tx, account, false, time.Now())
+	if err != nil || usage.Today != money.New(5000, "USD") {
+		t.Errorf("Expected deposits of 50.00 today, but got %+v, %v", usage, err)
+	}
+}
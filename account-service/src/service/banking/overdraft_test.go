@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+)
+
+func TestDebitBalance(t *testing.T) {
+
+	s := &bankingService{overdraftFee: "25.00"}
+	usd := func(minor int64) money.Money { return money.New(minor, money.DefaultCurrency) }
+
+	tests := []struct {
+		name        string
+		accountType string
+		amount      money.Money
+		balance     money.Money
+		fee         bool
+		fails       bool
+	}{
+		{"within balance", model.AccountChecking, usd(4000), usd(1000), false, false},
+		{"overdrawn with fee", model.AccountChecking, usd(6000), usd(-3500), true, false},
+		{"fee takes it past the limit", model.AccountChecking, usd(13000), usd(0), false, true},
+		{"credit accounts pay no fee", model.AccountCredit, usd(15000), usd(-10000), false, false},
+		{"past the credit limit", model.AccountCredit, usd(15100), usd(0), false, true},
+	}
+
+	for _, tt := range tests {
+		account := &model.Account{
+			ID:               "account",
+			Type:             tt.accountType,
+			Balance:          usd(5000),
+			AvailableBalance: usd(5000),
+			OverdraftLimit:   usd(10000),
+		}
+		balance, fee, err := s.debitBalance(account, tt.amount)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("%s: expected insufficient funds", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected error to be nil, but got %v", tt.name, err)
+			continue
+		}
+		if balance != tt.balance {
+			t.Errorf("%s: expected balance %s but got %s", tt.name, tt.balance, balance)
+		}
+		if (fee != nil) != tt.fee {
+			t.Errorf("%s: expected a fee to be %v but got %v", tt.name, tt.fee, fee)
+		}
+	}
+}
+
+func TestDebitBalanceHonoursHolds(t *testing.T) {
+
+	s := &bankingService{}
+	usd := func(minor int64) money.Money { return money.New(minor, money.DefaultCurrency) }
+
+	// 30.00 of the 50.00 balance is held
+	account := &model.Account{
+		ID:               "account",
+		Type:             model.AccountSavings,
+		Balance:          usd(5000),
+		AvailableBalance: usd(2000),
+		OverdraftLimit:   usd(0),
+	}
+
+	if _, _, err := s.debitBalance(account, usd(2001)); err == nil {
+		t.Errorf("Expected a debit of more than the available balance to be refused")
+	}
+	balance, _, err := s.debitBalance(account, usd(2000))
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if balance != usd(3000) {
+		t.Errorf("Expected balance 30.00 USD but got %s", balance)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/banking-app/account-service/src/model"
+
+	"github.com/lib/pq"
+)
+
+// Owner methods
+
+// GetAccountRole returns the role a user holds on an account, or an empty
+// string if they hold none
+func (s *bankingService) GetAccountRole(accountID string, email string) (string, error) {
+	var role string
+	err := s.db.QueryRow("SELECT role FROM account_owners WHERE account_id = $1 AND user_email = $2", accountID, email).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to query account role: %v", err)
+	}
+	return role, nil
+}
+
+// GetUserAccounts returns the accounts a user holds a role on
+func (s *bankingService) GetUserAccounts(email string) ([]model.OwnedAccount, error) {
+	rows, err := s.db.Query(`
+		SELECT a.id, a.first_name, a.last_name, a.email, a.account_type, 
+			a.balance, a.currency, a.available_balance, a.overdraft_limit, a.status, a.created_at, a.updated_at, 
+			a.closed_at, COALESCE(a.closure_reason, ''), o.role
+		FROM account_owners o JOIN accounts a ON a.id = o.account_id
+		WHERE o.user_email = $1
+		ORDER BY a.created_at`, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %v", err)
+	}
+	defer rows.Close()
+
+	accounts := []model.OwnedAccount{}
+	for rows.Next() {
+		var a model.OwnedAccount
+		err := rows.Scan(&a.ID, &a.FirstName, &a.LastName, &a.Email, &a.Type,
+			&a.Balance, &a.Balance.Currency, &a.AvailableBalance, &a.OverdraftLimit, &a.Status, &a.CreatedAt, &a.UpdatedAt, &a.ClosedAt, &a.ClosureReason, &a.Role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %v", err)
+		}
+		a.AvailableBalance.Currency = a.Balance.Currency
+		a.OverdraftLimit.Currency = a.Balance.Currency
+		accounts = append(accounts, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %v", err)
+	}
+	return accounts, nil
+}
+
+func (s *bankingService) AddAccountOwner(owner *model.AccountOwner) error {
+	_, err := s.db.Exec("INSERT INTO account_owners (account_id, user_email, role, created_at) VALUES ($1, $2, $3, $4)",
+		owner.AccountID, owner.Email, owner.Role, owner.CreatedAt)
+	if err != nil {
+		return ownerError(err)
+	}
+	return nil
+}
+
+// RemoveAccountOwner unlinks a joint owner or signatory. The primary owner
+// cannot be removed.
+func (s *bankingService) RemoveAccountOwner(accountID string, email string) error {
+	res, err := s.db.Exec("DELETE FROM account_owners WHERE account_id = $1 AND user_email = $2 AND role <> $3",
+		accountID, email, model.RolePrimary)
+	if err != nil {
+		return fmt.Errorf("failed to remove account owner: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s is not a joint owner or signatory of the account", email)
+	}
+	return nil
+}
+
+// addPrimaryOwner records the user who opened an account
+func addPrimaryOwner(tx *sql.Tx, accountID string, email string) error {
+	_, err := tx.Exec("INSERT INTO account_owners (account_id, user_email, role) VALUES ($1, $2, $3)",
+		accountID, email, model.RolePrimary)
+	if err != nil {
+		return ownerError(err)
+	}
+	return nil
+}
+
+// ownerError turns constraint violations on account_owners into messages
+// for the caller
+func ownerError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return fmt.Errorf("user already holds a role on the account")
+		case "foreign_key_violation":
+			return fmt.Errorf("user or account not found")
+		}
+	}
+	return fmt.Errorf("failed to add account owner: %v", err)
+}
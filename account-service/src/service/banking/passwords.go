@@ -0,0 +1,85 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashPassword hashes a password with bcrypt for storage
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("password is required")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches a stored hash
+func checkPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// isPasswordHash reports whether a stored password is already a bcrypt hash
+func isPasswordHash(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// resolvePassword returns the password to store on update. Records are read,
+// changed and written back whole, so an unchanged or empty password keeps the
+// current hash and anything else is a new password to hash.
+func resolvePassword(current string, given string) (string, error) {
+	if given == "" || given == current {
+		return current, nil
+	}
+	return hashPassword(given)
+}
+
+// migratePasswords hashes any password still stored in plaintext
+func migratePasswords(db *sql.DB) error {
+	for _, table := range []struct{ name, key string }{{"accounts", "id"}, {"users", "email"}} {
+		rows, err := db.Query(fmt.Sprintf("SELECT %s, password FROM %s WHERE password NOT LIKE '$2%%'", table.key, table.name))
+		if err != nil {
+			return fmt.Errorf("failed to query %s passwords: %v", table.name, err)
+		}
+		plain := map[string]string{}
+		for rows.Next() {
+			var key, password string
+			if err := rows.Scan(&key, &password); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s password: %v", table.name, err)
+			}
+			plain[key] = password
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating %s passwords: %v", table.name, err)
+		}
+
+		for key, password := range plain {
+			if isPasswordHash(password) || strings.TrimSpace(password) == "" {
+				continue
+			}
+			hash, err := hashPassword(password)
+			if err != nil {
+				return err
+			}
+			// only replace the value that was read, in case it changed since
+			_, err = db.Exec(fmt.Sprintf("UPDATE %s SET password = $1 WHERE %s = $2 AND password = $3", table.name, table.key), hash, key, password)
+			if err != nil {
+				return fmt.Errorf("failed to hash %s password: %v", table.name, err)
+			}
+		}
+		if len(plain) > 0 {
+			log.Printf("Hashed %d plaintext %s passwords", len(plain), table.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import "testing"
+
+func TestHashPassword(t *testing.T) {
+
+	hash, err := hashPassword("123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	if hash == "123456" || !isPasswordHash(hash) {
+		t.Errorf("Expected a bcrypt hash but got %s", hash)
+	}
+	if !checkPassword(hash, "123456") {
+		t.Errorf("Expected the password to match its hash")
+	}
+	if checkPassword(hash, "654321") {
+		t.Errorf("Expected a different password not to match")
+	}
+	if isPasswordHash("123456") {
+		t.Errorf("Expected a plaintext password not to look like a hash")
+	}
+}
+
+func TestResolvePassword(t *testing.T) {
+
+	current, err := hashPassword("123456")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	// a record read and written back keeps its hash
+	for _, given := range []string{"", current} {
+		resolved, err := resolvePassword(current, given)
+		if err != nil || resolved != current {
+			t.Errorf("Expected the current hash to be kept for %q", given)
+		}
+	}
+
+	resolved, err := resolvePassword(current, "654321")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if !checkPassword(resolved, "654321") {
+		t.Errorf("Expected a new password to be hashed")
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	"github.com/lib/pq"
+)
+
+const transactionColumns = `id, account, amount, currency, type, COALESCE(transfer_id::text, ''),
+	COALESCE(fx_rate::text, ''), COALESCE(reversal_of::text, ''), COALESCE(reference, ''), COALESCE(seq, 0), balance_after::text, timestamp`
+
+func scanTransaction(row rowScanner) (*model.Transaction, error) {
+	var t model.Transaction
+	var balanceAfter sql.NullString
+	err := row.Scan(&t.ID, &t.Account, &t.Amount, &t.Amount.Currency, &t.Type, &t.TransferID, &t.FxRate, &t.ReversalOf, &t.Reference, &t.Seq, &balanceAfter, &t.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if balanceAfter.Valid {
+		balance, err := money.Parse(balanceAfter.String, t.Amount.Currency)
+		if err != nil {
+			return nil, err
+		}
+		t.BalanceAfter = &balance
+	}
+	return &t, nil
+}
+
+// ListAccountIDs lists up to limit account ids in order, starting after the
+// given one, so that every account can be visited in batches
+func (s *bankingService) ListAccountIDs(after string, limit int) ([]string, error) {
+	query := "SELECT id FROM accounts ORDER BY id LIMIT $1"
+	args := []any{limit}
+	if after != "" {
+		query = "SELECT id FROM accounts WHERE id > $2 ORDER BY id LIMIT $1"
+		args = append(args, after)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %v", err)
+	}
+	return ids, nil
+}
+
+// GetAccountRecord reads an account's balance, every transaction posted to
+// it, oldest first, and what ledger adjustments posted to it, as of one
+// snapshot
+func (s *bankingService) GetAccountRecord(accountID string) (*model.AccountRecord, error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	record := &model.AccountRecord{AccountID: accountID}
+	err = tx.QueryRow("SELECT balance, currency FROM accounts WHERE id = $1", accountID).Scan(&record.Balance, &record.Balance.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("account %s not found", accountID)
+		}
+		return nil, fmt.Errorf("failed to query account: %v", err)
+	}
+
+	// adjustments are the journal entries that post no transaction
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM postings p
+		JOIN journal_entries j ON j.id = p.journal_id
+		WHERE p.account_id = $1 AND p.ledger = $2 AND j.transaction_id IS NULL`,
+		accountID, model.LedgerCustomer).Scan(&record.Adjustments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query adjustments: %v", err)
+	}
+	record.Adjustments.Currency = record.Balance.Currency
+
+	rows, err := tx.Query(`SELECT `+transactionColumns+` FROM transactions WHERE account = $1 ORDER BY timestamp`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %v", err)
+		}
+		record.Transactions = append(record.Transactions, *t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %v", err)
+	}
+	return record, nil
+}
+
+// RepublishTransactions queues transactions to be published again, rebuilt
+// from this service's record of them. An event still in the outbox is
+// marked unsent, so the relay picks it up again. It returns how many were
+// queued.
+func (s *bankingService) RepublishTransactions(ids []string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT `+transactionColumns+` FROM transactions WHERE id = ANY($1) ORDER BY timestamp`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query transactions: %v", err)
+	}
+	var transactions []*model.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan transaction: %v", err)
+		}
+		transactions = append(transactions, t)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating transactions: %v", err)
+	}
+
+	for _, t := range transactions {
+		event, err := model.NewOutboxEvent(t)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode transaction: %v", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO outbox (id, kind, event_key, account, payload, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id) DO UPDATE SET payload = EXCLUDED.payload, sent_at = NULL`,
+			event.ID, event.Kind, event.Key, event.Account, event.Payload, event.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to queue transaction %s: %v", t.ID, err)
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return len(transactions), nil
+}
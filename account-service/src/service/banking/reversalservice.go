@@ -0,0 +1,91 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+)
+
+// ReverseTransaction posts the transaction that undoes original on its
+// account, on behalf of actor. The amount and type reversed are read from
+// this service's own record of the original, which must not have been
+// reversed already. The reversal is not a customer posting, so it is not
+// held to the account's limits or risk rules and incurs no overdraft fee,
+// but it may not take the available balance past the overdraft limit.
+func (s *bankingService) ReverseTransaction(original *model.Transaction, actor string, reason string) (*model.Transaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// the account lock also keeps two reversals of the same transaction
+	// from both being posted
+	account, err := lockAccount(tx, original.Account)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := recordedTransaction(tx, original.ID, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	reversal, err := model.NewReversal(recorded)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status == model.AccountClosed {
+		return nil, fmt.Errorf("account %s is closed and cannot be posted to", account.ID)
+	}
+
+	var balance money.Money
+	if model.IsDebitType(reversal.Type) {
+		balance, err = account.Balance.Sub(reversal.Amount)
+	} else {
+		balance, err = account.Balance.Add(reversal.Amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	available, err := balance.Sub(heldBalance(account))
+	if err != nil {
+		return nil, err
+	}
+	if cmp, err := available.Cmp(account.OverdraftLimit.Neg()); err != nil || cmp < 0 {
+		return nil, fmt.Errorf("insufficient funds to reverse transaction %s", original.ID)
+	}
+	if err = recordTransaction(tx, reversal); err != nil {
+		return nil, err
+	}
+	if err = recordAudit(tx, model.NewTransactionReversedEvent(original.ID, reversal.ID, actor, reason)); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return reversal, nil
+}
+
+// recordedTransaction reads a transaction on an account from this service's
+// own record, with the reversal that undid it, if any
+func recordedTransaction(tx *sql.Tx, transactionID string, accountID string) (*model.Transaction, error) {
+	var t model.Transaction
+	err := tx.QueryRow(`
+		SELECT t.id, t.account, t.amount, t.currency, t.type, COALESCE(t.transfer_id::text, ''), t.timestamp,
+			COALESCE((SELECT r.id::text FROM transactions r WHERE r.reversal_of = t.id), '')
+		FROM transactions t
+		WHERE t.id = $1 AND t.account = $2`, transactionID, accountID).
+		Scan(&t.ID, &t.Account, &t.Amount, &t.Amount.Currency, &t.Type, &t.TransferID, &t.Timestamp, &t.ReversedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("transaction %s not found", transactionID)
+		}
+		return nil, fmt.Errorf("failed to query transaction: %v", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+	"github.com/banking-app/riskengine"
+)
+
+// riskHistoryLimit bounds how many of an account's recent transactions the
+// risk rules are given
+const riskHistoryLimit = 500
+
+const riskReviewColumns = `id, account_id, operation, amount, currency, rule, COALESCE(reason, ''), status, requested_by,
+	COALESCE(transaction_id::text, ''), COALESCE(decided_by, ''), COALESCE(decision_reason, ''), decided_at, created_at`
+
+func scanRiskReview(row rowScanner) (*model.RiskReview, error) {
+	var r model.RiskReview
+	err := row.Scan(&r.ID, &r.AccountID, &r.Operation, &r.Amount, &r.Amount.Currency, &r.Rule, &r.Reason, &r.Status,
+		&r.RequestedBy, &r.TransactionID, &r.DecidedBy, &r.DecisionReason, &r.DecidedAt, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// assessRisk runs the risk rules over a deposit or withdrawal of amount on a
+// locked account, judging it against the account's recent transactions
+func (s *bankingService) assessRisk(tx *sql.Tx, account *model.Account, amount money.Money, debit bool) (risk.Decision, error) {
+	if !s.risk.Enabled() {
+		return risk.Decision{Outcome: risk.Allow}, nil
+	}
+
+	now := time.Now()
+	activity := riskengine.Activity{
+		Account: account.ID,
+		Entry:   riskengine.Entry{Amount: amount, Debit: debit, At: now},
+	}
+
+	rows, err := tx.Query(`
+		SELECT amount, type, timestamp
+		FROM transactions
+		WHERE account = $1 AND timestamp >= $2
+		ORDER BY timestamp DESC
+		LIMIT $3`, account.ID, now.Add(-s.risk.Lookback()), riskHistoryLimit)
+	if err != nil {
+		return risk.Decision{}, fmt.Errorf("failed to query account history: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry riskengine.Entry
+		var transactionType string
+		if err = rows.Scan(&entry.Amount, &transactionType, &entry.At); err != nil {
+			return risk.Decision{}, fmt.Errorf("failed to scan account history: %v", err)
+		}
+		entry.Amount.Currency = account.Balance.Currency
+		entry.Debit = model.IsDebitType(transactionType)
+		activity.History = append(activity.History, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return risk.Decision{}, fmt.Errorf("failed to query account history: %v", err)
+	}
+
+	var lastActivity, reactivatedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT (SELECT MAX(timestamp) FROM transactions WHERE account = $1),
+			(SELECT MAX(changed_at) FROM account_status_history
+			 WHERE account_id = $1 AND to_status = $2 AND from_status IN ($3, $4))`,
+		account.ID, model.AccountActive, model.AccountInactive, model.AccountFrozen).Scan(&lastActivity, &reactivatedAt)
+	if err != nil {
+		return risk.Decision{}, fmt.Errorf("failed to query account activity: %v", err)
+	}
+	activity.LastActivity = lastActivity.Time
+	activity.ReactivatedAt = reactivatedAt.Time
+	// an inactive account is reactivated by this very posting
+	if account.Status == model.AccountInactive {
+		activity.ReactivatedAt = now
+	}
+
+	return s.risk.Evaluate(activity), nil
+}
+
+// holdForReview records a posting the risk rules did not allow, instead of
+// posting it, and commits the database transaction. It returns the
+// *model.RiskError to hand back to the caller, or the error that stopped
+// the review being recorded.
+func (s *bankingService) holdForReview(tx *sql.Tx, key *model.IdempotencyKey, review *model.RiskReview) error {
+	_, err := tx.Exec(`
+		INSERT INTO risk_reviews (id, account_id, operation, amount, currency, rule, reason, status, requested_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		review.ID, review.AccountID, review.Operation, review.Amount, review.Amount.Currency, review.Rule,
+		nullString(review.Reason), review.Status, review.RequestedBy, review.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record risk review: %v", err)
+	}
+
+	err = recordAudit(tx, model.NewRiskReviewChangedEvent(review.ID, "", review.Status, model.ActorSystem, review.Rule))
+	if err != nil {
+		return err
+	}
+
+	outcome := risk.Review
+	if review.Status == model.ReviewBlocked {
+		outcome = risk.Block
+	}
+	riskErr := &model.RiskError{ReviewID: review.ID, Outcome: outcome}
+	if err = storeIdempotentResponse(tx, key, &idempotentResponse{Review: riskErr}); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return riskErr
+}
+
+// ListRiskReviews lists the reviews in a status, oldest first, or every
+// review, newest first, when status is empty
+func (s *bankingService) ListRiskReviews(status string, limit int) ([]model.RiskReview, error) {
+	query := `SELECT ` + riskReviewColumns + ` FROM risk_reviews WHERE status = $1 ORDER BY created_at LIMIT $2`
+	args := []any{status, limit}
+	if status == "" {
+		query = `SELECT ` + riskReviewColumns + ` FROM risk_reviews ORDER BY created_at DESC LIMIT $1`
+		args = []any{limit}
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk reviews: %v", err)
+	}
+	defer rows.Close()
+
+	reviews := []model.RiskReview{}
+	for rows.Next() {
+		review, err := scanRiskReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan risk review: %v", err)
+		}
+		reviews = append(reviews, *review)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query risk reviews: %v", err)
+	}
+	return reviews, nil
+}
+
+// ApproveRiskReview posts a held deposit or withdrawal as if it were made
+// now. The account's status, limits and balance are checked again, and the
+// user who made it must still hold a role permitting it, but the risk rules
+// are not run again.
+func (s *bankingService) ApproveRiskReview(reviewID string, actor string, reason string) (*model.Transaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	review, err := lockRiskReview(tx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if err = model.CheckReviewDecision(review.Status, model.ReviewApproved); err != nil {
+		return nil, err
+	}
+
+	var role string
+	err = tx.QueryRow("SELECT role FROM account_owners WHERE account_id = $1 AND user_email = $2",
+		review.AccountID, review.RequestedBy).Scan(&role)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to query account role: %v", err)
+	}
+	if !model.RolePermits(role, review.Operation) {
+		return nil, fmt.Errorf("%s may no longer %s on this account", review.RequestedBy, review.Operation)
+	}
+
+	account, err := lockAccount(tx, review.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	debit := review.Operation == model.OpWithdraw
+	if err = postingError(account, debit); err != nil {
+		return nil, err
+	}
+	amount, err := inAccountCurrency(account, review.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLimits(tx, account, amount, debit); err != nil {
+		return nil, err
+	}
+
+	var transaction *model.Transaction
+	if debit {
+		transaction, err = s.postWithdrawal(tx, account, amount)
+	} else {
+		transaction, err = postDeposit(tx, account, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	review.TransactionID = transaction.ID
+	if err = decideRiskReview(tx, review, model.ReviewApproved, actor, reason); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+// RejectRiskReview rejects a held deposit or withdrawal, which is then never
+// posted
+func (s *bankingService) RejectRiskReview(reviewID string, actor string, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	review, err := lockRiskReview(tx, reviewID)
+	if err != nil {
+		return err
+	}
+	if err = model.CheckReviewDecision(review.Status, model.ReviewRejected); err != nil {
+		return err
+	}
+	if err = decideRiskReview(tx, review, model.ReviewRejected, actor, reason); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func lockRiskReview(tx *sql.Tx, reviewID string) (*model.RiskReview, error) {
+	review, err := scanRiskReview(tx.QueryRow(`
+		SELECT `+riskReviewColumns+`
+		FROM risk_reviews
+		WHERE id = $1
+		FOR UPDATE`, reviewID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("risk review %s not found", reviewID)
+		}
+		return nil, fmt.Errorf("failed to query risk review: %v", err)
+	}
+	return review, nil
+}
+
+// decideRiskReview writes an admin's decision on a review and its audit
+// event
+func decideRiskReview(tx *sql.Tx, review *model.RiskReview, status string, actor string, reason string) error {
+	_, err := tx.Exec(`
+		UPDATE risk_reviews
+		SET status = $1, transaction_id = $2, decided_by = $3, decision_reason = $4, decided_at = CURRENT_TIMESTAMP
+		WHERE id = $5`,
+		status, nullString(review.TransactionID), actor, nullString(reason), review.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update risk review: %v", err)
+	}
+	return recordAudit(tx, model.NewRiskReviewChangedEvent(review.ID, review.Status, status, actor, reason))
+}
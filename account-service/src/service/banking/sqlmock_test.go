@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newSQLMockService returns a banking service on a mock database, which
+// fails the test if an expected statement was not run
+func newSQLMockService(t *testing.T) (*bankingService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return &bankingService{db: db}, mock
+}
+
+// expectLockAccount expects an account to be locked and returns it as given
+func expectLockAccount(mock sqlmock.Sqlmock, account *model.Account) {
+	mock.ExpectQuery(`SELECT id, account_type, balance, currency, available_balance, overdraft_limit, status\s+FROM accounts\s+WHERE id = \$1\s+FOR UPDATE`).
+		WithArgs(account.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_type", "balance", "currency", "available_balance", "overdraft_limit", "status"}).
+			AddRow(account.ID, account.Type, account.Balance.Decimal(), account.Balance.Currency,
+				account.AvailableBalance.Decimal(), account.OverdraftLimit.Decimal(), account.Status))
+}
+
+// expectNoLimits expects an account to be found to have no limits of its own
+func expectNoLimits(mock sqlmock.Sqlmock, accountID string) {
+	mock.ExpectQuery(`SELECT daily_withdrawal, .* FROM account_limits`).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"daily_withdrawal"}))
+}
+
+// expectRecordTransaction expects a transaction to be numbered seq on an
+// account holding balance, stored, posted against its contra ledger, which
+// moves the account's balance by delta, and queued in the outbox
+func expectRecordTransaction(mock sqlmock.Sqlmock, accountID string, seq int64, balance money.Money, delta money.Money) {
+	mock.ExpectQuery(`UPDATE accounts SET transaction_seq = transaction_seq \+ 1 WHERE id = \$1 RETURNING transaction_seq, balance, currency`).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_seq", "balance", "currency"}).AddRow(seq, balance.Decimal(), balance.Currency))
+	mock.ExpectExec(`INSERT INTO transactions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO journal_entries`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO postings`).
+		WithArgs(sqlmock.AnyArg(), model.LedgerCustomer, accountID, delta.Decimal(), delta.Currency).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts\s+SET balance = balance \+ \$1, available_balance = available_balance \+ \$1`).
+		WithArgs(delta.Decimal(), accountID, delta.Currency).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO postings`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, 1))
+}
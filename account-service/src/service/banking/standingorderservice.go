@@ -0,0 +1,343 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+const defaultOrderRetryInterval = time.Hour
+
+// errStopOrder marks failures that no retry can fix, which cancel the order
+var errStopOrder = errors.New("standing order stopped")
+
+const standingOrderColumns = `id, from_account, to_account, amount, currency, frequency, 
+	start_date, end_date, COALESCE(max_occurrences, 0), occurrences, next_run_date, status, 
+	failed_attempts, COALESCE(last_error, ''), COALESCE(reference, ''), created_by, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanStandingOrder(row rowScanner) (*model.StandingOrder, error) {
+	var o model.StandingOrder
+	err := row.Scan(&o.ID, &o.FromAccount, &o.ToAccount, &o.Amount, &o.Amount.Currency, &o.Frequency,
+		&o.StartDate, &o.EndDate, &o.MaxOccurrences, &o.Occurrences, &o.NextRunDate, &o.Status,
+		&o.FailedAttempts, &o.LastError, &o.Reference, &o.CreatedBy, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// CreateStandingOrder stores a new standing order. An amount without a
+// currency is taken to be in the source account's currency.
+func (s *bankingService) CreateStandingOrder(order *model.StandingOrder) error {
+	from, err := s.GetAccountbyId(order.FromAccount)
+	if err != nil {
+		return err
+	}
+	to, err := s.GetAccountbyId(order.ToAccount)
+	if err != nil {
+		return fmt.Errorf("destination account not found")
+	}
+	if from.Status == model.AccountClosed || to.Status == model.AccountClosed {
+		return fmt.Errorf("standing orders cannot use closed accounts")
+	}
+	order.Amount, err = inAccountCurrency(from, order.Amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO standing_orders (
+			id, from_account, to_account, amount, currency, frequency, 
+			start_date, end_date, max_occurrences, next_run_date, status, 
+			reference, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		order.ID, order.FromAccount, order.ToAccount, order.Amount, order.Amount.Currency, order.Frequency,
+		order.StartDate, order.EndDate, nullInt(order.MaxOccurrences), order.NextRunDate, order.Status,
+		nullString(order.Reference), order.CreatedBy, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert standing order: %v", err)
+	}
+	return nil
+}
+
+// GetStandingOrder gets a standing order paying out of an account
+func (s *bankingService) GetStandingOrder(accountID string, orderID string) (*model.StandingOrder, error) {
+	order, err := scanStandingOrder(s.db.QueryRow(`
+		SELECT `+standingOrderColumns+` 
+		FROM standing_orders 
+		WHERE id = $1 AND from_account = $2`, orderID, accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("standing order not found")
+		}
+		return nil, fmt.Errorf("failed to query standing order: %v", err)
+	}
+	return order, nil
+}
+
+// ListStandingOrders lists the standing orders paying out of an account,
+// newest first
+func (s *bankingService) ListStandingOrders(accountID string) ([]model.StandingOrder, error) {
+	rows, err := s.db.Query(`
+		SELECT `+standingOrderColumns+` 
+		FROM standing_orders 
+		WHERE from_account = $1 
+		ORDER BY created_at DESC`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query standing orders: %v", err)
+	}
+	defer rows.Close()
+
+	orders := []model.StandingOrder{}
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standing order: %v", err)
+		}
+		orders = append(orders, *order)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating standing orders: %v", err)
+	}
+	return orders, nil
+}
+
+// UpdateStandingOrder saves an active order's amount, limits and reference
+func (s *bankingService) UpdateStandingOrder(order *model.StandingOrder) error {
+	res, err := s.db.Exec(`
+		UPDATE standing_orders 
+		SET amount = $1, end_date = $2, max_occurrences = $3, reference = $4, 
+			status = $5, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = $6 AND status = $7`,
+		order.Amount, order.EndDate, nullInt(order.MaxOccurrences), nullString(order.Reference),
+		order.Status, order.ID, model.OrderActive)
+	if err != nil {
+		return fmt.Errorf("failed to update standing order: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("standing order is no longer active")
+	}
+	return nil
+}
+
+// CancelStandingOrder stops an active standing order. Cancelled orders are
+// kept, so they can still be looked up.
+func (s *bankingService) CancelStandingOrder(accountID string, orderID string) error {
+	res, err := s.db.Exec(`
+		UPDATE standing_orders 
+		SET status = $1, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = $2 AND from_account = $3 AND status = $4`,
+		model.OrderCancelled, orderID, accountID, model.OrderActive)
+	if err != nil {
+		return fmt.Errorf("failed to cancel standing order: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("standing order not found or no longer active")
+	}
+	return nil
+}
+
+// ExecuteStandingOrders pays up to limit standing orders that are due by
+// now, oldest first, and returns how many it paid. An order several
+// occurrences behind pays one per call.
+func (s *bankingService) ExecuteStandingOrders(now time.Time, limit int) (int, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM standing_orders 
+		WHERE status = $1 AND next_run_date <= $2 AND (retry_at IS NULL OR retry_at <= $3) 
+		ORDER BY next_run_date 
+		LIMIT $4`, model.OrderActive, model.StartOfDay(now), now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query due standing orders: %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan standing order: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating standing orders: %v", err)
+	}
+
+	paid := 0
+	for _, id := range ids {
+		ok, err := s.executeStandingOrder(id, now)
+		if err != nil {
+			return paid, err
+		}
+		if ok {
+			paid++
+		}
+	}
+	return paid, nil
+}
+
+// executeStandingOrder pays one due occurrence of an order, using the same
+// locking and posting as a transfer, and moves the order on in the same
+// database transaction. It reports false if the order was not paid.
+func (s *bankingService) executeStandingOrder(id string, now time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// another replica may be paying the order already
+	order, err := scanStandingOrder(tx.QueryRow(`
+		SELECT `+standingOrderColumns+` 
+		FROM standing_orders 
+		WHERE id = $1 AND status = $2 AND next_run_date <= $3 AND (retry_at IS NULL OR retry_at <= $4) 
+		FOR UPDATE SKIP LOCKED`, id, model.OrderActive, model.StartOfDay(now), now))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim standing order: %v", err)
+	}
+
+	if payErr := s.payStandingOrder(tx, order); payErr != nil {
+		tx.Rollback()
+		log.Printf("Standing order %s failed: %v", order.ID, payErr)
+		return false, s.failStandingOrder(order, payErr, now)
+	}
+
+	order.Advance()
+	order.LastError = ""
+	if err = saveOrderSchedule(tx, order, nil); err != nil {
+		return false, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// payStandingOrder transfers one occurrence of an order. The user who set the
+// order up must still be allowed to transfer from the account.
+func (s *bankingService) payStandingOrder(tx *sql.Tx, order *model.StandingOrder) error {
+	var role string
+	err := tx.QueryRow("SELECT role FROM account_owners WHERE account_id = $1 AND user_email = $2",
+		order.FromAccount, order.CreatedBy).Scan(&role)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to query account role: %v", err)
+	}
+	if !model.RolePermits(role, model.OpTransfer) {
+		return fmt.Errorf("%w: %s can no longer transfer from this account", errStopOrder, order.CreatedBy)
+	}
+
+	from, to, err := lockAccounts(tx, order.FromAccount, order.ToAccount)
+	if err != nil {
+		return err
+	}
+	if from.Status == model.AccountClosed || to.Status == model.AccountClosed {
+		return fmt.Errorf("%w: an account has been closed", errStopOrder)
+	}
+	if err = postingError(from, true); err != nil {
+		return err
+	}
+	if err = postingError(to, false); err != nil {
+		return err
+	}
+	if err = s.checkTransferLimits(tx, from, order.Amount); err != nil {
+		return err
+	}
+	if err = reactivate(tx, from); err != nil {
+		return err
+	}
+
+	_, _, err = s.moveFunds(tx, from, to, order.Amount, "debit", order.Reference)
+	return err
+}
+
+// failStandingOrder records a failed payment of the occurrence claimed. The
+// occurrence is retried after the retry interval, up to the retry limit;
+// after that it is missed, and a one-off order fails. Failures no retry can
+// fix cancel the order. The claim was released when the payment rolled
+// back, so nothing is recorded if the order has moved on since: another
+// replica may have paid the occurrence, or recorded its failure, meanwhile.
+func (s *bankingService) failStandingOrder(claimed *model.StandingOrder, payErr error, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	order, err := scanStandingOrder(tx.QueryRow(`
+		SELECT `+standingOrderColumns+` 
+		FROM standing_orders 
+		WHERE id = $1 AND status = $2 AND next_run_date = $3 AND failed_attempts = $4 
+			AND (retry_at IS NULL OR retry_at <= $5) 
+		FOR UPDATE`, claimed.ID, model.OrderActive, claimed.NextRunDate, claimed.FailedAttempts, now))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to query standing order: %v", err)
+	}
+
+	order.LastError = payErr.Error()
+	if len(order.LastError) > 255 {
+		order.LastError = order.LastError[:255]
+	}
+	var retryAt *time.Time
+	switch {
+	case errors.Is(payErr, errStopOrder):
+		order.Status = model.OrderCancelled
+	case order.FailedAttempts < s.orderMaxRetries:
+		order.FailedAttempts++
+		next := now.Add(s.orderRetryInterval)
+		retryAt = &next
+	case order.Frequency == model.FrequencyOnce:
+		order.Status = model.OrderFailed
+	default:
+		order.Advance()
+	}
+
+	if err = saveOrderSchedule(tx, order, retryAt); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func saveOrderSchedule(tx *sql.Tx, order *model.StandingOrder, retryAt *time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE standing_orders 
+		SET occurrences = $1, next_run_date = $2, status = $3, failed_attempts = $4, 
+			retry_at = $5, last_error = $6, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = $7`,
+		order.Occurrences, order.NextRunDate, order.Status, order.FailedAttempts,
+		retryAt, nullString(order.LastError), order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update standing order: %v", err)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n > 0}
+}
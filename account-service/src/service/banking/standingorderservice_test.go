@@ -0,0 +1,66 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectClaimOrder expects a due order to be claimed and returns it as given
+func expectClaimOrder(mock sqlmock.Sqlmock, order *model.StandingOrder) {
+	mock.ExpectQuery(`FROM standing_orders\s+WHERE id = \$1 AND status = \$2 AND next_run_date <= \$3 AND \(retry_at IS NULL OR retry_at <= \$4\)\s+FOR UPDATE SKIP LOCKED`).
+		WithArgs(order.ID, model.OrderActive, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(standingOrderRows(order))
+}
+
+func standingOrderRows(order *model.StandingOrder) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "from_account", "to_account", "amount", "currency", "frequency",
+		"start_date", "end_date", "max_occurrences", "occurrences", "next_run_date", "status",
+		"failed_attempts", "last_error", "reference", "created_by", "created_at", "updated_at"}).
+		AddRow(order.ID, order.FromAccount, order.ToAccount, order.Amount.Decimal(), order.Amount.Currency, order.Frequency,
+			order.StartDate, nil, 0, order.Occurrences, order.NextRunDate, order.Status,
+			order.FailedAttempts, "", "", order.CreatedBy, order.CreatedAt, order.UpdatedAt)
+}
+
+func TestFailedStandingOrderLeavesAnOrderThatMovedOn(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	order := &model.StandingOrder{ID: "order", FromAccount: "from", ToAccount: "to", Frequency: "monthly",
+		StartDate: now, NextRunDate: model.StartOfDay(now), Status: model.OrderActive, FailedAttempts: 1,
+		CreatedBy: "johndoe@example.com", CreatedAt: now, UpdatedAt: now}
+	order.Amount.Currency = "USD"
+
+	for _, movedOn := range []bool{false, true} {
+		service, mock := newSQLMockService(t)
+
+		// the payment fails, as its creator may no longer transfer
+		mock.ExpectBegin()
+		expectClaimOrder(mock, order)
+		mock.ExpectQuery(`SELECT role FROM account_owners`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		// the failure is only recorded against the occurrence claimed
+		mock.ExpectBegin()
+		claim := mock.ExpectQuery(`FROM standing_orders\s+WHERE id = \$1 AND status = \$2 AND next_run_date = \$3 AND failed_attempts = \$4\s+AND \(retry_at IS NULL OR retry_at <= \$5\)\s+FOR UPDATE`).
+			WithArgs(order.ID, model.OrderActive, order.NextRunDate, order.FailedAttempts, now)
+		if movedOn {
+			claim.WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
+		} else {
+			claim.WillReturnRows(standingOrderRows(order))
+			mock.ExpectExec(`UPDATE standing_orders`).
+				WithArgs(order.Occurrences, order.NextRunDate, model.OrderCancelled, order.FailedAttempts, nil, sqlmock.AnyArg(), order.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		}
+
+		paid, err := service.executeStandingOrder(order.ID, now)
+		if paid || err != nil {
+			t.Errorf("Expected the order not to be paid and no error, but got %v, %v", paid, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+// GetStatement reads the statement stored for an account and month
+func (s *bankingService) GetStatement(accountID string, month string) (*model.StoredStatement, error) {
+	var stored model.StoredStatement
+	var statement []byte
+	err := s.db.QueryRow("SELECT statement, csv, pdf FROM statements WHERE account_id = $1 AND month = $2",
+		accountID, month).Scan(&statement, &stored.CSV, &stored.PDF)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrStatementNotFound
+		}
+		return nil, fmt.Errorf("failed to query statement: %v", err)
+	}
+	if err = json.Unmarshal(statement, &stored.Statement); err != nil {
+		return nil, fmt.Errorf("failed to decode statement: %v", err)
+	}
+	return &stored, nil
+}
+
+// SaveStatement stores a statement unless one is already stored for its
+// account and month, and returns the one that is
+func (s *bankingService) SaveStatement(stored *model.StoredStatement) (*model.StoredStatement, error) {
+	statement, err := json.Marshal(stored.Statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %v", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO statements (account_id, month, statement, csv, pdf, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (account_id, month) DO NOTHING`,
+		stored.Statement.AccountID, stored.Statement.Month, statement, stored.CSV, stored.PDF, stored.Statement.GeneratedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save statement: %v", err)
+	}
+	return s.GetStatement(stored.Statement.AccountID, stored.Statement.Month)
+}
+
+// GetStatementBalances reads an account's balance at start and at end from
+// its postings, the adjustments posted to it in between and how many of its
+// transactions up to end are still waiting in the outbox, as of one
+// snapshot
+func (s *bankingService) GetStatementBalances(accountID string, start time.Time, end time.Time) (*model.StatementBalances, error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	balances := &model.StatementBalances{Adjustments: []model.LedgerAdjustment{}}
+	var currency string
+	err = tx.QueryRow("SELECT currency FROM accounts WHERE id = $1", accountID).Scan(&currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("account %s not found", accountID)
+		}
+		return nil, fmt.Errorf("failed to query account: %v", err)
+	}
+
+	// a transaction's journal entry is dated with the transaction
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(p.amount) FILTER (WHERE j.created_at < $2), 0),
+			COALESCE(SUM(p.amount) FILTER (WHERE j.created_at < $3), 0)
+		FROM postings p
+		JOIN journal_entries j ON j.id = p.journal_id
+		WHERE p.account_id = $1 AND p.ledger = $4`,
+		accountID, start, end, model.LedgerCustomer).Scan(&balances.Opening, &balances.Closing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balances: %v", err)
+	}
+	balances.Opening.Currency = currency
+	balances.Closing.Currency = currency
+
+	rows, err := tx.Query(`
+		SELECT j.id, p.amount, j.created_at
+		FROM postings p
+		JOIN journal_entries j ON j.id = p.journal_id
+		WHERE p.account_id = $1 AND p.ledger = $2 AND j.transaction_id IS NULL
+			AND j.created_at >= $3 AND j.created_at < $4
+		ORDER BY j.created_at`,
+		accountID, model.LedgerCustomer, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query adjustments: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a model.LedgerAdjustment
+		if err = rows.Scan(&a.ID, &a.Amount, &a.At); err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment: %v", err)
+		}
+		a.Amount.Currency = currency
+		balances.Adjustments = append(balances.Adjustments, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating adjustments: %v", err)
+	}
+
+	// an outbox event is dated with its transaction
+	err = tx.QueryRow("SELECT COUNT(*) FROM outbox WHERE account = $1 AND kind = $2 AND sent_at IS NULL AND created_at < $3",
+		accountID, model.EventTransaction, end).Scan(&balances.Unsent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %v", err)
+	}
+	return balances, nil
+}
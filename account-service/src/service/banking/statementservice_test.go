@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetStatementBalances(t *testing.T) {
+
+	service, mock := newSQLMockService(t)
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency FROM accounts WHERE id = \$1`).
+		WithArgs("account").
+		WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+	mock.ExpectQuery(`FILTER \(WHERE j.created_at < \$2\).*FILTER \(WHERE j.created_at < \$3\).*FROM postings p`).
+		WithArgs("account", start, end, model.LedgerCustomer).
+		WillReturnRows(sqlmock.NewRows([]string{"opening", "closing"}).AddRow("80.00", "95.00"))
+	mock.ExpectQuery(`FROM postings p .* AND j.transaction_id IS NULL`).
+		WithArgs("account", model.LedgerCustomer, start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "created_at"}).AddRow("adjustment", "-5.00", start.Add(time.Hour)))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM outbox WHERE account = \$1 AND kind = \$2 AND sent_at IS NULL AND created_at < \$3`).
+		WithArgs("account", model.EventTransaction, end).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	balances, err := service.GetStatementBalances("account", start, end)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if balances.Opening != money.New(8000, "USD") || balances.Closing != money.New(9500, "USD") {
+		t.Errorf("Expected balances of 80.00 and 95.00, but got %s and %s", balances.Opening, balances.Closing)
+	}
+	if len(balances.Adjustments) != 1 || balances.Adjustments[0].Amount != money.New(-500, "USD") || balances.Unsent != 2 {
+		t.Errorf("Expected an adjustment of -5.00 and 2 unsent events, but got %+v", balances)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+// ChangeAccountStatus moves an account to a new status if the lifecycle
+// allows it, recording the change in the account's history and queueing an
+// audit event. Accounts are closed with CloseAccount, which settles their
+// balance.
+func (s *bankingService) ChangeAccountStatus(accountID string, status string, actor string, reason string) error {
+	if status == model.AccountClosed {
+		return fmt.Errorf("accounts must be closed through the closure workflow")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = model.CheckAccountTransition(account.Status, status); err != nil {
+		return err
+	}
+
+	if err = setAccountStatus(tx, accountID, account.Status, status, actor, reason); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetAccountStatusHistory lists an account's status changes, oldest first
+func (s *bankingService) GetAccountStatusHistory(accountID string) ([]model.AccountStatusChange, error) {
+	rows, err := s.db.Query(`
+		SELECT id, account_id, from_status, to_status, COALESCE(reason, ''), actor, changed_at 
+		FROM account_status_history 
+		WHERE account_id = $1 
+		ORDER BY id`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %v", err)
+	}
+	defer rows.Close()
+
+	history := []model.AccountStatusChange{}
+	for rows.Next() {
+		var c model.AccountStatusChange
+		err = rows.Scan(&c.ID, &c.AccountID, &c.From, &c.To, &c.Reason, &c.Actor, &c.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %v", err)
+		}
+		history = append(history, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %v", err)
+	}
+	return history, nil
+}
+
+// MarkInactiveAccounts moves active accounts with no transactions since
+// idleSince to inactive, and returns how many it moved. Accounts locked by a
+// posting in progress are skipped until the next run.
+func (s *bankingService) MarkInactiveAccounts(idleSince time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT a.id 
+		FROM accounts a 
+		WHERE a.status = $1 
+			AND a.created_at < $2 
+			AND NOT EXISTS (
+				SELECT 1 FROM transactions t WHERE t.account = a.id AND t.timestamp >= $2
+			) 
+		FOR UPDATE SKIP LOCKED`, model.AccountActive, idleSince)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query idle accounts: %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan account: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating idle accounts: %v", err)
+	}
+
+	for _, id := range ids {
+		err = setAccountStatus(tx, id, model.AccountActive, model.AccountInactive, model.ActorSystem, model.ReasonDormant)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return len(ids), nil
+}
+
+// reactivate moves an inactive account back to active when its owners use
+// it again. The account must be locked by the surrounding transaction.
+func reactivate(tx *sql.Tx, account *model.Account) error {
+	if account.Status != model.AccountInactive {
+		return nil
+	}
+	err := setAccountStatus(tx, account.ID, account.Status, model.AccountActive, model.ActorSystem, model.ReasonActivity)
+	if err != nil {
+		return err
+	}
+	account.Status = model.AccountActive
+	return nil
+}
+
+// setAccountStatus writes a status change, its history entry and its audit
+// event. Callers check the transition first.
+func setAccountStatus(tx *sql.Tx, accountID string, from string, to string, actor string, reason string) error {
+	_, err := tx.Exec("UPDATE accounts SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", to, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update account status: %v", err)
+	}
+
+	var nullReason sql.NullString
+	if reason != "" {
+		nullReason = sql.NullString{String: reason, Valid: true}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO account_status_history (account_id, from_status, to_status, reason, actor) 
+		VALUES ($1, $2, $3, $4, $5)`,
+		accountID, from, to, nullReason, actor)
+	if err != nil {
+		return fmt.Errorf("failed to record status change: %v", err)
+	}
+
+	return recordAudit(tx, model.NewAccountStatusChangedEvent(accountID, from, to, actor, reason))
+}
+
+// postingError returns why money cannot leave, or be paid into, an account
+// with the given status, if it cannot
+func postingError(account *model.Account, debit bool) error {
+	if debit && !model.CanDebit(account.Status) {
+		return fmt.Errorf("account %s is %s and cannot be debited", account.ID, account.Status)
+	}
+	if !debit && !model.CanCredit(account.Status) {
+		return fmt.Errorf("account %s is %s and cannot be credited", account.ID, account.Status)
+	}
+	return nil
+}
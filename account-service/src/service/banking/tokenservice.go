@@ -0,0 +1,47 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/banking-app/account-service/src/model"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrUserLocked         = errors.New("user is locked")
+)
+
+// Refresh token methods
+func (s *bankingService) SaveRefreshToken(token *model.RefreshToken) error {
+	_, err := s.db.Exec(`
+		INSERT INTO refresh_tokens (token_hash, email, expires_at, created_at) 
+		VALUES ($1, $2, $3, $4)`,
+		token.Hash, token.Email, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %v", err)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes a refresh token and returns it, provided it was
+// still valid. The check and the revocation are a single statement, so a token
+// can only ever be used once, even by concurrent requests.
+func (s *bankingService) RevokeRefreshToken(hash string) (*model.RefreshToken, error) {
+	token := model.RefreshToken{Hash: hash}
+	err := s.db.QueryRow(`
+		UPDATE refresh_tokens 
+		SET revoked_at = CURRENT_TIMESTAMP 
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP 
+		RETURNING email, expires_at, created_at`, hash).Scan(&token.Email, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to revoke refresh token: %v", err)
+	}
+	return &token, nil
+}
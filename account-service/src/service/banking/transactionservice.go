@@ -1,125 +1,178 @@
 package service
 
 import (
-
-	//import config from src/config/config.go
-
-	"context"
 	"database/sql"
 	"fmt"
 
 	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
 
 	"github.com/lib/pq"
 )
 
-func (s *bankingService) CreateTransaction(transaction *model.Transaction) error {
-	tx, err := s.db.Begin()
+// recordTransaction posts a transaction to the ledger, which moves its
+// account's balance, stores it and queues it in the outbox, so that the
+// event is written if and only if the balance change is committed
+func recordTransaction(tx *sql.Tx, transaction *model.Transaction) error {
+	var transferID sql.NullString
+	if transaction.TransferID != "" {
+		transferID = sql.NullString{String: transaction.TransferID, Valid: true}
+	}
+	var fxRate sql.NullString
+	if transaction.FxRate != "" {
+		fxRate = sql.NullString{String: transaction.FxRate, Valid: true}
+	}
+
+	// the next number on the account, taken under its row lock, and the
+	// balance this transaction is posted to
+	var seq int64
+	var balance money.Money
+	err := tx.QueryRow("UPDATE accounts SET transaction_seq = transaction_seq + 1 WHERE id = $1 RETURNING transaction_seq, balance, currency",
+		transaction.Account).Scan(&seq, &balance, &balance.Currency)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to number transaction: %v", err)
 	}
-	defer tx.Rollback()
-	res, err := tx.Exec("INSERT INTO transactions (id, account, amount, type, timestamp) VALUES ($1, $2, $3, $4, $5)", transaction.ID, transaction.Account, transaction.Amount, transaction.Type, transaction.Timestamp)
+	if err = transaction.Sequence(seq, balance); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO transactions (id, account, amount, currency, type, transfer_id, fx_rate, reversal_of, reference, seq, balance_after, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", transaction.ID, transaction.Account, transaction.Amount, transaction.Amount.Currency, transaction.Type, transferID, fxRate, nullString(transaction.ReversalOf), nullString(transaction.Reference), transaction.Seq, transaction.BalanceAfter, transaction.Timestamp)
 	if err != nil {
 		return fmt.Errorf("failed to insert transaction: %v", err)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	journal, err := transactionJournal(tx, transaction)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return err
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("transaction not created")
+	if err = postJournal(tx, journal); err != nil {
+		return err
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+	event, err := model.NewOutboxEvent(transaction)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %v", err)
 	}
-	return nil
+	return queueEvent(tx, event)
 }
 
-func (s *bankingService) GetTransactions() ([]model.Transaction, error) {
-	rows, err := s.db.Query("SELECT id, account, amount, type, timestamp FROM transactions")
+// recordAudit queues an audit event in the database transaction that makes
+// the change it describes
+func recordAudit(tx *sql.Tx, audit *model.AuditEvent) error {
+	event, err := model.NewAuditOutboxEvent(audit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %v", err)
+		return fmt.Errorf("failed to encode audit event: %v", err)
 	}
-	defer rows.Close()
+	return queueEvent(tx, event)
+}
 
-	var transactions []model.Transaction
-	for rows.Next() {
-		var t model.Transaction
-		err := rows.Scan(&t.ID, &t.Account, &t.Amount, &t.Type, &t.Timestamp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %v", err)
-		}
-		transactions = append(transactions, t)
+func queueEvent(tx *sql.Tx, event *model.OutboxEvent) error {
+	var account sql.NullString
+	if event.Account != "" {
+		account = sql.NullString{String: event.Account, Valid: true}
 	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transactions: %v", err)
+	_, err := tx.Exec("INSERT INTO outbox (id, kind, event_key, account, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		event.ID, event.Kind, event.Key, account, event.Payload, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to queue %s event: %v", event.Kind, err)
 	}
-	return transactions, nil
+	return nil
 }
 
-func (s *bankingService) DeleteTransactionsByIds(ids []string) error {
+// outboxLockClass namespaces the per-key advisory locks taken by the relay
+const outboxLockClass = 0x6f7574
+
+// RelayOutbox claims up to limit unsent events, oldest first, hands them to
+// publish and marks them sent, all in one database transaction. Rows are
+// claimed with FOR UPDATE SKIP LOCKED so that several replicas can relay
+// concurrently, and a key is only claimed by one replica at a time so that
+// its events cannot be published out of order. If publish fails nothing
+// is marked and the batch is retried on the next poll. It returns the number
+// of events relayed.
+func (s *bankingService) RelayOutbox(limit int, publish func([]model.OutboxEvent) error) (int, error) {
 	tx, err := s.db.Begin()
-
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
 	}
 	defer tx.Rollback()
 
-	// Execute delete within transaction
-	res, err := tx.Exec(`
-		DELETE FROM transactions 
-		WHERE id IN ($1)`,
-		pq.Array(ids))
-
+	// The batch is claimed first, and only then are the events kept from it
+	// whose earlier unsent events under the same key are all in it too, so
+	// that an event is never sent ahead of one another replica has claimed.
+	// The advisory lock is taken on the events kept and held until the
+	// transaction ends, so that no other replica sends their key's later
+	// events meanwhile. Events not kept stay claimed until then.
+	rows, err := tx.Query(`
+		WITH batch AS MATERIALIZED (
+			SELECT id, seq, COALESCE(event_key, account::text, id::text) AS lock_key
+			FROM outbox
+			WHERE sent_at IS NULL
+			ORDER BY seq
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), kept AS MATERIALIZED (
+			SELECT b.id, b.seq, b.lock_key
+			FROM batch b
+			WHERE NOT EXISTS (
+				SELECT 1 FROM outbox e
+				WHERE e.sent_at IS NULL
+					AND COALESCE(e.event_key, e.account::text, e.id::text) = b.lock_key
+					AND e.seq < b.seq
+					AND e.id NOT IN (SELECT id FROM batch))
+		)
+		SELECT o.id, o.seq, o.kind, COALESCE(o.event_key, o.account::text, ''), o.account, o.payload, o.created_at
+		FROM kept k
+		JOIN outbox o ON o.id = k.id
+		WHERE pg_try_advisory_xact_lock($1, hashtext(k.lock_key))
+		ORDER BY k.seq`, outboxLockClass, limit)
 	if err != nil {
-		return fmt.Errorf("failed to delete transactions: %v", err)
+		return 0, fmt.Errorf("failed to claim outbox events: %v", err)
 	}
-
-	rowsAffected, err := res.RowsAffected()
+	events, err := scanOutboxEvents(rows)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("transactions not deleted")
+		return 0, err
 	}
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+	if len(events) == 0 {
+		return 0, nil
 	}
-	return nil
-}
 
-func (s *bankingService) DeleteTransactionsById(id string) error {
-	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+	if err = publish(events); err != nil {
+		return 0, err
 	}
-	defer tx.Rollback()
-	res, err := tx.Exec(`
-		DELETE FROM transactions 
-		WHERE id = $1`,
-		id)
 
-	if err != nil {
-		return fmt.Errorf("failed to delete transactions: %v", err)
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
 	}
-
-	rowsAffected, err := res.RowsAffected()
+	_, err = tx.Exec("UPDATE outbox SET sent_at = CURRENT_TIMESTAMP WHERE id = ANY($1)", pq.Array(ids))
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("transaction not deleted")
+		return 0, fmt.Errorf("failed to mark outbox events sent: %v", err)
 	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
 	}
-	return nil
+
+	return len(events), nil
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]model.OutboxEvent, error) {
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		var e model.OutboxEvent
+		var account sql.NullString
+		err := rows.Scan(&e.ID, &e.Seq, &e.Kind, &e.Key, &account, &e.Payload, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %v", err)
+		}
+		e.Account = account.String
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox: %v", err)
+	}
+	return events, nil
 }
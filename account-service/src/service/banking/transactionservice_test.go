@@ -1,136 +1,119 @@
 package service
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
 	"github.com/google/uuid"
-	"github.com/stretchr/testify/mock"
-)
-
-type mockTransactionService struct {
-	t *testing.T
-	mock.Mock
-}
-
-func (m *mockTransactionService) CreateTransaction(transaction *model.Transaction) error {
-	args := m.Called(transaction)
-	return args.Error(0)
-}
-
-func (m *mockTransactionService) GetTransactions() ([]model.Transaction, error) {
-	args := m.Called()
-	return args.Get(0).([]model.Transaction), args.Error(1)
-}
-
-func (m *mockTransactionService) DeleteTransactionsByIds(ids []string) error {
-	args := m.Called(ids)
-	return args.Error(0)
-}
-
-func (m *mockTransactionService) DeleteTransactionsById(id string) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
-
-func NewMockTransactionService(t *testing.T) *mockTransactionService {
-	return &mockTransactionService{t: t}
-}
 
-func TestCreateTransaction(t *testing.T) {
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
 
-	mockTransactionService := NewMockTransactionService(t)
+func TestNewOutboxEvent(t *testing.T) {
 
 	transaction := model.Transaction{
 		ID:        uuid.New().String(),
 		Account:   uuid.New().String(),
-		Amount:    100,
+		Amount:    money.New(10000, money.DefaultCurrency),
 		Type:      "credit",
 		Timestamp: time.Now(),
 	}
 
-	// add the transaction to the mock transaction service
-	mockTransactionService.On("CreateTransaction", &transaction).Return(nil)
-
-	// call the CreateTransaction method
-	err := mockTransactionService.CreateTransaction(&transaction)
+	event, err := model.NewOutboxEvent(&transaction)
 
 	if err != nil {
 		t.Errorf("Expected error to be nil, but got %v", err)
 	}
-}
 
-func TestGetTransactions(t *testing.T) {
-
-	mockTransactionService := NewMockTransactionService(t)
-
-	transaction := model.Transaction{
-		ID:        uuid.New().String(),
-		Account:   uuid.New().String(),
-		Amount:    100,
-		Type:      "credit",
-		Timestamp: time.Now(),
+	if event.ID != transaction.ID || event.Account != transaction.Account {
+		t.Errorf("Expected event to be keyed by the transaction, but got %s/%s", event.ID, event.Account)
 	}
+}
 
-	// add the transaction to the mock transaction service
-	mockTransactionService.On("GetTransactions").Return([]model.Transaction{transaction}, nil)
+// expectClaimOutbox expects a batch of up to limit unsent events to be
+// claimed with SKIP LOCKED before any advisory lock is taken, and only on
+// the events kept from it
+func expectClaimOutbox(mock sqlmock.Sqlmock, limit int, events ...*model.OutboxEvent) {
+	rows := sqlmock.NewRows([]string{"id", "seq", "kind", "event_key", "account", "payload", "created_at"})
+	for i, e := range events {
+		rows.AddRow(e.ID, int64(i+1), e.Kind, e.Key, e.Account, e.Payload, e.CreatedAt)
+	}
+	mock.ExpectQuery(`WITH batch AS MATERIALIZED \(\s+`+
+		`SELECT id, seq, COALESCE\(event_key, account::text, id::text\) AS lock_key\s+FROM outbox\s+WHERE sent_at IS NULL\s+ORDER BY seq\s+LIMIT \$2\s+FOR UPDATE SKIP LOCKED\s+`+
+		`\), kept AS MATERIALIZED \(.*\)\s+`+
+		`SELECT o.id, .*\s+FROM kept k\s+JOIN outbox o ON o.id = k.id\s+WHERE pg_try_advisory_xact_lock\(\$1, hashtext\(k.lock_key\)\)\s+ORDER BY k.seq`).
+		WithArgs(outboxLockClass, limit).
+		WillReturnRows(rows)
+}
 
-	// call the GetTransactions method
-	result, err := mockTransactionService.GetTransactions()
+func TestRelayOutbox(t *testing.T) {
 
-	if len(result) != 1 {
-		t.Errorf("Expected result to have 1 element, but got %d", len(result))
+	service, mock := newSQLMockService(t)
+	transaction := model.NewTransaction(uuid.New().String(), money.New(10000, money.DefaultCurrency), "credit")
+	event, err := model.NewOutboxEvent(transaction)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
 	}
 
+	mock.ExpectBegin()
+	expectClaimOutbox(mock, 100, event)
+	mock.ExpectExec(`UPDATE outbox SET sent_at = CURRENT_TIMESTAMP WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{event.ID})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var published []model.OutboxEvent
+	n, err := service.RelayOutbox(100, func(events []model.OutboxEvent) error {
+		published = events
+		return nil
+	})
 	if err != nil {
-		t.Errorf("Expected error to be nil, but got %v", err)
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if n != 1 || len(published) != 1 || published[0].ID != event.ID || published[0].Key != event.Key {
+		t.Errorf("Expected the event to be relayed, but got %d: %+v", n, published)
 	}
 }
 
-func TestDeleteTransactionsByIds(t *testing.T) {
+func TestRelayOutboxPublishFails(t *testing.T) {
 
-	mockTransactionService := NewMockTransactionService(t)
-
-	transaction := model.Transaction{
-		ID:        uuid.New().String(),
-		Account:   uuid.New().String(),
-		Amount:    100,
-		Type:      "credit",
-		Timestamp: time.Now(),
+	service, mock := newSQLMockService(t)
+	transaction := model.NewTransaction(uuid.New().String(), money.New(10000, money.DefaultCurrency), "credit")
+	event, err := model.NewOutboxEvent(transaction)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
 	}
 
-	// add the transaction to the mock transaction service
-	mockTransactionService.On("DeleteTransactionsByIds", []string{transaction.ID}).Return(nil)
-
-	// call the DeleteTransactionsByIds method
-	err := mockTransactionService.DeleteTransactionsByIds([]string{transaction.ID})
+	// nothing is marked sent, so the batch is claimed again on the next poll
+	mock.ExpectBegin()
+	expectClaimOutbox(mock, 100, event)
+	mock.ExpectRollback()
 
-	if err != nil {
-		t.Errorf("Expected error to be nil, but got %v", err)
+	n, err := service.RelayOutbox(100, func(events []model.OutboxEvent) error {
+		return errors.New("kafka unavailable")
+	})
+	if err == nil || n != 0 {
+		t.Errorf("Expected the publish error, but got %d, %v", n, err)
 	}
 }
 
-func TestDeleteTransactionsById(t *testing.T) {
-
-	mockTransactionService := NewMockTransactionService(t)
-
-	transaction := model.Transaction{
-		ID:        uuid.New().String(),
-		Account:   uuid.New().String(),
-		Amount:    100,
-		Type:      "credit",
-		Timestamp: time.Now(),
-	}
+func TestRelayOutboxNothingToSend(t *testing.T) {
 
-	// add the transaction to the mock transaction service
-	mockTransactionService.On("DeleteTransactionsById", transaction.ID).Return(nil)
+	service, mock := newSQLMockService(t)
 
-	// call the DeleteTransactionsById method
-	err := mockTransactionService.DeleteTransactionsById(transaction.ID)
+	mock.ExpectBegin()
+	expectClaimOutbox(mock, 100)
+	mock.ExpectRollback()
 
-	if err != nil {
-		t.Errorf("Expected error to be nil, but got %v", err)
+	n, err := service.RelayOutbox(100, func(events []model.OutboxEvent) error {
+		t.Errorf("Expected nothing to be published, but got %d events", len(events))
+		return nil
+	})
+	if err != nil || n != 0 {
+		t.Errorf("Expected nothing to be relayed, but got %d, %v", n, err)
 	}
 }
-
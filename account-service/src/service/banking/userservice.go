@@ -11,7 +11,10 @@ import (
 // User methods
 func (s *bankingService) GetUserbyEmail(userId string) (*model.User, error) {
 	var user model.User
-	err := s.db.QueryRow("SELECT * FROM users WHERE email = $1", userId).Scan(&user.FirstName, &user.LastName, &user.Email, &user.Type, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err := s.db.QueryRow(`
+		SELECT first_name, last_name, email, type, status, password, created_at, updated_at 
+		FROM users WHERE email = $1`, userId).Scan(
+		&user.FirstName, &user.LastName, &user.Email, &user.Type, &user.Status, &user.Password, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
@@ -31,14 +34,19 @@ func (s *bankingService) CreateUser(user *model.User) error {
 
 	defer tx.Rollback()
 
+	user.Password, err = hashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+
 	// Execute insert within transaction
 	res, err := tx.Exec(`
 		INSERT INTO users (
 			first_name, last_name, email, type, 
-			password, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			status, password, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 		user.FirstName, user.LastName, user.Email, user.Type,
-		user.Password, user.CreatedAt, user.UpdatedAt)
+		user.Status, user.Password, user.CreatedAt, user.UpdatedAt)
 		
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %v", err)
@@ -70,6 +78,20 @@ func (s *bankingService) UpdateUser(user *model.User) error {
 
 	defer tx.Rollback()
 
+	var currentPassword string
+	err = tx.QueryRow("SELECT password FROM users WHERE email = $1 FOR UPDATE", user.Email).Scan(&currentPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("failed to query user: %v", err)
+	}
+
+	user.Password, err = resolvePassword(currentPassword, user.Password)
+	if err != nil {
+		return err
+	}
+
 	// Execute update within transaction
 	res, err := tx.Exec(`
 		UPDATE users 
@@ -98,3 +120,131 @@ func (s *bankingService) UpdateUser(user *model.User) error {
 
 	return nil	
 }
+
+// AuthenticateUser checks a user's password. The same error is returned for
+// an unknown email and a wrong password, so callers cannot tell them apart.
+// Failed logins in a row are counted and lock the user once they reach the
+// limit; the first successful login activates a pending user.
+func (s *bankingService) AuthenticateUser(email string, password string) (*model.User, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var user model.User
+	var failedLogins int
+	err = tx.QueryRow(`
+		SELECT first_name, last_name, email, type, status, password, failed_logins 
+		FROM users WHERE email = $1 
+		FOR UPDATE`, email).Scan(
+		&user.FirstName, &user.LastName, &user.Email, &user.Type, &user.Status, &user.Password, &failedLogins)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to query user: %v", err)
+	}
+
+	if err = signInError(user.Status); err != nil {
+		return nil, err
+	}
+
+	status := user.Status
+	var authErr error
+	if !checkPassword(user.Password, password) {
+		authErr = ErrInvalidCredentials
+		failedLogins++
+		if s.maxFailedLogins > 0 && failedLogins >= s.maxFailedLogins {
+			status = model.UserLocked
+			err = recordAudit(tx, model.NewUserStatusChangedEvent(email, user.Status, status, model.ActorSystem, "too many failed logins"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		failedLogins = 0
+		if user.Status == model.UserPending {
+			status = model.UserActive
+			err = recordAudit(tx, model.NewUserStatusChangedEvent(email, user.Status, status, email, "first login"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	_, err = tx.Exec("UPDATE users SET status = $1, failed_logins = $2 WHERE email = $3", status, failedLogins, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	if authErr != nil {
+		return nil, authErr
+	}
+	user.Status = status
+	return &user, nil
+}
+
+// ChangeUserStatus moves a user to a new status if the lifecycle allows it,
+// and queues an audit event recording who did it and why. Disabling or
+// locking a user also revokes their refresh tokens.
+func (s *bankingService) ChangeUserStatus(email string, status string, actor string, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow("SELECT status FROM users WHERE email = $1 FOR UPDATE", email).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("failed to query user: %v", err)
+	}
+
+	if err = model.CheckUserTransition(current, status); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET status = $1, failed_logins = 0, updated_at = CURRENT_TIMESTAMP WHERE email = $2", status, email)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %v", err)
+	}
+
+	if !model.CanSignIn(status) {
+		_, err = tx.Exec("UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE email = $1 AND revoked_at IS NULL", email)
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %v", err)
+		}
+	}
+
+	if err = recordAudit(tx, model.NewUserStatusChangedEvent(email, current, status, actor, reason)); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// signInError returns why a user with the given status may not sign in, if
+// they may not
+func signInError(status string) error {
+	switch status {
+	case model.UserDisabled:
+		return ErrUserDisabled
+	case model.UserLocked:
+		return ErrUserLocked
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/banking-app/account-service/src/config"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rate is the number of units of To that one unit of From buys
+type Rate struct {
+	From  string
+	To    string
+	Value *big.Rat
+}
+
+// String formats the rate as a plain decimal, e.g. "0.92"
+func (r Rate) String() string {
+	s := r.Value.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// RateProvider looks up exchange rates between currencies
+type RateProvider interface {
+	Rate(from string, to string) (Rate, error)
+}
+
+type staticRateProvider struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// rateFile is the layout of the rates file, every rate is quoted against base
+//
+//	base: USD
+//	rates:
+//	  EUR: "0.92"
+type rateFile struct {
+	Base  string            `yaml:"base"`
+	Rates map[string]string `yaml:"rates"`
+}
+
+// NewStaticRateProvider returns a provider backed by a fixed table of rates
+// quoted against base, e.g. {"EUR": "0.92"} means 1 base buys 0.92 EUR
+func NewStaticRateProvider(base string, rates map[string]string) (RateProvider, error) {
+	provider := &staticRateProvider{
+		base:  strings.ToUpper(base),
+		rates: make(map[string]*big.Rat, len(rates)),
+	}
+	for currency, value := range rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s", value, currency)
+		}
+		provider.rates[strings.ToUpper(currency)] = rate
+	}
+	return provider, nil
+}
+
+// NewFileRateProvider loads the rates file configured in exchange.rates_file
+func NewFileRateProvider(cfg *config.Config) (RateProvider, error) {
+	file, err := os.ReadFile(cfg.Exchange.RatesFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rates file: %v", err)
+	}
+
+	var rates rateFile
+	if err = yaml.Unmarshal(file, &rates); err != nil {
+		return nil, fmt.Errorf("error parsing rates file: %v", err)
+	}
+
+	return NewStaticRateProvider(rates.Base, rates.Rates)
+}
+
+func (p *staticRateProvider) Rate(from string, to string) (Rate, error) {
+	if from == to {
+		return Rate{From: from, To: to, Value: big.NewRat(1, 1)}, nil
+	}
+
+	fromRate, err := p.quote(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toRate, err := p.quote(to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	// both quotes are against the base currency, so from -> to is to/from
+	return Rate{From: from, To: to, Value: new(big.Rat).Quo(toRate, fromRate)}, nil
+}
+
+func (p *staticRateProvider) quote(currency string) (*big.Rat, error) {
+	if currency == p.base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := p.rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	return rate, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestRate(t *testing.T) {
+	provider, err := NewStaticRateProvider("USD", map[string]string{
+		"EUR": "0.92",
+		"GBP": "0.80",
+	})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"USD", "USD", "1"},
+		{"USD", "EUR", "0.92"},
+		{"EUR", "USD", "1.0869565217"},
+		{"GBP", "EUR", "1.15"},
+	}
+
+	for _, tt := range tests {
+		rate, err := provider.Rate(tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Rate(%s, %s) returned error %v", tt.from, tt.to, err)
+			continue
+		}
+		if rate.String() != tt.want {
+			t.Errorf("Rate(%s, %s) = %s, want %s", tt.from, tt.to, rate, tt.want)
+		}
+	}
+
+	if _, err := provider.Rate("USD", "JPY"); err == nil {
+		t.Errorf("Expected an error for an unknown currency")
+	}
+}
@@ -2,124 +2,97 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/banking-app/account-service/src/config"
 	"github.com/banking-app/account-service/src/model"
-	bankingService "github.com/banking-app/account-service/src/service/banking"
 	"go.uber.org/fx"
 
 	"github.com/segmentio/kafka-go"
 )
 
 type KafkaService interface {
-	PublishTransaction(transaction *model.Transaction) error
+	PublishEvents(events []model.OutboxEvent) error
 }
 
 type kafkaService struct {
-	banking bankingService.BankingService
 	writer *kafka.Writer
-	topic  string
+	// topics maps each kind of outbox event to the topic it is published to
+	topics map[string]string
 }
 
-func NewKafkaService(cfg *config.Config, banking bankingService.BankingService) (KafkaService, error) {
+func NewKafkaService(lc fx.Lifecycle, cfg *config.Config) (KafkaService, error) {
 	writer := kafka.NewWriter(kafka.WriterConfig{
 		Brokers:      cfg.Kafka.Brokers,
-		Topic:        cfg.Kafka.Topic,
+		Balancer:     &kafka.Hash{},
 		BatchSize:    cfg.Kafka.BatchSize,
 		BatchTimeout: time.Millisecond * time.Duration(cfg.Kafka.BatchTimeout),
 		RequiredAcks: cfg.Kafka.RequiredAcks,
 		Async:        cfg.Kafka.Async,
 	})
 
-	return &kafkaService{
-		banking: banking,
+	k := &kafkaService{
 		writer: writer,
-		topic:  "banking.transactions",
-	}, nil
+		topics: map[string]string{
+			model.EventTransaction: cfg.Kafka.Topic,
+			model.EventAudit:       cfg.Kafka.AuditTopic,
+		},
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return Close(k)
+		},
+	})
+
+	return k, nil
 }
 
-func (k *kafkaService) PublishTransaction(transaction *model.Transaction) error {
-	json, err := json.Marshal(transaction)
+// PublishEvents writes a batch of outbox events to kafka in a single call,
+// each to the topic for its kind. Messages are hashed to a partition by key,
+// so the events of one account or user stay on one partition and in order.
+func (k *kafkaService) PublishEvents(events []model.OutboxEvent) error {
+	msgs, err := k.messages(events)
 	if err != nil {
 		return err
 	}
 
-	msg := kafka.Message{
-		Value: json,
-		Headers: []kafka.Header{
-			{
-				Key:   "type",
-				Value: []byte(transaction.ID),
-			},
-		},
-	}
-
 	ctx := context.Background()
-	err = k.writer.WriteMessages(ctx, msg)
+	err = k.writer.WriteMessages(ctx, msgs...)
 	if err != nil {
-		log.Printf("Failed to publish transaction: %v", err)
+		log.Printf("Failed to publish %d events: %v", len(events), err)
 		return err
 	}
 
 	return nil
 }
 
-// Don't forget to add a Close method if you need to clean up
-func Close(k *kafkaService) error {
-	return k.writer.Close()
-}
-
-// scan the transactions and publish to kafka periodically using a ticker in for loop
-func(kafkaservice *kafkaService) ScanTransactions() error {
-	t := time.NewTicker(time.Second * 10)
-	// Scan transactions every 10 seconds
-	for {
-		select {
-		case <-t.C:
-			
-			// get transactions from postgres
-			transactions, err := kafkaservice.banking.GetTransactions()
-			if err != nil {
-				log.Printf("Error getting transactions: %v", err)
-				continue
-			}
-			for _, transaction := range transactions {
-				err:= kafkaservice.PublishTransaction(&transaction)
-				if err != nil {
-					log.Printf("Error publishing transaction: %v", err)
-					continue
-				}
-				log.Printf("Successfully published transaction: %v", transaction)
-				err= kafkaservice.banking.DeleteTransactionsById(transaction.ID)
-				if err != nil {
-					log.Printf("Error deleting transaction: %v", err)
-					continue
-				}
-			}
-
+// messages builds the kafka message for each event, failing if any event is
+// of a kind no topic is configured for
+func (k *kafkaService) messages(events []model.OutboxEvent) ([]kafka.Message, error) {
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		topic, ok := k.topics[event.Kind]
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("no topic configured for %s event %s", event.Kind, event.ID)
+		}
+		msgs[i] = kafka.Message{
+			Topic: topic,
+			Key:   []byte(event.Key),
+			Value: event.Payload,
+			Headers: []kafka.Header{
+				{
+					Key:   "id",
+					Value: []byte(event.ID),
+				},
+			},
 		}
 	}
+	return msgs, nil
 }
 
-func StartKafkaScan(lc fx.Lifecycle, kafka KafkaService) {
-    lc.Append(fx.Hook{
-        OnStart: func(context.Context) error {
-            go func() {
-                if k, ok := kafka.(*kafkaService); ok {
-                    if err := k.ScanTransactions(); err != nil {
-                        log.Printf("Error starting transaction scan: %v", err)
-                    }
-                }
-            }()
-            return nil
-        },
-        OnStop: func(context.Context) error {
-            Close(kafka.(*kafkaService))
-            return nil
-        },
-    })
+func Close(k *kafkaService) error {
+	return k.writer.Close()
 }
-
@@ -2,9 +2,9 @@ package service
 
 import (
 	"testing"
-	"time"
 
 	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
@@ -14,8 +14,8 @@ type mockKafkaService struct {
 	mock.Mock
 }
 
-func (m *mockKafkaService) PublishTransaction(transaction *model.Transaction) error {
-	args := m.Called(transaction)
+func (m *mockKafkaService) PublishEvents(events []model.OutboxEvent) error {
+	args := m.Called(events)
 	return args.Error(0)
 }
 
@@ -23,25 +23,61 @@ func NewMockKafkaService(t *testing.T) *mockKafkaService {
 	return &mockKafkaService{t: t}
 }
 
-func TestPublishTransaction(t *testing.T) {
+func TestPublishEvents(t *testing.T) {
 
 	mockKafkaService := NewMockKafkaService(t)
 
-	transaction := model.Transaction{
-		ID:        uuid.New().String(),
-		Account:   uuid.New().String(),
-		Amount:    100,
-		Type:      "credit",
-		Timestamp: time.Now(),
+	transaction := model.NewTransaction(uuid.New().String(), money.New(10000, money.DefaultCurrency), "credit")
+	event, err := model.NewOutboxEvent(transaction)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
 	}
 
-	// add the transaction to the mock kafka service
-	mockKafkaService.On("PublishTransaction", &transaction).Return(nil)
+	events := []model.OutboxEvent{*event}
+
+	// add the events to the mock kafka service
+	mockKafkaService.On("PublishEvents", events).Return(nil)
 
-	// call the PublishTransaction method
-	err := mockKafkaService.PublishTransaction(&transaction)
+	// call the PublishEvents method
+	err = mockKafkaService.PublishEvents(events)
 
 	if err != nil {
 		t.Errorf("Expected error to be nil, but got %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestMessagesRouteEventsByKind(t *testing.T) {
+
+	k := &kafkaService{topics: map[string]string{
+		model.EventTransaction: "banking-transactions",
+		model.EventAudit:       "banking-audit",
+	}}
+
+	transaction := model.NewTransaction(uuid.New().String(), money.New(10000, money.DefaultCurrency), "credit")
+	txnEvent, err := model.NewOutboxEvent(transaction)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	audit := model.NewUserStatusChangedEvent("yash@gmail.com", model.UserActive, model.UserDisabled, "yash@gmail.com", "")
+	auditEvent, err := model.NewAuditOutboxEvent(audit)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	msgs, err := k.messages([]model.OutboxEvent{*txnEvent, *auditEvent})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if msgs[0].Topic != "banking-transactions" || string(msgs[0].Key) != transaction.Account {
+		t.Errorf("Expected the transaction on banking-transactions keyed by account, but got %s/%s", msgs[0].Topic, msgs[0].Key)
+	}
+	if msgs[1].Topic != "banking-audit" || string(msgs[1].Key) != "yash@gmail.com" {
+		t.Errorf("Expected the audit event on banking-audit keyed by user, but got %s/%s", msgs[1].Topic, msgs[1].Key)
+	}
+
+	// an event with no topic is refused rather than dropped
+	delete(k.topics, model.EventAudit)
+	if _, err = k.messages([]model.OutboxEvent{*auditEvent}); err == nil {
+		t.Errorf("Expected an error for an audit event with no topic")
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/gateway"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+
+	"go.uber.org/fx"
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultGracePeriod = 5 * time.Minute
+)
+
+// Reconciler checks that each account's balance and transactions agree with
+// the history transaction-service built from the events it consumed. An
+// event lost on the way leaves the two apart for good, so missing
+// transactions can be published again from this service's record.
+type Reconciler struct {
+	banking     bankingService.BankingService
+	gateway     gateway.Gateway
+	interval    time.Duration
+	batchSize   int
+	gracePeriod time.Duration
+	republish   bool
+	now         func() time.Time
+}
+
+func NewReconciler(cfg *config.Config, banking bankingService.BankingService, gateway gateway.Gateway) *Reconciler {
+	r := &Reconciler{
+		banking:     banking,
+		gateway:     gateway,
+		interval:    time.Minute * time.Duration(cfg.Reconciliation.Interval),
+		batchSize:   cfg.Reconciliation.BatchSize,
+		gracePeriod: time.Second * time.Duration(cfg.Reconciliation.GracePeriod),
+		republish:   cfg.Reconciliation.Republish,
+		now:         time.Now,
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+	if r.gracePeriod <= 0 {
+		r.gracePeriod = defaultGracePeriod
+	}
+	return r
+}
+
+// Reconcile reconciles the given accounts, or every account if none are
+// given. With republish, transactions missing from transaction-service are
+// queued to be published again. An account that cannot be reconciled is
+// reported as failed and the rest are still checked.
+func (r *Reconciler) Reconcile(accountIDs []string, republish bool) (*model.ReconciliationReport, error) {
+	report := &model.ReconciliationReport{
+		StartedAt:  r.now(),
+		Mismatches: []model.AccountMismatch{},
+		Failed:     map[string]string{},
+		Adjusted:   []model.AccountAdjustment{},
+	}
+	settledAfter := report.StartedAt.Add(-r.gracePeriod)
+
+	if len(accountIDs) > 0 {
+		for _, id := range accountIDs {
+			r.reconcileAccount(id, settledAfter, republish, report)
+		}
+	} else {
+		after := ""
+		for {
+			ids, err := r.banking.ListAccountIDs(after, r.batchSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				r.reconcileAccount(id, settledAfter, republish, report)
+			}
+			if len(ids) < r.batchSize {
+				break
+			}
+			after = ids[len(ids)-1]
+		}
+	}
+
+	report.FinishedAt = r.now()
+	return report, nil
+}
+
+func (r *Reconciler) reconcileAccount(accountID string, settledAfter time.Time, republish bool, report *model.ReconciliationReport) {
+	report.Accounts++
+
+	record, err := r.banking.GetAccountRecord(accountID)
+	if err != nil {
+		report.Failed[accountID] = err.Error()
+		return
+	}
+	if !record.Adjustments.IsZero() {
+		report.Adjusted = append(report.Adjusted, model.AccountAdjustment{AccountID: accountID, Amount: record.Adjustments})
+	}
+	summary, err := r.gateway.GetAccountSummary(accountID)
+	if err != nil {
+		report.Failed[accountID] = err.Error()
+		return
+	}
+	mismatch, err := model.Reconcile(record, summary, settledAfter)
+	if err != nil {
+		report.Failed[accountID] = err.Error()
+		return
+	}
+	if mismatch == nil {
+		return
+	}
+
+	if republish && len(mismatch.Missing) > 0 {
+		n, err := r.banking.RepublishTransactions(mismatch.Missing)
+		if err != nil {
+			report.Failed[accountID] = err.Error()
+		}
+		mismatch.Republished = n
+		report.Republished += n
+	}
+	report.Mismatches = append(report.Mismatches, *mismatch)
+}
+
+// Run reconciles every account on the configured interval until ctx is
+// cancelled. It does nothing if no interval is set.
+func (r *Reconciler) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			report, err := r.Reconcile(nil, r.republish)
+			if err != nil {
+				log.Printf("Error reconciling accounts: %v", err)
+				continue
+			}
+			logReport(report)
+		}
+	}
+}
+
+func logReport(report *model.ReconciliationReport) {
+	for _, m := range report.Mismatches {
+		log.Printf("Account %s has balance %s but its history adds up to %s; missing %v, extra %v, republished %d",
+			m.AccountID, m.Balance, m.HistoryBalance, m.Missing, m.Extra, m.Republished)
+	}
+	for id, reason := range report.Failed {
+		log.Printf("Could not reconcile account %s: %s", id, reason)
+	}
+	log.Printf("Reconciled %d accounts: %d mismatched, %d failed, %d transactions republished",
+		report.Accounts, len(report.Mismatches), len(report.Failed), report.Republished)
+}
+
+func StartReconciler(lc fx.Lifecycle, reconciler *Reconciler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go reconciler.Run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
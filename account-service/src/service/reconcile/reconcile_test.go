@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/gateway"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+	"github.com/banking-app/protos/money"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBankingService struct {
+	bankingService.BankingService
+	mock.Mock
+}
+
+func (m *mockBankingService) ListAccountIDs(after string, limit int) ([]string, error) {
+	args := m.Called(after, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockBankingService) GetAccountRecord(accountID string) (*model.AccountRecord, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(*model.AccountRecord), args.Error(1)
+}
+
+func (m *mockBankingService) RepublishTransactions(ids []string) (int, error) {
+	args := m.Called(ids)
+	return args.Int(0), args.Error(1)
+}
+
+type mockGateway struct {
+	gateway.Gateway
+	mock.Mock
+}
+
+func (m *mockGateway) GetAccountSummary(accountId string) (*model.TransactionSummary, error) {
+	args := m.Called(accountId)
+	return args.Get(0).(*model.TransactionSummary), args.Error(1)
+}
+
+func TestReconcileVisitsEveryAccountAndRepublishesMissing(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Reconciliation.BatchSize = 2
+
+	banking := &mockBankingService{}
+	gw := &mockGateway{}
+	r := NewReconciler(cfg, banking, gw)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	credit := func(id string) model.Transaction {
+		return model.Transaction{ID: id, Amount: usd(1000), Type: "credit", Timestamp: now.Add(-time.Hour)}
+	}
+
+	banking.On("ListAccountIDs", "", 2).Return([]string{"a", "b"}, nil)
+	banking.On("ListAccountIDs", "b", 2).Return([]string{"c"}, nil)
+
+	banking.On("GetAccountRecord", "a").Return(&model.AccountRecord{AccountID: "a", Balance: usd(1000), Transactions: []model.Transaction{credit("a1")}}, nil)
+	gw.On("GetAccountSummary", "a").Return(&model.TransactionSummary{Balance: usd(1000), TransactionIDs: []string{"a1"}}, nil)
+
+	banking.On("GetAccountRecord", "b").Return(&model.AccountRecord{AccountID: "b", Balance: usd(2000), Transactions: []model.Transaction{credit("b1"), credit("b2")}}, nil)
+	gw.On("GetAccountSummary", "b").Return(&model.TransactionSummary{Balance: usd(1000), TransactionIDs: []string{"b1"}}, nil)
+	banking.On("RepublishTransactions", []string{"b2"}).Return(1, nil)
+
+	banking.On("GetAccountRecord", "c").Return((*model.AccountRecord)(nil), errors.New("account c not found"))
+
+	report, err := r.Reconcile(nil, true)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+	gw.AssertExpectations(t)
+
+	if report.Accounts != 3 {
+		t.Errorf("Expected 3 accounts to be reconciled but got %d", report.Accounts)
+	}
+	if len(report.Mismatches) != 1 || report.Mismatches[0].AccountID != "b" {
+		t.Fatalf("Expected account b to mismatch but got %+v", report.Mismatches)
+	}
+	if report.Republished != 1 || report.Mismatches[0].Republished != 1 {
+		t.Errorf("Expected one transaction to be republished but got %d", report.Republished)
+	}
+	if _, ok := report.Failed["c"]; !ok {
+		t.Errorf("Expected account c to fail but got %v", report.Failed)
+	}
+}
+
+func TestReconcileOnlyReportsWithoutRepublish(t *testing.T) {
+
+	banking := &mockBankingService{}
+	gw := &mockGateway{}
+	r := NewReconciler(&config.Config{}, banking, gw)
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	lost := model.Transaction{ID: "lost", Amount: usd(1000), Type: "credit", Timestamp: time.Now().Add(-time.Hour)}
+	banking.On("GetAccountRecord", "a").Return(&model.AccountRecord{AccountID: "a", Balance: usd(1000), Transactions: []model.Transaction{lost}}, nil)
+	gw.On("GetAccountSummary", "a").Return(&model.TransactionSummary{TransactionIDs: []string{}}, nil)
+
+	report, err := r.Reconcile([]string{"a"}, false)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertNotCalled(t, "RepublishTransactions", mock.Anything)
+	if len(report.Mismatches) != 1 || report.Republished != 0 {
+		t.Errorf("Expected a mismatch and nothing republished but got %+v", report)
+	}
+}
+
+func TestReconcileReportsAdjustmentsSeparately(t *testing.T) {
+
+	banking := &mockBankingService{}
+	gw := &mockGateway{}
+	r := NewReconciler(&config.Config{}, banking, gw)
+
+	// 15.00 of the balance came from the ledger migration's adjustment
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	credit := model.Transaction{ID: "a1", Amount: usd(1000), Type: "credit", Timestamp: time.Now().Add(-time.Hour)}
+	banking.On("GetAccountRecord", "a").Return(&model.AccountRecord{AccountID: "a", Balance: usd(2500), Transactions: []model.Transaction{credit}, Adjustments: usd(1500)}, nil)
+	gw.On("GetAccountSummary", "a").Return(&model.TransactionSummary{Balance: usd(1000), TransactionIDs: []string{"a1"}}, nil)
+
+	report, err := r.Reconcile([]string{"a"}, true)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Errorf("Expected no mismatch but got %+v", report.Mismatches)
+	}
+	if len(report.Adjusted) != 1 || report.Adjusted[0].AccountID != "a" || report.Adjusted[0].Amount != usd(1500) {
+		t.Errorf("Expected account a's adjustment to be reported but got %+v", report.Adjusted)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+	kafkaService "github.com/banking-app/account-service/src/service/kafka"
+
+	"go.uber.org/fx"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+)
+
+// Relay drains the outbox to kafka. Events are written to the outbox in the
+// same database transaction as the balance change they describe, so every
+// committed change is eventually published, at least once.
+type Relay struct {
+	banking      bankingService.BankingService
+	kafka        kafkaService.KafkaService
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewRelay(cfg *config.Config, banking bankingService.BankingService, kafka kafkaService.KafkaService) *Relay {
+	r := &Relay{
+		banking:      banking,
+		kafka:        kafka,
+		pollInterval: time.Millisecond * time.Duration(cfg.Kafka.OutboxPollInterval),
+		batchSize:    cfg.Kafka.OutboxBatchSize,
+	}
+	if r.pollInterval <= 0 {
+		r.pollInterval = defaultPollInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+	return r
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	t := time.NewTicker(r.pollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := r.drain(ctx); err != nil {
+				log.Printf("Error relaying outbox: %v", err)
+			}
+		}
+	}
+}
+
+// drain relays batches until the outbox has no more claimable events, so a
+// backlog is cleared without waiting a poll interval per batch
+func (r *Relay) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := r.banking.RelayOutbox(r.batchSize, r.kafka.PublishEvents)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Relayed %d outbox events", n)
+		}
+		if n < r.batchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+func StartRelay(lc fx.Lifecycle, relay *Relay) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go relay.Run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
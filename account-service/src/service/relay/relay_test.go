@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockBankingService serves claimed batches from a queue, calling publish on
+// each the way the real service does
+type mockBankingService struct {
+	bankingService.BankingService
+	mock.Mock
+	batches [][]model.OutboxEvent
+}
+
+func (m *mockBankingService) RelayOutbox(limit int, publish func([]model.OutboxEvent) error) (int, error) {
+	args := m.Called(limit)
+	if err := args.Error(0); err != nil {
+		return 0, err
+	}
+	if len(m.batches) == 0 {
+		return 0, nil
+	}
+	batch := m.batches[0]
+	if err := publish(batch); err != nil {
+		return 0, err
+	}
+	m.batches = m.batches[1:]
+	return len(batch), nil
+}
+
+type mockKafkaService struct {
+	mock.Mock
+}
+
+func (m *mockKafkaService) PublishEvents(events []model.OutboxEvent) error {
+	args := m.Called(events)
+	return args.Error(0)
+}
+
+func newTestRelay(banking *mockBankingService, kafka *mockKafkaService) *Relay {
+	cfg := &config.Config{}
+	cfg.Kafka.OutboxBatchSize = 2
+	return NewRelay(cfg, banking, kafka)
+}
+
+func TestDrainRelaysUntilOutboxIsEmpty(t *testing.T) {
+
+	banking := &mockBankingService{
+		batches: [][]model.OutboxEvent{
+			{{ID: "1"}, {ID: "2"}},
+			{{ID: "3"}},
+		},
+	}
+	kafka := &mockKafkaService{}
+
+	banking.On("RelayOutbox", 2).Return(nil)
+	kafka.On("PublishEvents", mock.Anything).Return(nil)
+
+	err := newTestRelay(banking, kafka).drain(context.Background())
+
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	// a full batch is followed straight away by another claim, a short one is not
+	banking.AssertNumberOfCalls(t, "RelayOutbox", 2)
+	kafka.AssertNumberOfCalls(t, "PublishEvents", 2)
+}
+
+func TestDrainStopsOnPublishFailure(t *testing.T) {
+
+	batch := []model.OutboxEvent{{ID: "1"}, {ID: "2"}}
+	banking := &mockBankingService{
+		batches: [][]model.OutboxEvent{batch, {{ID: "3"}}},
+	}
+	kafka := &mockKafkaService{}
+
+	banking.On("RelayOutbox", 2).Return(nil)
+	kafka.On("PublishEvents", batch).Return(errors.New("broker unavailable"))
+
+	err := newTestRelay(banking, kafka).drain(context.Background())
+
+	if err == nil {
+		t.Errorf("Expected the publish error to be returned")
+	}
+	// the failed batch stays queued and nothing after it is published
+	if len(banking.batches) != 2 {
+		t.Errorf("Expected 2 batches to remain queued but got %d", len(banking.batches))
+	}
+	kafka.AssertNumberOfCalls(t, "PublishEvents", 1)
+}
+
+func TestNewRelayDefaults(t *testing.T) {
+
+	r := NewRelay(&config.Config{}, &mockBankingService{}, &mockKafkaService{})
+
+	if r.pollInterval != defaultPollInterval {
+		t.Errorf("Expected poll interval %v but got %v", defaultPollInterval, r.pollInterval)
+	}
+	if r.batchSize != defaultBatchSize {
+		t.Errorf("Expected batch size %d but got %d", defaultBatchSize, r.batchSize)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+
+	"go.uber.org/fx"
+)
+
+const (
+	defaultDormancyCheckInterval = time.Hour
+	defaultInterestCheckInterval = time.Hour
+	defaultOrderCheckInterval    = time.Minute
+	defaultOrderBatchSize        = 100
+	idempotencyPurgeInterval     = time.Hour
+	defaultHoldCheckInterval     = 5 * time.Minute
+	defaultHoldBatchSize         = 100
+)
+
+// Job is periodic work run by the scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs account maintenance jobs in the background, each on its own
+// interval
+type Scheduler struct {
+	jobs []Job
+	now  func() time.Time
+}
+
+func NewScheduler(cfg *config.Config, banking bankingService.BankingService) *Scheduler {
+	s := &Scheduler{now: time.Now}
+
+	if cfg.Accounts.InactiveAfterDays > 0 {
+		idleFor := time.Hour * 24 * time.Duration(cfg.Accounts.InactiveAfterDays)
+		interval := time.Minute * time.Duration(cfg.Accounts.DormancyCheckInterval)
+		if interval <= 0 {
+			interval = defaultDormancyCheckInterval
+		}
+		s.jobs = append(s.jobs, Job{
+			Name:     "dormancy",
+			Interval: interval,
+			Run: func(context.Context) error {
+				n, err := banking.MarkInactiveAccounts(s.now().Add(-idleFor))
+				if n > 0 {
+					log.Printf("Marked %d accounts inactive", n)
+				}
+				return err
+			},
+		})
+	}
+
+	if len(cfg.Interest.Rates) > 0 {
+		interval := time.Minute * time.Duration(cfg.Interest.CheckInterval)
+		if interval <= 0 {
+			interval = defaultInterestCheckInterval
+		}
+		s.jobs = append(s.jobs, Job{
+			Name:     "interest",
+			Interval: interval,
+			Run: func(context.Context) error {
+				// going back to the start of last month catches up on days
+				// missed while the service was down, before last month is
+				// posted
+				yesterday := s.now().AddDate(0, 0, -1)
+				from := model.StartOfMonth(s.now()).AddDate(0, -1, 0)
+				run, err := banking.AccrueInterestRange(from, yesterday)
+				if err != nil {
+					return err
+				}
+				if run.Accrued > 0 || run.Posted > 0 {
+					log.Printf("Accrued interest on %d account days and posted %d months", run.Accrued, run.Posted)
+				}
+				return nil
+			},
+		})
+	}
+
+	orderInterval := time.Second * time.Duration(cfg.StandingOrders.CheckInterval)
+	if orderInterval <= 0 {
+		orderInterval = defaultOrderCheckInterval
+	}
+	orderBatchSize := cfg.StandingOrders.BatchSize
+	if orderBatchSize <= 0 {
+		orderBatchSize = defaultOrderBatchSize
+	}
+	s.jobs = append(s.jobs, Job{
+		Name:     "standing orders",
+		Interval: orderInterval,
+		Run: func(context.Context) error {
+			n, err := banking.ExecuteStandingOrders(s.now(), orderBatchSize)
+			if n > 0 {
+				log.Printf("Paid %d standing orders", n)
+			}
+			return err
+		},
+	})
+
+	holdInterval := time.Minute * time.Duration(cfg.Holds.CheckInterval)
+	if holdInterval <= 0 {
+		holdInterval = defaultHoldCheckInterval
+	}
+	holdBatchSize := cfg.Holds.BatchSize
+	if holdBatchSize <= 0 {
+		holdBatchSize = defaultHoldBatchSize
+	}
+	s.jobs = append(s.jobs, Job{
+		Name:     "holds",
+		Interval: holdInterval,
+		Run: func(context.Context) error {
+			n, err := banking.ExpireHolds(s.now(), holdBatchSize)
+			if n > 0 {
+				log.Printf("Released %d expired holds", n)
+			}
+			return err
+		},
+	})
+
+	if cfg.Ledger.VerifyInterval > 0 {
+		s.jobs = append(s.jobs, Job{
+			Name:     "ledger",
+			Interval: time.Minute * time.Duration(cfg.Ledger.VerifyInterval),
+			Run: func(context.Context) error {
+				report, err := banking.VerifyLedger()
+				if err != nil {
+					return err
+				}
+				for _, d := range report.Drift {
+					log.Printf("Balance of account %s is %s but its postings sum to %s", d.AccountID, d.Balance, d.Ledger)
+				}
+				for _, id := range report.Unbalanced {
+					log.Printf("Journal entry %s does not balance", id)
+				}
+				return nil
+			},
+		})
+	}
+
+	if cfg.Idempotency.KeyTTL > 0 {
+		keyTTL := time.Hour * time.Duration(cfg.Idempotency.KeyTTL)
+		s.jobs = append(s.jobs, Job{
+			Name:     "idempotency keys",
+			Interval: idempotencyPurgeInterval,
+			Run: func(context.Context) error {
+				n, err := banking.PurgeIdempotencyKeys(s.now().Add(-keyTTL))
+				if n > 0 {
+					log.Printf("Purged %d idempotency keys", n)
+				}
+				return err
+			},
+		})
+	}
+
+	return s
+}
+
+// Run runs every job on its interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.run(ctx, job)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	t := time.NewTicker(job.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := job.Run(ctx); err != nil {
+				log.Printf("Error running %s job: %v", job.Name, err)
+			}
+		}
+	}
+}
+
+func StartScheduler(lc fx.Lifecycle, scheduler *Scheduler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			scheduler.Run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/config"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBankingService struct {
+	bankingService.BankingService
+	mock.Mock
+}
+
+func (m *mockBankingService) MarkInactiveAccounts(idleSince time.Time) (int, error) {
+	args := m.Called(idleSince)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBankingService) AccrueInterestRange(from time.Time, to time.Time) (*model.InterestRun, error) {
+	args := m.Called(from, to)
+	return args.Get(0).(*model.InterestRun), args.Error(1)
+}
+
+func (m *mockBankingService) ExecuteStandingOrders(now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBankingService) PurgeIdempotencyKeys(before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBankingService) ExpireHolds(now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBankingService) VerifyLedger() (*model.LedgerReport, error) {
+	args := m.Called()
+	return args.Get(0).(*model.LedgerReport), args.Error(1)
+}
+
+// findJob returns the scheduler's job with the given name, or nil
+func findJob(s *Scheduler, name string) *Job {
+	for i := range s.jobs {
+		if s.jobs[i].Name == name {
+			return &s.jobs[i]
+		}
+	}
+	return nil
+}
+
+func TestDormancyJobUsesInactiveAfterDays(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Accounts.InactiveAfterDays = 30
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	job := findJob(s, "dormancy")
+	if job == nil {
+		t.Fatalf("Expected the dormancy job")
+	}
+	if job.Interval != defaultDormancyCheckInterval {
+		t.Errorf("Expected the default interval but got %v", job.Interval)
+	}
+
+	banking.On("MarkInactiveAccounts", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)).Return(2, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+}
+
+func TestDormancyJobCanBeTurnedOff(t *testing.T) {
+
+	s := NewScheduler(&config.Config{}, &mockBankingService{})
+
+	if findJob(s, "dormancy") != nil {
+		t.Errorf("Expected no dormancy job")
+	}
+}
+
+func TestInterestJobCatchesUpFromLastMonth(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Interest.Rates = map[string][]config.InterestTier{
+		"savings": {{MinBalance: "0.00", Rate: "0.02"}},
+	}
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+	now := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	job := findJob(s, "interest")
+	if job == nil {
+		t.Fatalf("Expected the interest job")
+	}
+
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 29, 1, 0, 0, 0, time.UTC)
+	banking.On("AccrueInterestRange", from, to).Return(&model.InterestRun{}, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+}
+
+func TestStandingOrderJobPaysDueOrders(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.StandingOrders.BatchSize = 10
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	job := findJob(s, "standing orders")
+	if job == nil {
+		t.Fatalf("Expected the standing orders job")
+	}
+	if job.Interval != defaultOrderCheckInterval {
+		t.Errorf("Expected the default interval but got %v", job.Interval)
+	}
+
+	banking.On("ExecuteStandingOrders", now, 10).Return(3, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+}
+
+func TestIdempotencyJobPurgesExpiredKeys(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Idempotency.KeyTTL = 24
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	job := findJob(s, "idempotency keys")
+	if job == nil {
+		t.Fatalf("Expected the idempotency keys job")
+	}
+
+	banking.On("PurgeIdempotencyKeys", time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC)).Return(3, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+
+	// keys are kept forever when no ttl is set
+	if findJob(NewScheduler(&config.Config{}, banking), "idempotency keys") != nil {
+		t.Errorf("Expected no idempotency keys job")
+	}
+}
+
+func TestHoldsJobExpiresHolds(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Holds.BatchSize = 20
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	job := findJob(s, "holds")
+	if job == nil {
+		t.Fatalf("Expected the holds job")
+	}
+	if job.Interval != defaultHoldCheckInterval {
+		t.Errorf("Expected the default interval but got %v", job.Interval)
+	}
+
+	banking.On("ExpireHolds", now, 20).Return(3, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+}
+
+func TestLedgerJobVerifiesBalances(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Ledger.VerifyInterval = 60
+
+	banking := &mockBankingService{}
+	s := NewScheduler(cfg, banking)
+
+	job := findJob(s, "ledger")
+	if job == nil {
+		t.Fatalf("Expected the ledger job")
+	}
+	if job.Interval != time.Hour {
+		t.Errorf("Expected an interval of an hour but got %v", job.Interval)
+	}
+
+	// drift is reported, not returned as an error
+	report := &model.LedgerReport{Drift: []model.BalanceDrift{{AccountID: "account"}}}
+	banking.On("VerifyLedger").Return(report, nil)
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	banking.AssertExpectations(t)
+
+	if findJob(NewScheduler(&config.Config{}, banking), "ledger") != nil {
+		t.Errorf("Expected no ledger job")
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"github.com/banking-app/account-service/src/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const dateLayout = "2006-01-02"
+
+// RenderCSV writes a statement as CSV: a header block with the account and
+// opening balance, one row per transaction, then the totals and closing
+// balance
+func RenderCSV(s *model.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	currency := s.OpeningBalance.Currency
+
+	records := [][]string{
+		{"Account", s.AccountID},
+		{"Account holder", s.AccountHolder},
+		{"Month", s.Month},
+		{"Currency", currency},
+		{"Opening balance", s.OpeningBalance.Decimal()},
+		{},
+		{"Date", "Description", "Transaction", "Amount", "Balance"},
+	}
+	for _, l := range s.Lines {
+		records = append(records, []string{
+			l.Date.UTC().Format(dateLayout), l.Description, l.TransactionID, l.Amount.Decimal(), l.Balance.Decimal(),
+		})
+	}
+	records = append(records,
+		[]string{},
+		[]string{"Total credits", s.TotalCredits.Decimal()},
+		[]string{"Total debits", s.TotalDebits.Decimal()},
+		[]string{"Closing balance", s.ClosingBalance.Decimal()},
+	)
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write statement csv: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF lays a statement out as an A4 PDF. The document is dated when
+// the statement was generated, so rendering it again gives the same bytes.
+func RenderPDF(s *model.Statement) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(s.GeneratedAt)
+	pdf.SetModificationDate(s.GeneratedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(fmt.Sprintf("Statement %s %s", s.AccountID, s.Month), false)
+	pdf.AliasNbPages("")
+	// the core fonts only cover cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	currency := s.OpeningBalance.Currency
+
+	widths := []float64{25, 75, 25, 32, 33}
+	tableHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, title := range []string{"Date", "Description", "Reference", "Amount", "Balance"} {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, title, "B", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			tableHeader()
+		}
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Account statement", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range [][2]string{
+		{"Account holder", tr(s.AccountHolder)},
+		{"Account", s.AccountID},
+		{"Account type", s.AccountType},
+		{"Month", s.Month},
+		{"Currency", currency},
+	} {
+		pdf.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, row[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	summary := func(label string, value string) {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(157, 7, label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(33, 7, value, "", 1, "R", false, 0, "")
+	}
+	summary("Opening balance", s.OpeningBalance.Decimal())
+
+	tableHeader()
+	for _, l := range s.Lines {
+		pdf.CellFormat(widths[0], 6, l.Date.UTC().Format(dateLayout), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, tr(l.Description), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, shortReference(l.TransactionID), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, l.Amount.Decimal(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, l.Balance.Decimal(), "", 1, "R", false, 0, "")
+	}
+	if len(s.Lines) == 0 {
+		pdf.CellFormat(0, 6, "No transactions this month", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	summary("Total credits", s.TotalCredits.Decimal())
+	summary("Total debits", s.TotalDebits.Decimal())
+	summary("Closing balance", s.ClosingBalance.Decimal())
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write statement pdf: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// shortReference is the part of a transaction id printed on a statement
+func shortReference(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/model"
+	"github.com/banking-app/protos/money"
+)
+
+func testStatement(t *testing.T) *model.Statement {
+	account := &model.Account{ID: "account", FirstName: "Zoë", LastName: "Smith", Type: "checking", Balance: money.New(10000, "USD")}
+	transactions := []model.Transaction{
+		{ID: "credit", Amount: money.New(5000, "USD"), Type: "credit", Timestamp: time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	balances := &model.StatementBalances{Opening: money.New(5000, "USD"), Closing: money.New(10000, "USD")}
+	s, err := model.NewStatement(account, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), balances, transactions,
+		time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	return s
+}
+
+func TestRenderCSV(t *testing.T) {
+
+	csv, err := RenderCSV(testStatement(t))
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	for _, want := range []string{"Opening balance,50.00", "2024-03-03,Deposit,credit,50.00,100.00", "Closing balance,100.00"} {
+		if !strings.Contains(string(csv), want) {
+			t.Errorf("Expected the csv to contain %q, but got\n%s", want, csv)
+		}
+	}
+}
+
+func TestRenderPDFIsDeterministic(t *testing.T) {
+
+	s := testStatement(t)
+	first, err := RenderPDF(s)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	second, err := RenderPDF(s)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if !bytes.HasPrefix(first, []byte("%PDF-")) {
+		t.Errorf("Expected a pdf")
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("Expected rendering the same statement twice to give the same pdf")
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/banking-app/account-service/src/gateway"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+)
+
+// Statements produces monthly account statements. A statement is generated
+// from the ledger's balances and the account's transaction history the first
+// time it is asked for once the two agree, and stored with its renderings,
+// so later downloads are the same document.
+type Statements struct {
+	banking bankingService.BankingService
+	gateway gateway.Gateway
+	now     func() time.Time
+}
+
+func NewStatements(banking bankingService.BankingService, gateway gateway.Gateway) *Statements {
+	return &Statements{banking: banking, gateway: gateway, now: time.Now}
+}
+
+// Get returns an account's statement for month, written like 2024-03,
+// generating and storing it if it has not been yet
+func (s *Statements) Get(accountID string, month string) (*model.StoredStatement, error) {
+	start, err := time.Parse(model.MonthLayout, month)
+	if err != nil {
+		return nil, fmt.Errorf("month must be like 2024-03")
+	}
+
+	stored, err := s.banking.GetStatement(accountID, month)
+	if err == nil {
+		return stored, nil
+	}
+	if !errors.Is(err, model.ErrStatementNotFound) {
+		return nil, err
+	}
+
+	account, err := s.banking.GetAccountbyId(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the balances come from the ledger and the lines from transaction-
+	// service's history, which must agree before the statement is stored
+	now := s.now()
+	balances, err := s.banking.GetStatementBalances(accountID, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.gateway.GetTransactionsbyMonthRange(accountID, month, month)
+	if err != nil && !strings.Contains(err.Error(), "no transactions found") {
+		return nil, fmt.Errorf("failed to get transactions: %v", err)
+	}
+
+	statement, err := model.NewStatement(account, start, balances, transactions, now)
+	if err != nil {
+		return nil, err
+	}
+	stored = &model.StoredStatement{Statement: *statement}
+	if stored.CSV, err = RenderCSV(statement); err != nil {
+		return nil, err
+	}
+	if stored.PDF, err = RenderPDF(statement); err != nil {
+		return nil, err
+	}
+
+	// whichever request stores the statement first wins, and every other
+	// gets that one back
+	return s.banking.SaveStatement(stored)
+}
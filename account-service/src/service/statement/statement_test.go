@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/account-service/src/gateway"
+	"github.com/banking-app/account-service/src/model"
+	bankingService "github.com/banking-app/account-service/src/service/banking"
+	"github.com/banking-app/protos/money"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBankingService struct {
+	bankingService.BankingService
+	mock.Mock
+}
+
+func (m *mockBankingService) GetStatement(accountID string, month string) (*model.StoredStatement, error) {
+	args := m.Called(accountID, month)
+	return args.Get(0).(*model.StoredStatement), args.Error(1)
+}
+
+func (m *mockBankingService) GetAccountbyId(accountID string) (*model.Account, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
+func (m *mockBankingService) GetStatementBalances(accountID string, start time.Time, end time.Time) (*model.StatementBalances, error) {
+	args := m.Called(accountID, start, end)
+	return args.Get(0).(*model.StatementBalances), args.Error(1)
+}
+
+func (m *mockBankingService) SaveStatement(stored *model.StoredStatement) (*model.StoredStatement, error) {
+	args := m.Called(stored)
+	return args.Get(0).(*model.StoredStatement), args.Error(1)
+}
+
+type mockGateway struct {
+	gateway.Gateway
+	mock.Mock
+}
+
+func (m *mockGateway) GetTransactionsbyMonthRange(accountId string, startMonth string, endMonth string) ([]model.Transaction, error) {
+	args := m.Called(accountId, startMonth, endMonth)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func TestGetDoesNotStoreAnUnreadyStatement(t *testing.T) {
+
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	credit := model.Transaction{ID: "credit", Amount: usd(5000), Type: "credit", Timestamp: start.Add(time.Hour)}
+
+	tests := []struct {
+		name     string
+		balances *model.StatementBalances
+	}{
+		{"events unsent", &model.StatementBalances{Opening: usd(0), Closing: usd(5000), Unsent: 1}},
+		{"history short", &model.StatementBalances{Opening: usd(0), Closing: usd(7500)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banking := &mockBankingService{}
+			gw := &mockGateway{}
+			s := NewStatements(banking, gw)
+			s.now = func() time.Time { return time.Date(2024, time.April, 5, 0, 0, 0, 0, time.UTC) }
+
+			banking.On("GetStatement", "account", "2024-03").Return((*model.StoredStatement)(nil), model.ErrStatementNotFound)
+			banking.On("GetAccountbyId", "account").Return(&model.Account{ID: "account", Balance: usd(5000)}, nil)
+			banking.On("GetStatementBalances", "account", start, start.AddDate(0, 1, 0)).Return(tt.balances, nil)
+			gw.On("GetTransactionsbyMonthRange", "account", "2024-03", "2024-03").Return([]model.Transaction{credit}, nil)
+
+			_, err := s.Get("account", "2024-03")
+			if !errors.Is(err, model.ErrStatementNotReady) {
+				t.Errorf("Expected ErrStatementNotReady, but got %v", err)
+			}
+			banking.AssertNotCalled(t, "SaveStatement", mock.Anything)
+		})
+	}
+}
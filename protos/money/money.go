@@ -0,0 +1,274 @@
+// Package money provides a fixed-point Money type shared by the banking
+// services. Amounts are held as an integer number of minor units (cents) so
+// that arithmetic is exact and matches the DECIMAL(15,2) columns in Postgres.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	moneypb "github.com/banking-app/protos/generated/money"
+)
+
+// DefaultCurrency is the currency of accounts opened without one
+const DefaultCurrency = "USD"
+
+// minorDigits is the number of decimal places carried by every amount
+const minorDigits = 2
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+type Money struct {
+	Minor    int64  `bson:"minor"`
+	Currency string `bson:"currency"`
+}
+
+// jsonMoney is the wire format of Money, e.g. {"value": "100.25", "currency": "USD"}
+type jsonMoney struct {
+	Value    json.RawMessage `json:"value"`
+	Currency string          `json:"currency"`
+}
+
+// New returns an amount of minor units in the given currency
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// Parse parses a decimal string such as "100.25" into Money. More than two
+// decimal places, exponents and signs other than a leading minus are rejected.
+// An empty currency is kept empty so that callers can apply their own default,
+// typically the currency of the account the amount is posted to.
+func Parse(value string, currency string) (Money, error) {
+	minor, err := parseMinor(value)
+	if err != nil {
+		return Money{}, err
+	}
+	currency, err = normaliseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// FromProto converts a proto amount into Money
+func FromProto(m *moneypb.Money) (Money, error) {
+	if m == nil {
+		return Money{}, fmt.Errorf("amount is required")
+	}
+	return Parse(m.Value, m.Currency)
+}
+
+func (m Money) ToProto() *moneypb.Money {
+	return &moneypb.Money{
+		Value:    m.Decimal(),
+		Currency: m.Currency,
+	}
+}
+
+// Decimal formats the amount without its currency, e.g. "-100.05"
+func (m Money) Decimal() string {
+	sign := ""
+	minor := m.Minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Convert applies an exchange rate to the amount, rounding half away from zero
+// to the nearest minor unit
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Minor), rate)
+
+	// round half away from zero: add or subtract one half, then truncate
+	half := big.NewRat(1, 2)
+	if converted.Sign() < 0 {
+		converted.Sub(converted, half)
+	} else {
+		converted.Add(converted, half)
+	}
+	minor := new(big.Int).Quo(converted.Num(), converted.Denom())
+
+	return Money{Minor: minor.Int64(), Currency: currency}
+}
+
+// Cmp returns -1, 0 or 1 depending on whether m is less than, equal to or
+// greater than o
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Value    string `json:"value"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON accepts the value either as a string or as a JSON number,
+// but never with more than two decimal places
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw jsonMoney
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid amount: %v", err)
+	}
+	if len(raw.Value) == 0 {
+		return fmt.Errorf("invalid amount: value is required")
+	}
+
+	value := string(raw.Value)
+	if raw.Value[0] == '"' {
+		if err := json.Unmarshal(raw.Value, &value); err != nil {
+			return fmt.Errorf("invalid amount: %v", err)
+		}
+	}
+
+	parsed, err := Parse(value, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column into the minor units. The currency lives in its
+// own column and is left untouched.
+func (m *Money) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	case int64:
+		m.Minor = v * 100
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+	minor, err := parseMinor(value)
+	if err != nil {
+		return err
+	}
+	m.Minor = minor
+	return nil
+}
+
+// Value writes the amount as a decimal string so Postgres stores it exactly
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+func parseMinor(value string) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("invalid amount: empty value")
+	}
+
+	digits := value
+	negative := false
+	if digits[0] == '-' {
+		negative = true
+		digits = digits[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && fraction == "") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(fraction) > minorDigits {
+		return 0, fmt.Errorf("invalid amount %q: at most %d decimal places are allowed", value, minorDigits)
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+
+	fraction += strings.Repeat("0", minorDigits-len(fraction))
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", value, err)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// ParseCurrency validates and upper-cases an ISO 4217 currency code
+func ParseCurrency(currency string) (string, error) {
+	if currency == "" {
+		return "", fmt.Errorf("currency is required")
+	}
+	return normaliseCurrency(currency)
+}
+
+func normaliseCurrency(currency string) (string, error) {
+	if currency == "" {
+		return "", nil
+	}
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 {
+		return "", fmt.Errorf("invalid currency %q", currency)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency %q", currency)
+		}
+	}
+	return currency, nil
+}
@@ -0,0 +1,131 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		minor int64
+		ok    bool
+	}{
+		{"100", 10000, true},
+		{"100.2", 10020, true},
+		{"100.25", 10025, true},
+		{"-0.05", -5, true},
+		{"0.1", 10, true},
+		{"100.255", 0, false},
+		{"1e3", 0, false},
+		{"+1", 0, false},
+		{".5", 0, false},
+		{"5.", 0, false},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.value, "usd")
+		if tt.ok && err != nil {
+			t.Errorf("Parse(%q) returned error %v", tt.value, err)
+			continue
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("Expected Parse(%q) to fail", tt.value)
+			}
+			continue
+		}
+		if m.Minor != tt.minor || m.Currency != "USD" {
+			t.Errorf("Parse(%q) = %v, want %d USD", tt.value, m, tt.minor)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var m Money
+	if err := json.Unmarshal([]byte(`{"value": 100.25, "currency": "EUR"}`), &m); err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if m != New(10025, "EUR") {
+		t.Errorf("Expected 100.25 EUR, but got %v", m)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if string(data) != `{"value":"100.25","currency":"EUR"}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"value": "0.001", "currency": "EUR"}`), &m); err == nil {
+		t.Errorf("Expected more than two decimal places to be rejected")
+	}
+	if err := json.Unmarshal([]byte(`{"value": 1.5e2, "currency": "EUR"}`), &m); err == nil {
+		t.Errorf("Expected exponent notation to be rejected")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(1010, "USD")
+	b := New(20, "USD")
+
+	sum, err := a.Add(b)
+	if err != nil || sum.Minor != 1030 {
+		t.Errorf("Expected 10.30 USD, but got %v (%v)", sum, err)
+	}
+
+	diff, err := b.Sub(a)
+	if err != nil || diff.Decimal() != "-9.90" {
+		t.Errorf("Expected -9.90, but got %v (%v)", diff, err)
+	}
+
+	if _, err := a.Add(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected currency mismatch, but got %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		minor int64
+		rate  *big.Rat
+		want  int64
+	}{
+		{10000, big.NewRat(92, 100), 9200},
+		{1, big.NewRat(1, 2), 1},
+		{-1, big.NewRat(1, 2), -1},
+		{333, big.NewRat(1, 3), 111},
+		{1000, big.NewRat(10000, 7919), 1263},
+	}
+
+	for _, tt := range tests {
+		got := New(tt.minor, "USD").Convert("EUR", tt.rate)
+		if got != New(tt.want, "EUR") {
+			t.Errorf("Convert(%d, %s) = %v, want %d EUR", tt.minor, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestParseWithoutCurrency(t *testing.T) {
+	m, err := Parse("1.00", "")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if m.Currency != "" {
+		t.Errorf("Expected currency to stay empty, but got %q", m.Currency)
+	}
+}
+
+func TestScan(t *testing.T) {
+	m := Zero("GBP")
+	if err := m.Scan([]byte("15.50")); err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if m != New(1550, "GBP") {
+		t.Errorf("Expected 15.50 GBP, but got %v", m)
+	}
+}
@@ -0,0 +1,20 @@
+// Package risk holds the risk decisions the banking services share. The
+// rules that make them live in the riskengine module.
+package risk
+
+// Outcome is what a rule decides should happen to a transaction
+type Outcome string
+
+const (
+	Allow  Outcome = "allow"
+	Review Outcome = "review"
+	Block  Outcome = "block"
+)
+
+// Decision is the outcome of assessing an activity, with the rule that
+// decided it and why
+type Decision struct {
+	Outcome Outcome `json:"outcome"`
+	Rule    string  `json:"rule,omitempty"`
+	Reason  string  `json:"reason,omitempty"`
+}
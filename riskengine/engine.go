@@ -0,0 +1,101 @@
+// Package riskengine provides the fraud and risk rules shared by the banking
+// services. A rule judges a transaction against the recent history of its
+// account, and an Engine combines the outcomes of its rules into a decision.
+// Each service gathers the history from its own store.
+package riskengine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+)
+
+// ParseOutcome parses the outcome a rule is configured with. Only review
+// and block make sense there.
+func ParseOutcome(outcome string) (risk.Outcome, error) {
+	switch risk.Outcome(outcome) {
+	case risk.Review, risk.Block:
+		return risk.Outcome(outcome), nil
+	}
+	return "", fmt.Errorf("invalid outcome %q, must be review or block", outcome)
+}
+
+// severity orders outcomes so that the strictest one wins
+func severity(o risk.Outcome) int {
+	switch o {
+	case risk.Review:
+		return 1
+	case risk.Block:
+		return 2
+	}
+	return 0
+}
+
+// Entry is a transaction on an account as the rules see it
+type Entry struct {
+	Amount money.Money
+	Debit  bool
+	At     time.Time
+}
+
+// Activity is a transaction being assessed and the account history it is
+// judged against
+type Activity struct {
+	Account string
+	Entry
+	// History holds the account's earlier transactions within the engine's
+	// lookback, most recent first
+	History []Entry
+	// LastActivity is when the account last had a transaction before this
+	// one, and is zero if it never had one
+	LastActivity time.Time
+	// ReactivatedAt is when the account was last made active again after
+	// being inactive or frozen, and is zero if never or not known
+	ReactivatedAt time.Time
+}
+
+// Rule judges one kind of risk. It allows activities it has no concern with.
+type Rule interface {
+	Name() string
+	Evaluate(a Activity) risk.Decision
+}
+
+// Engine runs a set of rules over each activity
+type Engine struct {
+	rules    []Rule
+	lookback time.Duration
+}
+
+// NewEngine returns an engine running rules over activities whose history
+// goes back lookback
+func NewEngine(lookback time.Duration, rules ...Rule) *Engine {
+	return &Engine{rules: rules, lookback: lookback}
+}
+
+// Enabled reports whether the engine has any rules to run
+func (e *Engine) Enabled() bool {
+	return e != nil && len(e.rules) > 0
+}
+
+// Lookback is how far back the history given to Evaluate must go
+func (e *Engine) Lookback() time.Duration {
+	return e.lookback
+}
+
+// Evaluate runs every rule over the activity and returns the strictest
+// decision. Of rules deciding the same outcome the first one wins.
+func (e *Engine) Evaluate(a Activity) risk.Decision {
+	decision := risk.Decision{Outcome: risk.Allow}
+	if !e.Enabled() {
+		return decision
+	}
+	for _, rule := range e.rules {
+		d := rule.Evaluate(a)
+		if severity(d.Outcome) > severity(decision.Outcome) {
+			decision = d
+		}
+	}
+	return decision
+}
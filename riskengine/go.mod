@@ -0,0 +1,9 @@
+module github.com/banking-app/riskengine
+
+go 1.24.0
+
+replace github.com/banking-app/protos => ../protos
+
+require github.com/banking-app/protos v0.0.0-00010101000000-000000000000
+
+require google.golang.org/protobuf v1.36.5 // indirect
@@ -0,0 +1,232 @@
+package riskengine
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+)
+
+// Built-in rule types
+const (
+	RuleUnusualAmount      = "unusual_amount"
+	RuleRapidSuccession    = "rapid_succession"
+	RuleReactivatedAccount = "reactivated_account"
+)
+
+// defaultLookbackDays is how much history rules see when none is configured
+const defaultLookbackDays = 30
+
+// Config lists the rules the services run, as read from their config files
+type Config struct {
+	// LookbackDays is how many days of an account's history the rules see
+	LookbackDays int          `yaml:"lookback_days"`
+	Rules        []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig configures one rule. Each rule type reads the fields it needs.
+type RuleConfig struct {
+	// Type is the rule type, e.g. unusual_amount
+	Type string `yaml:"type"`
+	// Outcome is review or block
+	Outcome string `yaml:"outcome"`
+
+	// Multiplier is how many times the account's average transaction an
+	// unusual amount is, e.g. "5"
+	Multiplier string `yaml:"multiplier"`
+	// MinHistory is how many transactions an account needs before its
+	// average is trusted
+	MinHistory int `yaml:"min_history"`
+
+	// Window, in seconds, and MaxCount cap how many transactions an account
+	// may make in rapid succession
+	Window   int `yaml:"window"`
+	MaxCount int `yaml:"max_count"`
+
+	// WithinDays is how long after being reactivated, or after DormantDays
+	// without a transaction, an account's debits of MinAmount or more are
+	// caught
+	WithinDays  int    `yaml:"within_days"`
+	DormantDays int    `yaml:"dormant_days"`
+	MinAmount   string `yaml:"min_amount"`
+}
+
+// Factory builds a rule of one type from its config
+type Factory func(cfg RuleConfig, outcome risk.Outcome) (Rule, error)
+
+var factories = map[string]Factory{
+	RuleUnusualAmount:      newUnusualAmount,
+	RuleRapidSuccession:    newRapidSuccession,
+	RuleReactivatedAccount: newReactivatedAccount,
+}
+
+// Register makes a rule type available to NewEngineFromConfig, replacing
+// any built-in rule of the same type. It is meant to be called from init.
+func Register(ruleType string, factory Factory) {
+	factories[ruleType] = factory
+}
+
+// NewEngineFromConfig builds an engine running the configured rules in
+// order. An empty config builds an engine that allows everything.
+func NewEngineFromConfig(cfg Config) (*Engine, error) {
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for i, ruleCfg := range cfg.Rules {
+		factory, ok := factories[ruleCfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("rule %d: unknown rule type %q", i, ruleCfg.Type)
+		}
+		outcome, err := ParseOutcome(ruleCfg.Outcome)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		rule, err := factory(ruleCfg, outcome)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid %s rule: %v", i, ruleCfg.Type, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	lookbackDays := cfg.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultLookbackDays
+	}
+	return NewEngine(time.Hour*24*time.Duration(lookbackDays), rules...), nil
+}
+
+// UnusualAmount catches a transaction far larger than the account's average
+// transaction in the same direction
+type UnusualAmount struct {
+	Multiplier *big.Rat
+	MinHistory int
+	Outcome    risk.Outcome
+}
+
+func newUnusualAmount(cfg RuleConfig, outcome risk.Outcome) (Rule, error) {
+	multiplier, ok := new(big.Rat).SetString(cfg.Multiplier)
+	if !ok || multiplier.Sign() <= 0 {
+		return nil, fmt.Errorf("multiplier must be a positive number")
+	}
+	minHistory := cfg.MinHistory
+	if minHistory <= 0 {
+		minHistory = 1
+	}
+	return &UnusualAmount{Multiplier: multiplier, MinHistory: minHistory, Outcome: outcome}, nil
+}
+
+func (r *UnusualAmount) Name() string {
+	return RuleUnusualAmount
+}
+
+func (r *UnusualAmount) Evaluate(a Activity) risk.Decision {
+	var count, total int64
+	for _, entry := range a.History {
+		if entry.Debit == a.Debit && entry.Amount.Currency == a.Amount.Currency {
+			count++
+			total += entry.Amount.Minor
+		}
+	}
+	if count < int64(r.MinHistory) {
+		return risk.Decision{Outcome: risk.Allow}
+	}
+
+	// amount > multiplier * total / count, kept exact
+	threshold := new(big.Rat).Mul(r.Multiplier, big.NewRat(total, count))
+	if new(big.Rat).SetInt64(a.Amount.Minor).Cmp(threshold) <= 0 {
+		return risk.Decision{Outcome: risk.Allow}
+	}
+	average := money.New(total/count, a.Amount.Currency)
+	return risk.Decision{
+		Outcome: r.Outcome,
+		Rule:    r.Name(),
+		Reason:  fmt.Sprintf("%s is more than %s times the account's average of %s", a.Amount, r.Multiplier.RatString(), average),
+	}
+}
+
+// RapidSuccession catches an account making more than MaxCount transactions
+// within Window
+type RapidSuccession struct {
+	Window   time.Duration
+	MaxCount int
+	Outcome  risk.Outcome
+}
+
+func newRapidSuccession(cfg RuleConfig, outcome risk.Outcome) (Rule, error) {
+	if cfg.Window <= 0 || cfg.MaxCount <= 0 {
+		return nil, fmt.Errorf("window and max_count must be positive")
+	}
+	return &RapidSuccession{Window: time.Second * time.Duration(cfg.Window), MaxCount: cfg.MaxCount, Outcome: outcome}, nil
+}
+
+func (r *RapidSuccession) Name() string {
+	return RuleRapidSuccession
+}
+
+func (r *RapidSuccession) Evaluate(a Activity) risk.Decision {
+	since := a.At.Add(-r.Window)
+	count := 1
+	for _, entry := range a.History {
+		if entry.At.After(since) {
+			count++
+		}
+	}
+	if count <= r.MaxCount {
+		return risk.Decision{Outcome: risk.Allow}
+	}
+	return risk.Decision{
+		Outcome: r.Outcome,
+		Rule:    r.Name(),
+		Reason:  fmt.Sprintf("%d transactions within %s", count, r.Window),
+	}
+}
+
+// ReactivatedAccount catches large debits soon after an account comes back
+// into use, either by being reactivated or after a long time without
+// transactions
+type ReactivatedAccount struct {
+	Within     time.Duration
+	DormantFor time.Duration
+	MinAmount  money.Money
+	Outcome    risk.Outcome
+}
+
+func newReactivatedAccount(cfg RuleConfig, outcome risk.Outcome) (Rule, error) {
+	if cfg.WithinDays <= 0 {
+		return nil, fmt.Errorf("within_days must be positive")
+	}
+	var minAmount money.Money
+	if cfg.MinAmount != "" {
+		var err error
+		if minAmount, err = money.Parse(cfg.MinAmount, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &ReactivatedAccount{
+		Within:     time.Hour * 24 * time.Duration(cfg.WithinDays),
+		DormantFor: time.Hour * 24 * time.Duration(cfg.DormantDays),
+		MinAmount:  minAmount,
+		Outcome:    outcome,
+	}, nil
+}
+
+func (r *ReactivatedAccount) Name() string {
+	return RuleReactivatedAccount
+}
+
+func (r *ReactivatedAccount) Evaluate(a Activity) risk.Decision {
+	if !a.Debit || a.Amount.Minor < r.MinAmount.Minor {
+		return risk.Decision{Outcome: risk.Allow}
+	}
+
+	var reason string
+	switch {
+	case !a.ReactivatedAt.IsZero() && a.At.Sub(a.ReactivatedAt) <= r.Within:
+		reason = fmt.Sprintf("%s debited within %s of the account being reactivated", a.Amount, r.Within)
+	case r.DormantFor > 0 && !a.LastActivity.IsZero() && a.At.Sub(a.LastActivity) >= r.DormantFor:
+		reason = fmt.Sprintf("%s debited after no transactions for %s", a.Amount, a.At.Sub(a.LastActivity).Truncate(time.Hour))
+	default:
+		return risk.Decision{Outcome: risk.Allow}
+	}
+	return risk.Decision{Outcome: r.Outcome, Rule: r.Name(), Reason: reason}
+}
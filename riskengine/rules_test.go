@@ -0,0 +1,115 @@
+package riskengine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func debits(minors ...int64) []Entry {
+	entries := make([]Entry, len(minors))
+	for i, minor := range minors {
+		entries[i] = Entry{Amount: money.New(minor, "USD"), Debit: true, At: now.Add(-time.Duration(i+1) * 24 * time.Hour)}
+	}
+	return entries
+}
+
+func TestUnusualAmount(t *testing.T) {
+
+	engine, err := NewEngineFromConfig(Config{Rules: []RuleConfig{
+		{Type: RuleUnusualAmount, Outcome: "review", Multiplier: "5", MinHistory: 3},
+		{Type: RuleUnusualAmount, Outcome: "block", Multiplier: "20", MinHistory: 3},
+	}})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	// an average debit of 100.00
+	history := debits(5000, 10000, 15000)
+	tests := []struct {
+		minor int64
+		want  risk.Outcome
+	}{
+		{50000, risk.Allow},
+		{50001, risk.Review},
+		{200001, risk.Block},
+	}
+	for _, tt := range tests {
+		a := Activity{Entry: Entry{Amount: money.New(tt.minor, "USD"), Debit: true, At: now}, History: history}
+		if got := engine.Evaluate(a); got.Outcome != tt.want {
+			t.Errorf("Expected %s for %d but got %s", tt.want, tt.minor, got.Outcome)
+		}
+	}
+
+	// too little history to judge by, or history in the other direction
+	a := Activity{Entry: Entry{Amount: money.New(1000000, "USD"), Debit: false, At: now}, History: history}
+	if got := engine.Evaluate(a); got.Outcome != risk.Allow {
+		t.Errorf("Expected a deposit not to be judged by debits, but got %s", got.Outcome)
+	}
+}
+
+func TestRapidSuccession(t *testing.T) {
+
+	rule, err := newRapidSuccession(RuleConfig{Window: 60, MaxCount: 2}, risk.Review)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	recent := []Entry{{Amount: money.New(100, "USD"), At: now.Add(-30 * time.Second)}}
+	a := Activity{Entry: Entry{Amount: money.New(100, "USD"), At: now}, History: recent}
+	if got := rule.Evaluate(a); got.Outcome != risk.Allow {
+		t.Errorf("Expected a second transaction to be allowed, but got %s", got.Outcome)
+	}
+
+	a.History = append(recent, Entry{Amount: money.New(100, "USD"), At: now.Add(-45 * time.Second)})
+	if got := rule.Evaluate(a); got.Outcome != risk.Review || got.Rule != RuleRapidSuccession {
+		t.Errorf("Expected a third transaction to be held, but got %+v", got)
+	}
+}
+
+func TestReactivatedAccount(t *testing.T) {
+
+	rule, err := newReactivatedAccount(RuleConfig{WithinDays: 7, DormantDays: 90, MinAmount: "500.00"}, risk.Review)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	a := Activity{Entry: Entry{Amount: money.New(50000, "USD"), Debit: true, At: now}, ReactivatedAt: now.Add(-48 * time.Hour)}
+	if got := rule.Evaluate(a); got.Outcome != risk.Review {
+		t.Errorf("Expected a debit just after reactivation to be held, but got %s", got.Outcome)
+	}
+
+	a.Amount = money.New(49999, "USD")
+	if got := rule.Evaluate(a); got.Outcome != risk.Allow {
+		t.Errorf("Expected a debit under the minimum to be allowed, but got %s", got.Outcome)
+	}
+
+	a = Activity{Entry: Entry{Amount: money.New(50000, "USD"), Debit: true, At: now}, LastActivity: now.Add(-100 * 24 * time.Hour)}
+	if got := rule.Evaluate(a); got.Outcome != risk.Review {
+		t.Errorf("Expected a debit after 100 quiet days to be held, but got %s", got.Outcome)
+	}
+}
+
+func TestNewEngineFromConfigRejectsBadRules(t *testing.T) {
+
+	bad := []RuleConfig{
+		{Type: "no_such_rule", Outcome: "review"},
+		{Type: RuleUnusualAmount, Outcome: "allow", Multiplier: "5"},
+		{Type: RuleUnusualAmount, Outcome: "review", Multiplier: "-1"},
+		{Type: RuleRapidSuccession, Outcome: "block"},
+	}
+	for _, rule := range bad {
+		if _, err := NewEngineFromConfig(Config{Rules: []RuleConfig{rule}}); err == nil {
+			t.Errorf("Expected %+v to be rejected", rule)
+		}
+	}
+
+	engine, err := NewEngineFromConfig(Config{})
+	if err != nil || engine.Enabled() {
+		t.Errorf("Expected an empty config to build an engine with no rules")
+	}
+}
@@ -2,10 +2,15 @@ module github.com/banking-app/transaction-service
 
 replace github.com/banking-app/protos => ../protos
 
+replace github.com/banking-app/riskengine => ../riskengine
+
 go 1.24.0
 
 require (
+	github.com/banking-app/protos v0.0.0-00010101000000-000000000000
+	github.com/banking-app/riskengine v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.10.0
+	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/google/uuid v1.6.0
 	github.com/segmentio/kafka-go v0.4.47
 	github.com/stretchr/testify v1.9.0
@@ -54,6 +59,6 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.23.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
-	google.golang.org/protobuf v1.34.1 // indirect
+	google.golang.org/protobuf v1.36.5 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -7,6 +7,8 @@ import (
 	"github.com/banking-app/transaction-service/src/config"
 	"github.com/banking-app/transaction-service/src/handler"
 	"github.com/banking-app/transaction-service/src/server"
+	authService "github.com/banking-app/transaction-service/src/service/auth"
+	deadLetterService "github.com/banking-app/transaction-service/src/service/deadletter"
 	kafkaservice "github.com/banking-app/transaction-service/src/service/kafka"
 	transactionService "github.com/banking-app/transaction-service/src/service/transaction"
 	"go.uber.org/fx"
@@ -22,6 +24,8 @@ func main() {
 		fx.Provide(
 			config.LoadFromFile,
 			transactionService.NewTransactionService,
+			deadLetterService.NewDeadLetterService,
+			authService.NewAuthService,
 			kafkaservice.NewKafkaConsumer,
 			handler.NewHandler,
 			server.NewGinServer,
@@ -37,14 +41,15 @@ func main() {
 }
 
 func startKafkaConsumer(lc fx.Lifecycle, consumer *kafkaservice.KafkaConsumer) {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
 			go kafkaservice.StartConsuming(ctx, consumer)
 			return nil
 		},
 		OnStop: func(context.Context) error {
-			// Add cleanup if necessary
+			// stop fetching; an uncommitted message is redelivered on restart
+			cancel()
 			return nil
 		},
 	})
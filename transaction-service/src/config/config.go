@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 
+	"github.com/banking-app/riskengine"
+
 	"go.uber.org/fx"
 	"gopkg.in/yaml.v2"
 )
@@ -11,6 +13,19 @@ type Config struct {
 	Server  Server  `yaml:"server"`
 	MongoDB MongoDB `yaml:"mongodb"`
 	Kafka   Kafka   `yaml:"kafka"`
+	// Risk lists the fraud rules run over each stored transaction
+	Risk riskengine.Config `yaml:"risk"`
+	Auth Auth        `yaml:"auth"`
+}
+
+// Auth is how admin routes check the access tokens account-service issues
+type Auth struct {
+	// JwtSecret is account-service's token secret. The JWT_SECRET environment
+	// variable takes precedence, so that the secret need not live in the
+	// file.
+	JwtSecret string `yaml:"jwt_secret"`
+	// Admins are the users who may call admin routes
+	Admins []string `yaml:"admins"`
 }
 
 type Server struct {
@@ -26,6 +41,16 @@ type Kafka struct {
 	Brokers       []string `yaml:"brokers"`
 	Topic         string   `yaml:"topic"`
 	ConsumerGroup string   `yaml:"consumer_group"`
+
+	// DeadLetterTopic receives messages that cannot be stored
+	DeadLetterTopic string `yaml:"dead_letter_topic"`
+	// MaxRetries is how many times a failed write is retried before the
+	// message is dead-lettered
+	MaxRetries int `yaml:"max_retries"`
+	// RetryBackoff is the wait, in milliseconds, before the first retry. It
+	// doubles on each retry up to MaxRetryBackoff.
+	RetryBackoff    int `yaml:"retry_backoff"`
+	MaxRetryBackoff int `yaml:"max_retry_backoff"`
 }
 
 func LoadFromFile() (*Config, error) {
@@ -40,6 +65,10 @@ func LoadFromFile() (*Config, error) {
 		return nil, err
 	}
 
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		cfg.Auth.JwtSecret = secret
+	}
+
 	return &cfg, nil
 
 }
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin lets a request through only with an access token, issued by
+// account-service, of one of the configured admins
+func (h handler) RequireAdmin(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return
+	}
+
+	email, err := h.AuthService.VerifyAccessToken(token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.AuthService.IsAdmin(email) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "only admins can do this"})
+		return
+	}
+	c.Next()
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	authservice "github.com/banking-app/transaction-service/src/service/auth"
+	deadletterservice "github.com/banking-app/transaction-service/src/service/deadletter"
 	transactionservice "github.com/banking-app/transaction-service/src/service/transaction"
 
 	"github.com/gin-gonic/gin"
@@ -10,16 +12,29 @@ type Handler interface {
 	GetTransactionbyId(c *gin.Context)
 	GetTransactionsbyCount(c *gin.Context)
 	GetTransactionsbyMonthRange(c *gin.Context)
+	GetAccountSummary(c *gin.Context)
+	GetBalanceAsOf(c *gin.Context)
+	SearchTransactions(c *gin.Context)
+
+	// Admin methods
+	RequireAdmin(c *gin.Context)
+	ListDeadLetters(c *gin.Context)
+	ReplayDeadLetter(c *gin.Context)
+	ListRiskAlerts(c *gin.Context)
 }
 
 // AccountHandlerImpl implements AccountHandler
 type handler struct {
 	TransactionService transactionservice.TransactionService
+	DeadLetterService  deadletterservice.DeadLetterService
+	AuthService        authservice.AuthService
 }
 
 // NewAccountHandlerImpl returns a new AccountHandlerImpl
-func NewHandler(transactionService transactionservice.TransactionService) Handler {
+func NewHandler(transactionService transactionservice.TransactionService, deadLetterService deadletterservice.DeadLetterService, authService authservice.AuthService) Handler {
 	return &handler{
 		TransactionService: transactionService,
+		DeadLetterService:  deadLetterService,
+		AuthService:        authService,
 	}
 }
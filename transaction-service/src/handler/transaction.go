@@ -3,8 +3,11 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/banking-app/transaction-service/src/model"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -75,3 +78,57 @@ func (h handler) GetTransactionsbyMonthRange(c *gin.Context) {
 	c.JSON(http.StatusOK, transactions)
 
 }
+
+// GetAccountSummary adds up an account's stored transactions, for
+// account-service to reconcile against its balance
+func (h handler) GetAccountSummary(c *gin.Context) {
+	summary, err := h.TransactionService.GetAccountSummary(c.Param("account"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// SearchTransactions pages through the transactions matching the filters in
+// the query, newest first. A page's nextCursor is passed back as cursor to
+// get the next one.
+func (h handler) SearchTransactions(c *gin.Context) {
+	search, err := model.ParseTransactionSearch(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := h.TransactionService.SearchTransactions(search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetBalanceAsOf gets an account's balance as of a time, given in RFC 3339,
+// or as of the end of a day given like 2024-03-31
+func (h handler) GetBalanceAsOf(c *gin.Context) {
+	date := c.Param("date")
+	asOf, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		day, dayErr := time.Parse("2006-01-02", date)
+		if dayErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be like 2024-03-31 or 2024-03-31T12:00:00Z"})
+			return
+		}
+		asOf = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	balance, err := h.TransactionService.GetBalanceAsOf(c.Param("account"), asOf)
+	if err != nil {
+		if strings.Contains(err.Error(), "no transactions found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, balance)
+}
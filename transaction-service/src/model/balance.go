@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+// AccountBalance is an account's balance as of a point in time
+type AccountBalance struct {
+	Account string      `json:"account"`
+	AsOf    time.Time   `json:"asOf"`
+	Balance money.Money `json:"balance"`
+	// TransactionID and Seq identify the last transaction posted by then
+	TransactionID string `json:"transactionId"`
+	Seq           int64  `json:"seq,omitempty"`
+}
+
+// NewAccountBalance works out an account's balance as of asOf from its
+// transactions up to then, in the order they were posted. The balance is
+// taken from the last one that carries the balance after it, plus whatever
+// follows; a history from before balances were carried is added up.
+func NewAccountBalance(account string, asOf time.Time, transactions []Transaction) (*AccountBalance, error) {
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("no transactions found")
+	}
+
+	from := 0
+	for i := len(transactions) - 1; i >= 0; i-- {
+		if transactions[i].BalanceAfter != nil {
+			from = i
+			break
+		}
+	}
+
+	var balance money.Money
+	for i, t := range transactions[from:] {
+		if i == 0 && t.BalanceAfter != nil {
+			balance = *t.BalanceAfter
+			continue
+		}
+		amount := t.Amount
+		if IsDebitType(t.Type) {
+			amount = amount.Neg()
+		}
+		if i == 0 {
+			balance = money.Zero(amount.Currency)
+		}
+		var err error
+		if balance, err = balance.Add(amount); err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", t.ID, err)
+		}
+	}
+
+	last := transactions[len(transactions)-1]
+	return &AccountBalance{
+		Account:       account,
+		AsOf:          asOf,
+		Balance:       balance,
+		TransactionID: last.ID,
+		Seq:           last.Seq,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewAccountBalance(t *testing.T) {
+
+	usd := func(minor int64) *money.Money { m := money.New(minor, "USD"); return &m }
+	asOf := time.Now()
+
+	// published before balances were carried, so added up
+	legacy := []Transaction{
+		{ID: "opening", Amount: *usd(10000), Type: "opening"},
+		{ID: "debit", Amount: *usd(4000), Type: "debit"},
+	}
+	balance, err := NewAccountBalance("account", asOf, legacy)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if balance.Balance != *usd(6000) || balance.TransactionID != "debit" {
+		t.Errorf("Expected a balance of 60.00 after debit but got %s after %s", balance.Balance, balance.TransactionID)
+	}
+
+	// the running balance is taken as it was carried, not added up again
+	carried := append(legacy,
+		Transaction{ID: "credit", Amount: *usd(2500), Type: "credit", Seq: 3, BalanceAfter: usd(9000)},
+		Transaction{ID: "fee", Amount: *usd(500), Type: "fee", Seq: 4, BalanceAfter: usd(8500)},
+	)
+	balance, err = NewAccountBalance("account", asOf, carried)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if balance.Balance != *usd(8500) || balance.Seq != 4 {
+		t.Errorf("Expected a balance of 85.00 at seq 4 but got %s at %d", balance.Balance, balance.Seq)
+	}
+
+	if _, err = NewAccountBalance("account", asOf, nil); err == nil {
+		t.Errorf("Expected no balance without transactions")
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// DeadLetter is a message the consumer gave up on, as read back from the
+// dead-letter topic. Partition and Offset locate it in the dead-letter topic;
+// the Original fields locate the message it was copied from.
+type DeadLetter struct {
+	Partition         int       `json:"partition"`
+	Offset            int64     `json:"offset"`
+	Key               string    `json:"key"`
+	Value             string    `json:"value"`
+	Error             string    `json:"error"`
+	Attempts          int       `json:"attempts"`
+	OriginalTopic     string    `json:"originalTopic"`
+	OriginalPartition int       `json:"originalPartition"`
+	OriginalOffset    int64     `json:"originalOffset"`
+	FailedAt          time.Time `json:"failedAt"`
+}
@@ -3,18 +3,32 @@ package model
 import (
 	"time"
 
+	"github.com/banking-app/protos/money"
 	"github.com/google/uuid"
 )
 
 type Transaction struct {
-	ID        string    `json:"id" bson:"_id"`
-	Account   string    `json:"account" bson:"account"`
-	Amount    float64   `json:"amount" bson:"amount"`
-	Type      string    `json:"type" bson:"type"`
-	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	ID         string      `json:"id" bson:"_id"`
+	Account    string      `json:"account" bson:"account"`
+	Amount     money.Money `json:"amount" bson:"amount"`
+	Type       string      `json:"type" bson:"type"`
+	TransferID string      `json:"transferId,omitempty" bson:"transferId,omitempty"`
+	FxRate     string      `json:"fxRate,omitempty" bson:"fxRate,omitempty"`
+	// Reference is free text the customer gave the payment
+	Reference string `json:"reference,omitempty" bson:"reference,omitempty"`
+	// ReversalOf is the transaction this one reverses
+	ReversalOf string `json:"reversalOf,omitempty" bson:"reversalOf,omitempty"`
+	// ReversedBy is the transaction that reversed this one
+	ReversedBy string `json:"reversedBy,omitempty" bson:"reversedBy,omitempty"`
+	// Seq numbers the account's transactions in the order account-service
+	// posted them, and BalanceAfter is the account's balance once this one
+	// was. Transactions published before they were added have neither.
+	BalanceAfter *money.Money `json:"balanceAfter,omitempty" bson:"balanceAfter,omitempty"`
+	Seq          int64        `json:"seq,omitempty" bson:"seq,omitempty"`
+	Timestamp    time.Time    `json:"timestamp" bson:"timestamp"`
 }
 
-func NewTransaction(Account string, Amount float64, Type string) *Transaction {
+func NewTransaction(Account string, Amount money.Money, Type string) *Transaction {
 	return &Transaction{
 		ID:        uuid.New().String(),
 		Account:   Account,
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+)
+
+// debitTypes are the transaction types account-service posts that take
+// money out of an account. Every other type pays money in.
+var debitTypes = []string{"debit", "closing", "fee", "reversal"}
+
+// IsDebitType reports whether transactions of the given type take money out
+// of an account
+func IsDebitType(transactionType string) bool {
+	for _, t := range debitTypes {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// RiskAlert is a stored transaction the risk rules would have held for
+// review or blocked. Transactions reach this service after they are posted,
+// so an alert only flags one for a closer look.
+type RiskAlert struct {
+	TransactionID string       `json:"transactionId" bson:"_id"`
+	Account       string       `json:"account" bson:"account"`
+	Amount        money.Money  `json:"amount" bson:"amount"`
+	Type          string       `json:"type" bson:"type"`
+	Outcome       risk.Outcome `json:"outcome" bson:"outcome"`
+	Rule          string       `json:"rule" bson:"rule"`
+	Reason        string       `json:"reason" bson:"reason"`
+	CreatedAt     time.Time    `json:"createdAt" bson:"createdAt"`
+}
+
+// NewRiskAlert records the decision of the risk rules on a transaction
+func NewRiskAlert(transaction *Transaction, decision risk.Decision) *RiskAlert {
+	return &RiskAlert{
+		TransactionID: transaction.ID,
+		Account:       transaction.Account,
+		Amount:        transaction.Amount,
+		Type:          transaction.Type,
+		Outcome:       decision.Outcome,
+		Rule:          decision.Rule,
+		Reason:        decision.Reason,
+		CreatedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+const (
+	// DefaultPageSize is how many transactions a search returns when no
+	// limit is asked for, and MaxPageSize the most it returns at once
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// TransactionSearch filters transactions and pages through them newest
+// first. Every filter is optional.
+type TransactionSearch struct {
+	Account string
+	Types   []string
+	// MinAmount and MaxAmount bound the amount, inclusive. They are compared
+	// in minor units, so are usually given with Currency.
+	MinAmount *money.Money
+	MaxAmount *money.Money
+	Currency  string
+	// From and To bound the timestamp; From is inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// Reference matches transactions whose reference contains it, ignoring
+	// case
+	Reference string
+	Limit     int
+	// After is where the previous page ended
+	After *Cursor
+}
+
+// Cursor marks the last transaction of a page. Pages are ordered by
+// timestamp, then id, so the next page starts strictly after it.
+type Cursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// TransactionPage is one page of a search. NextCursor is empty on the last
+// page.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"nextCursor,omitempty"`
+}
+
+// Encode returns the cursor in the opaque form handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor handed out by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c Cursor
+	if err = json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// ParseTransactionSearch reads a search from query parameters: account,
+// type (repeated or comma separated), min_amount, max_amount, currency,
+// from, to, reference, limit and cursor. Dates are RFC 3339, or like
+// 2024-03-31 for the start of that day.
+func ParseTransactionSearch(query url.Values) (*TransactionSearch, error) {
+	search := &TransactionSearch{
+		Account:   query.Get("account"),
+		Reference: strings.TrimSpace(query.Get("reference")),
+		Limit:     DefaultPageSize,
+	}
+	for _, value := range query["type"] {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				search.Types = append(search.Types, t)
+			}
+		}
+	}
+
+	if value := query.Get("currency"); value != "" {
+		currency, err := money.ParseCurrency(value)
+		if err != nil {
+			return nil, err
+		}
+		search.Currency = currency
+	}
+	for name, bound := range map[string]**money.Money{"min_amount": &search.MinAmount, "max_amount": &search.MaxAmount} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		amount, err := money.Parse(value, search.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		*bound = &amount
+	}
+	if search.MinAmount != nil && search.MaxAmount != nil && search.MinAmount.Minor > search.MaxAmount.Minor {
+		return nil, fmt.Errorf("min_amount cannot be more than max_amount")
+	}
+
+	for name, bound := range map[string]*time.Time{"from": &search.From, "to": &search.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		at, err := parseSearchTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: must be like 2024-03-31 or 2024-03-31T12:00:00Z", name)
+		}
+		*bound = at
+	}
+	if !search.From.IsZero() && !search.To.IsZero() && !search.From.Before(search.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+		}
+		search.Limit = limit
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+		search.After = cursor
+	}
+	return search, nil
+}
+
+func parseSearchTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// NewTransactionPage makes a page of up to limit transactions from a search
+// that fetched one more than that, so it knows whether another page follows
+func NewTransactionPage(transactions []Transaction, limit int) *TransactionPage {
+	page := &TransactionPage{Transactions: transactions}
+	if page.Transactions == nil {
+		page.Transactions = []Transaction{}
+	}
+	if len(page.Transactions) > limit {
+		page.Transactions = page.Transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = Cursor{Timestamp: last.Timestamp, ID: last.ID}.Encode()
+	}
+	return page
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestParseTransactionSearch(t *testing.T) {
+
+	query := url.Values{
+		"account":    {"account"},
+		"type":       {"debit,fee", "refund"},
+		"currency":   {"usd"},
+		"min_amount": {"10.00"},
+		"max_amount": {"250.50"},
+		"from":       {"2024-03-01"},
+		"to":         {"2024-04-01T00:00:00Z"},
+		"reference":  {" rent "},
+		"limit":      {"20"},
+	}
+	search, err := ParseTransactionSearch(query)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if len(search.Types) != 3 || search.Types[2] != "refund" {
+		t.Errorf("Expected types debit, fee and refund but got %v", search.Types)
+	}
+	if search.Currency != "USD" || *search.MinAmount != money.New(1000, "USD") || *search.MaxAmount != money.New(25050, "USD") {
+		t.Errorf("Expected amounts from 10.00 to 250.50 USD but got %v to %v in %s", search.MinAmount, search.MaxAmount, search.Currency)
+	}
+	if !search.From.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) || search.To.Month() != time.April {
+		t.Errorf("Expected March 2024 but got %s to %s", search.From, search.To)
+	}
+	if search.Reference != "rent" || search.Limit != 20 || search.After != nil {
+		t.Errorf("Expected reference rent and 20 a page but got %+v", search)
+	}
+
+	defaults, err := ParseTransactionSearch(url.Values{})
+	if err != nil || defaults.Limit != DefaultPageSize {
+		t.Errorf("Expected the default page size but got %+v, %v", defaults, err)
+	}
+
+	for _, invalid := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"201"}},
+		{"min_amount": {"5.00"}, "max_amount": {"1.00"}},
+		{"from": {"2024-04-01"}, "to": {"2024-03-01"}},
+		{"from": {"March"}},
+		{"cursor": {"not a cursor"}},
+	} {
+		if _, err := ParseTransactionSearch(invalid); err == nil {
+			t.Errorf("Expected %v to be refused", invalid)
+		}
+	}
+}
+
+func TestNewTransactionPage(t *testing.T) {
+
+	now := time.Now().UTC()
+	transactions := []Transaction{
+		{ID: "c", Timestamp: now},
+		{ID: "b", Timestamp: now.Add(-time.Minute)},
+		{ID: "a", Timestamp: now.Add(-2 * time.Minute)},
+	}
+
+	page := NewTransactionPage(transactions, 2)
+	if len(page.Transactions) != 2 || page.NextCursor == "" {
+		t.Fatalf("Expected a page of 2 with a next cursor but got %+v", page)
+	}
+	cursor, err := DecodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if cursor.ID != "b" || !cursor.Timestamp.Equal(transactions[1].Timestamp) {
+		t.Errorf("Expected the cursor to mark b but got %+v", cursor)
+	}
+
+	last := NewTransactionPage(transactions[2:], 2)
+	if len(last.Transactions) != 1 || last.NextCursor != "" {
+		t.Errorf("Expected a last page without a cursor but got %+v", last)
+	}
+	if empty := NewTransactionPage(nil, 2); empty.Transactions == nil {
+		t.Errorf("Expected an empty page to list no transactions rather than null")
+	}
+}
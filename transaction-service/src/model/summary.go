@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/banking-app/protos/money"
+)
+
+// AccountSummary is what an account's stored history adds up to, for
+// reconciling it against account-service
+type AccountSummary struct {
+	Account string `json:"account"`
+	// Balance is the sum of the account's transactions, debits taken away.
+	// Its currency is empty when the account has no transactions.
+	Balance        money.Money `json:"balance"`
+	TransactionIDs []string    `json:"transactionIds"`
+}
+
+// NewAccountSummary adds up an account's transactions
+func NewAccountSummary(account string, transactions []Transaction) (*AccountSummary, error) {
+	summary := &AccountSummary{Account: account, TransactionIDs: []string{}}
+	for i, t := range transactions {
+		amount := t.Amount
+		if IsDebitType(t.Type) {
+			amount = amount.Neg()
+		}
+		if i == 0 {
+			summary.Balance = money.Zero(amount.Currency)
+		}
+		balance, err := summary.Balance.Add(amount)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", t.ID, err)
+		}
+		summary.Balance = balance
+		summary.TransactionIDs = append(summary.TransactionIDs, t.ID)
+	}
+	return summary, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/banking-app/protos/money"
+)
+
+func TestNewAccountSummary(t *testing.T) {
+
+	transactions := []Transaction{
+		{ID: "opening", Amount: money.New(10000, "USD"), Type: "opening"},
+		{ID: "credit", Amount: money.New(2500, "USD"), Type: "credit"},
+		{ID: "debit", Amount: money.New(4000, "USD"), Type: "debit"},
+		{ID: "fee", Amount: money.New(500, "USD"), Type: "fee"},
+	}
+	summary, err := NewAccountSummary("account", transactions)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if summary.Balance != money.New(8000, "USD") {
+		t.Errorf("Expected a balance of 80.00 USD but got %s", summary.Balance)
+	}
+	if len(summary.TransactionIDs) != 4 {
+		t.Errorf("Expected 4 transaction ids but got %v", summary.TransactionIDs)
+	}
+
+	empty, err := NewAccountSummary("account", nil)
+	if err != nil || !empty.Balance.IsZero() || len(empty.TransactionIDs) != 0 {
+		t.Errorf("Expected an empty summary but got %+v, %v", empty, err)
+	}
+
+	transactions = append(transactions, Transaction{ID: "euro", Amount: money.New(100, "EUR"), Type: "credit"})
+	if _, err := NewAccountSummary("account", transactions); err == nil {
+		t.Errorf("Expected mixed currencies to be refused")
+	}
+}
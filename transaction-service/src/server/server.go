@@ -16,9 +16,17 @@ func NewGinServer(handler handler.Handler) *gin.Engine {
 	bankingApp := r.Group("/bankingapp")
 
 	transactionGroup := bankingApp.Group("/transactions")
+	transactionGroup.GET("", handler.SearchTransactions)
 	transactionGroup.GET("/id/:transactionId", handler.GetTransactionbyId)
 	transactionGroup.GET("history/:account/:count", handler.GetTransactionsbyCount)
 	transactionGroup.GET("/range/:account/:startMonth/:endMonth", handler.GetTransactionsbyMonthRange)
+	transactionGroup.GET("/summary/:account", handler.GetAccountSummary)
+	transactionGroup.GET("/balance/:account/:date", handler.GetBalanceAsOf)
+
+	adminGroup := bankingApp.Group("/admin", handler.RequireAdmin)
+	adminGroup.GET("/deadletters", handler.ListDeadLetters)
+	adminGroup.POST("/deadletters/:partition/:offset/replay", handler.ReplayDeadLetter)
+	adminGroup.GET("/risk-alerts", handler.ListRiskAlerts)
 
 	return r
 
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/banking-app/transaction-service/src/config"
+	"github.com/banking-app/transaction-service/src/handler"
+	"github.com/banking-app/transaction-service/src/model"
+	authService "github.com/banking-app/transaction-service/src/service/auth"
+	deadLetterService "github.com/banking-app/transaction-service/src/service/deadletter"
+	transactionService "github.com/banking-app/transaction-service/src/service/transaction"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type mockDeadLetterService struct {
+	deadLetterService.DeadLetterService
+	replayed int
+}
+
+func (m *mockDeadLetterService) List(ctx context.Context, limit int) ([]model.DeadLetter, error) {
+	return []model.DeadLetter{}, nil
+}
+
+func (m *mockDeadLetterService) Replay(ctx context.Context, partition int, offset int64) error {
+	m.replayed++
+	return nil
+}
+
+type mockTransactionService struct {
+	transactionService.TransactionService
+}
+
+func (m *mockTransactionService) GetRiskAlerts(limit int) ([]model.RiskAlert, error) {
+	return []model.RiskAlert{}, nil
+}
+
+// accessToken signs a token the way account-service does
+func accessToken(t *testing.T, email string, secret string) string {
+	t.Helper()
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "account-service",
+		Subject:   email,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func newTestServer(t *testing.T, transactions transactionService.TransactionService, deadLetters deadLetterService.DeadLetterService) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Auth.JwtSecret = testSecret
+	cfg.Auth.Admins = []string{"admin@bankingapp.com"}
+	auth, err := authService.NewAuthService(cfg)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	return NewGinServer(handler.NewHandler(transactions, deadLetters, auth))
+}
+
+func TestDeadLetterRoutesRequireAdmin(t *testing.T) {
+
+	deadLetters := &mockDeadLetterService{}
+	r := newTestServer(t, nil, deadLetters)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"forged token", accessToken(t, "admin@bankingapp.com", "not-the-secret-not-the-secret-000"), http.StatusUnauthorized},
+		{"customer", accessToken(t, "customer@example.com", testSecret), http.StatusForbidden},
+		{"admin", accessToken(t, "admin@bankingapp.com", testSecret), http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, route := range []struct{ method, path string }{
+			{http.MethodGet, "/bankingapp/admin/deadletters"},
+			{http.MethodPost, "/bankingapp/admin/deadletters/0/4/replay"},
+		} {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("%s %s as %s: expected %d but got %d", route.method, route.path, tt.name, tt.status, w.Code)
+			}
+		}
+	}
+	if deadLetters.replayed != 1 {
+		t.Errorf("Expected only the admin to replay a dead letter, but it was replayed %d times", deadLetters.replayed)
+	}
+}
+
+func TestRiskAlertsRouteRequiresAdmin(t *testing.T) {
+
+	r := newTestServer(t, &mockTransactionService{}, nil)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", accessToken(t, "customer@example.com", testSecret), http.StatusForbidden},
+		{"admin", accessToken(t, "admin@bankingapp.com", testSecret), http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/bankingapp/admin/risk-alerts", nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("risk alerts as %s: expected %d but got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
+
+func TestNewAuthServiceRequiresSecret(t *testing.T) {
+
+	for _, secret := range []string{"", "short"} {
+		cfg := &config.Config{}
+		cfg.Auth.JwtSecret = secret
+		if _, err := authService.NewAuthService(cfg); err == nil {
+			t.Errorf("Expected secret %q to be rejected", secret)
+		}
+	}
+}
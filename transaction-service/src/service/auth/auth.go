@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking-app/transaction-service/src/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// issuer is the service whose access tokens are accepted
+	issuer = "account-service"
+
+	// minSecretLength is the shortest secret accepted for HS256
+	minSecretLength = 32
+)
+
+// ErrInvalidToken is returned for an access token that is malformed,
+// tampered with, expired or not issued by account-service
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// AuthService checks the access tokens account-service issues. It shares
+// account-service's secret, so it can verify them without calling it.
+type AuthService interface {
+	// VerifyAccessToken returns the email of the user an access token was
+	// issued to
+	VerifyAccessToken(accessToken string) (string, error)
+	// IsAdmin reports whether a user may call admin routes
+	IsAdmin(email string) bool
+}
+
+type authService struct {
+	secret []byte
+	admins map[string]bool
+	now    func() time.Time
+}
+
+func NewAuthService(cfg *config.Config) (AuthService, error) {
+	if cfg.Auth.JwtSecret == "" {
+		return nil, fmt.Errorf("no token secret is set: set JWT_SECRET to account-service's")
+	}
+	if len(cfg.Auth.JwtSecret) < minSecretLength {
+		return nil, fmt.Errorf("auth.jwt_secret must be at least %d characters", minSecretLength)
+	}
+
+	a := &authService{
+		secret: []byte(cfg.Auth.JwtSecret),
+		admins: map[string]bool{},
+		now:    time.Now,
+	}
+	for _, email := range cfg.Auth.Admins {
+		a.admins[email] = true
+	}
+	return a, nil
+}
+
+func (a *authService) VerifyAccessToken(accessToken string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now),
+	)
+	if err != nil || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	return claims.Subject, nil
+}
+
+func (a *authService) IsAdmin(email string) bool {
+	return a.admins[email]
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banking-app/transaction-service/src/config"
+	"github.com/banking-app/transaction-service/src/model"
+	"go.uber.org/fx"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers added to a message when it is dead-lettered
+const (
+	HeaderError             = "dlq-error"
+	HeaderAttempts          = "dlq-attempts"
+	HeaderOriginalTopic     = "dlq-original-topic"
+	HeaderOriginalPartition = "dlq-original-partition"
+	HeaderOriginalOffset    = "dlq-original-offset"
+	HeaderFailedAt          = "dlq-failed-at"
+)
+
+// maxMessageBytes bounds a single fetch from the dead-letter topic
+const maxMessageBytes = 10e6
+
+// readTimeout bounds how long listing or replaying waits on the broker
+const readTimeout = 10 * time.Second
+
+type DeadLetterService interface {
+	// Send copies msg to the dead-letter topic, recording why and where it failed
+	Send(ctx context.Context, msg kafka.Message, cause error, attempts int) error
+	// List returns up to limit of the most recent dead letters of each partition
+	List(ctx context.Context, limit int) ([]model.DeadLetter, error)
+	// Replay publishes a dead letter back to the topic it came from
+	Replay(ctx context.Context, partition int, offset int64) error
+}
+
+// messageWriter is the part of kafka.Writer used here
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type deadLetterService struct {
+	writer  messageWriter
+	brokers []string
+	topic   string
+}
+
+func NewDeadLetterService(lc fx.Lifecycle, cfg *config.Config) (DeadLetterService, error) {
+	if cfg.Kafka.DeadLetterTopic == "" {
+		return nil, fmt.Errorf("kafka.dead_letter_topic is required")
+	}
+
+	// no topic on the writer, each message names its own so that one writer
+	// serves both the dead-letter topic and replays
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return writer.Close()
+		},
+	})
+
+	return &deadLetterService{
+		writer:  writer,
+		brokers: cfg.Kafka.Brokers,
+		topic:   cfg.Kafka.DeadLetterTopic,
+	}, nil
+}
+
+func (d *deadLetterService) Send(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	dead := kafka.Message{
+		Topic: d.topic,
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(withoutDeadLetterHeaders(msg.Headers),
+			kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+			kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+			kafka.Header{Key: HeaderOriginalTopic, Value: []byte(msg.Topic)},
+			kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+			kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			kafka.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		),
+	}
+	if err := d.writer.WriteMessages(ctx, dead); err != nil {
+		return fmt.Errorf("failed to write dead letter: %v", err)
+	}
+	log.Printf("Dead-lettered message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, cause)
+	return nil
+}
+
+func (d *deadLetterService) List(ctx context.Context, limit int) ([]model.DeadLetter, error) {
+	partitions, err := d.partitions()
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []model.DeadLetter
+	for _, partition := range partitions {
+		msgs, err := d.readTail(ctx, partition, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			letters = append(letters, toDeadLetter(msg))
+		}
+	}
+	return letters, nil
+}
+
+func (d *deadLetterService) Replay(ctx context.Context, partition int, offset int64) error {
+	conn, err := kafka.DialLeader(ctx, "tcp", d.brokers[0], d.topic, partition)
+	if err != nil {
+		return fmt.Errorf("failed to connect to dead-letter partition %d: %v", partition, err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return fmt.Errorf("failed to seek to offset %d: %v", offset, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	msg, err := conn.ReadMessage(maxMessageBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read dead letter %d/%d: %v", partition, offset, err)
+	}
+	if msg.Offset != offset {
+		return fmt.Errorf("dead letter %d/%d not found", partition, offset)
+	}
+
+	letter := toDeadLetter(msg)
+	if letter.OriginalTopic == "" {
+		return fmt.Errorf("dead letter %d/%d has no original topic", partition, offset)
+	}
+	replay := kafka.Message{
+		Topic:   letter.OriginalTopic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: withoutDeadLetterHeaders(msg.Headers),
+	}
+	if err = d.writer.WriteMessages(ctx, replay); err != nil {
+		return fmt.Errorf("failed to replay dead letter: %v", err)
+	}
+	log.Printf("Replayed dead letter %d/%d to %s", partition, offset, letter.OriginalTopic)
+	return nil
+}
+
+func (d *deadLetterService) partitions() ([]int, error) {
+	conn, err := kafka.Dial("tcp", d.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %v", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(d.topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter partitions: %v", err)
+	}
+	ids := make([]int, len(partitions))
+	for i, p := range partitions {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
+
+// readTail reads up to limit of the newest messages of a partition
+func (d *deadLetterService) readTail(ctx context.Context, partition int, limit int) ([]kafka.Message, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", d.brokers[0], d.topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to dead-letter partition %d: %v", partition, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offsets of partition %d: %v", partition, err)
+	}
+	start := last - int64(limit)
+	if start < first {
+		start = first
+	}
+	if start >= last {
+		return nil, nil
+	}
+	if _, err = conn.Seek(start, kafka.SeekAbsolute); err != nil {
+		return nil, fmt.Errorf("failed to seek to offset %d: %v", start, err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	batch := conn.ReadBatch(1, maxMessageBytes)
+	defer batch.Close()
+
+	var msgs []kafka.Message
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+		if msg.Offset >= last-1 {
+			break
+		}
+	}
+	return msgs, nil
+}
+
+func toDeadLetter(msg kafka.Message) model.DeadLetter {
+	letter := model.DeadLetter{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Value:     string(msg.Value),
+	}
+	for _, h := range msg.Headers {
+		value := string(h.Value)
+		switch h.Key {
+		case HeaderError:
+			letter.Error = value
+		case HeaderAttempts:
+			letter.Attempts, _ = strconv.Atoi(value)
+		case HeaderOriginalTopic:
+			letter.OriginalTopic = value
+		case HeaderOriginalPartition:
+			letter.OriginalPartition, _ = strconv.Atoi(value)
+		case HeaderOriginalOffset:
+			letter.OriginalOffset, _ = strconv.ParseInt(value, 10, 64)
+		case HeaderFailedAt:
+			letter.FailedAt, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	return letter
+}
+
+// withoutDeadLetterHeaders drops the headers added by Send, so that a message
+// dead-lettered twice records only its latest failure
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	var kept []kafka.Header
+	for _, h := range headers {
+		if !strings.HasPrefix(h.Key, "dlq-") {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type recordingWriter struct {
+	msgs []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func TestSendRecordsFailure(t *testing.T) {
+
+	writer := &recordingWriter{}
+	d := &deadLetterService{writer: writer, topic: "transactions-dlq"}
+
+	msg := kafka.Message{
+		Topic:     "transactions",
+		Partition: 2,
+		Offset:    41,
+		Key:       []byte("account"),
+		Value:     []byte("not json"),
+		Headers:   []kafka.Header{{Key: "id", Value: []byte("event")}},
+	}
+	err := d.Send(context.Background(), msg, errors.New("failed to unmarshal transaction"), 1)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+	if len(writer.msgs) != 1 {
+		t.Fatalf("Expected 1 message to be written but got %d", len(writer.msgs))
+	}
+
+	written := writer.msgs[0]
+	if written.Topic != "transactions-dlq" {
+		t.Errorf("Expected topic transactions-dlq but got %s", written.Topic)
+	}
+	letter := toDeadLetter(written)
+	if letter.Error != "failed to unmarshal transaction" || letter.Attempts != 1 {
+		t.Errorf("Expected the error and attempts to be recorded but got %q and %d", letter.Error, letter.Attempts)
+	}
+	if letter.OriginalTopic != "transactions" || letter.OriginalPartition != 2 || letter.OriginalOffset != 41 {
+		t.Errorf("Expected origin transactions/2/41 but got %s/%d/%d", letter.OriginalTopic, letter.OriginalPartition, letter.OriginalOffset)
+	}
+	if letter.Value != "not json" || letter.Key != "account" {
+		t.Errorf("Expected the original key and value to be kept")
+	}
+}
+
+func TestSendReplacesEarlierFailure(t *testing.T) {
+
+	writer := &recordingWriter{}
+	d := &deadLetterService{writer: writer, topic: "transactions-dlq"}
+
+	msg := kafka.Message{
+		Topic: "transactions",
+		Headers: []kafka.Header{
+			{Key: "id", Value: []byte("event")},
+			{Key: HeaderError, Value: []byte("first failure")},
+		},
+	}
+	if err := d.Send(context.Background(), msg, errors.New("second failure"), 1); err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	var errorHeaders int
+	for _, h := range writer.msgs[0].Headers {
+		if h.Key == HeaderError {
+			errorHeaders++
+		}
+	}
+	if errorHeaders != 1 {
+		t.Errorf("Expected 1 error header but got %d", errorHeaders)
+	}
+	if letter := toDeadLetter(writer.msgs[0]); letter.Error != "second failure" {
+		t.Errorf("Expected the latest error but got %q", letter.Error)
+	}
+}
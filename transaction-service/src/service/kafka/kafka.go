@@ -3,26 +3,73 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/banking-app/transaction-service/src/config"
 	"github.com/banking-app/transaction-service/src/model"
+	deadLetterService "github.com/banking-app/transaction-service/src/service/deadletter"
 	service "github.com/banking-app/transaction-service/src/service/transaction"
 	"github.com/segmentio/kafka-go"
 )
 
+const (
+	defaultMaxRetries      = 5
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultMaxRetryBackoff = 10 * time.Second
+)
+
 type KafkaConsumer struct {
-	config     config.Kafka
-	txnService service.TransactionService
+	config          config.Kafka
+	txnService      service.TransactionService
+	deadLetters     deadLetterService.DeadLetterService
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
 }
 
-func NewKafkaConsumer(config *config.Config, txnService service.TransactionService) *KafkaConsumer {
-	return &KafkaConsumer{
-		config:     config.Kafka,
-		txnService: txnService,
+func NewKafkaConsumer(config *config.Config, txnService service.TransactionService, deadLetters deadLetterService.DeadLetterService) *KafkaConsumer {
+	k := &KafkaConsumer{
+		config:          config.Kafka,
+		txnService:      txnService,
+		deadLetters:     deadLetters,
+		maxRetries:      config.Kafka.MaxRetries,
+		retryBackoff:    time.Millisecond * time.Duration(config.Kafka.RetryBackoff),
+		maxRetryBackoff: time.Millisecond * time.Duration(config.Kafka.MaxRetryBackoff),
+	}
+	if k.maxRetries <= 0 {
+		k.maxRetries = defaultMaxRetries
 	}
+	if k.retryBackoff <= 0 {
+		k.retryBackoff = defaultRetryBackoff
+	}
+	if k.maxRetryBackoff <= 0 {
+		k.maxRetryBackoff = defaultMaxRetryBackoff
+	}
+	return k
+}
+
+// poisonError marks a message that can never be stored, however often it is
+// retried
+type poisonError struct {
+	err error
+}
+
+func (e poisonError) Error() string {
+	return e.err.Error()
+}
+
+// messageReader is the part of a kafka reader the consumer uses
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
 }
 
+// StartConsuming stores every message in MongoDB and commits its offset only
+// once the message has been stored or dead-lettered, so a crash between the
+// two redelivers the message rather than losing it. Storing is idempotent, so
+// redelivery is safe.
 func StartConsuming(ctx context.Context, kafkaConsumer *KafkaConsumer) error {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: kafkaConsumer.config.Brokers,
@@ -31,33 +78,127 @@ func StartConsuming(ctx context.Context, kafkaConsumer *KafkaConsumer) error {
 	})
 	defer reader.Close()
 
-	log.Printf("Started consuming from topic: banking.transactions")
+	log.Printf("Started consuming from topic: %s", kafkaConsumer.config.Topic)
+	kafkaConsumer.consume(ctx, reader)
+	return nil
+}
 
+// consume processes messages from reader until ctx is cancelled. While
+// fetching fails, e.g. because the brokers are down, it backs off the same
+// way as failed writes before fetching again.
+func (k *KafkaConsumer) consume(ctx context.Context, reader messageReader) {
+	failures := 0
 	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			msg, err := reader.ReadMessage(ctx)
-			if err != nil {
-				log.Printf("Error reading message: %v", err)
-				continue
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
 			}
-
-			var transaction model.Transaction
-			if err := json.Unmarshal(msg.Value, &transaction); err != nil {
-				log.Printf("Failed to unmarshal transaction: %v", err)
-				continue
+			failures++
+			log.Printf("Error fetching message, attempt %d: %v", failures, err)
+			if k.wait(ctx, failures) != nil {
+				return
 			}
+			continue
+		}
+		failures = 0
+
+		if err := k.process(ctx, msg); err != nil {
+			// only returned once ctx is cancelled; the message is redelivered
+			return
+		}
 
-			// Add transaction to MongoDB
-			id, err := kafkaConsumer.txnService.AddTransaction(&transaction)
-			if err != nil {
-				log.Printf("Failed to store transaction: %v", err)
-				continue
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return
 			}
+			log.Printf("Failed to commit offset %d: %v", msg.Offset, err)
+		}
+	}
+}
 
-			log.Printf("Successfully processed transaction with ID: %s", id)
+// process stores msg, retrying failed writes with exponential backoff. A
+// poison message, or one still failing after the last retry, is sent to the
+// dead-letter topic instead. It only returns an error if ctx is cancelled
+// first, in which case the message must not be committed.
+func (k *KafkaConsumer) process(ctx context.Context, msg kafka.Message) error {
+	attempts := 1
+	err := k.handleMessage(msg)
+	for err != nil {
+		if _, poison := err.(poisonError); poison || attempts > k.maxRetries {
+			break
 		}
+		log.Printf("Failed to process message at offset %d, attempt %d: %v", msg.Offset, attempts, err)
+		if waitErr := k.wait(ctx, attempts); waitErr != nil {
+			return waitErr
+		}
+		attempts++
+		err = k.handleMessage(msg)
+	}
+	if err == nil {
+		return nil
 	}
+
+	// a message is only committed once it is somewhere, so keep trying the
+	// dead-letter topic for as long as it is unavailable
+	for retry := 1; ; retry++ {
+		sendErr := k.deadLetters.Send(ctx, msg, err, attempts)
+		if sendErr == nil {
+			return nil
+		}
+		log.Printf("Failed to dead-letter message at offset %d: %v", msg.Offset, sendErr)
+		if waitErr := k.wait(ctx, retry); waitErr != nil {
+			return waitErr
+		}
+	}
+}
+
+// wait sleeps before the given retry, doubling the backoff each time
+func (k *KafkaConsumer) wait(ctx context.Context, retry int) error {
+	backoff := k.retryBackoff
+	for i := 1; i < retry && backoff < k.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > k.maxRetryBackoff {
+		backoff = k.maxRetryBackoff
+	}
+
+	t := time.NewTimer(backoff)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// handleMessage stores the transaction carried by msg and runs the risk
+// rules over it. A failed assessment fails the message so it is retried;
+// storing and assessing again are both idempotent.
+func (k *KafkaConsumer) handleMessage(msg kafka.Message) error {
+	var transaction model.Transaction
+	if err := json.Unmarshal(msg.Value, &transaction); err != nil {
+		return poisonError{fmt.Errorf("failed to unmarshal transaction: %v", err)}
+	}
+	if transaction.ID == "" {
+		return poisonError{fmt.Errorf("transaction has no id")}
+	}
+
+	// Add transaction to MongoDB
+	id, err := k.txnService.AddTransaction(&transaction)
+	if err != nil {
+		return fmt.Errorf("failed to store transaction: %v", err)
+	}
+
+	alert, err := k.txnService.AssessTransaction(&transaction)
+	if err != nil {
+		return fmt.Errorf("failed to assess transaction: %v", err)
+	}
+	if alert != nil {
+		log.Printf("Risk alert on transaction %s: %s (%s)", id, alert.Outcome, alert.Reason)
+	}
+
+	log.Printf("Successfully processed transaction with ID: %s", id)
+	return nil
 }
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+	"github.com/banking-app/transaction-service/src/config"
+	"github.com/banking-app/transaction-service/src/model"
+	deadLetterService "github.com/banking-app/transaction-service/src/service/deadletter"
+	service "github.com/banking-app/transaction-service/src/service/transaction"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockTransactionService struct {
+	service.TransactionService
+	mock.Mock
+}
+
+func (m *mockTransactionService) AddTransaction(transaction *model.Transaction) (string, error) {
+	args := m.Called(transaction)
+	return transaction.ID, args.Error(0)
+}
+
+func (m *mockTransactionService) AssessTransaction(transaction *model.Transaction) (*model.RiskAlert, error) {
+	args := m.Called(transaction)
+	alert, _ := args.Get(0).(*model.RiskAlert)
+	return alert, args.Error(1)
+}
+
+func TestHandleMessagePreservesProducerFields(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	consumer := &KafkaConsumer{txnService: txnService}
+
+	produced := model.Transaction{
+		ID:        uuid.New().String(),
+		Account:   uuid.New().String(),
+		Amount:    money.New(10000, money.DefaultCurrency),
+		Type:      "credit",
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	value, err := json.Marshal(produced)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got %v", err)
+	}
+
+	txnService.On("AddTransaction", mock.MatchedBy(func(stored *model.Transaction) bool {
+		return stored.ID == produced.ID && stored.Timestamp.Equal(produced.Timestamp)
+	})).Return(nil)
+	txnService.On("AssessTransaction", mock.Anything).Return(nil, nil)
+
+	if err := consumer.handleMessage(kafka.Message{Value: value}); err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	txnService.AssertExpectations(t)
+}
+
+type mockDeadLetterService struct {
+	deadLetterService.DeadLetterService
+	mock.Mock
+}
+
+func (m *mockDeadLetterService) Send(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	args := m.Called(msg.Offset, attempts)
+	return args.Error(0)
+}
+
+func newTestConsumer(txnService *mockTransactionService, deadLetters *mockDeadLetterService) *KafkaConsumer {
+	cfg := &config.Config{}
+	cfg.Kafka.MaxRetries = 2
+	cfg.Kafka.RetryBackoff = 1
+	cfg.Kafka.MaxRetryBackoff = 1
+	return NewKafkaConsumer(cfg, txnService, deadLetters)
+}
+
+func TestProcessRetriesTransientErrors(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	deadLetters := &mockDeadLetterService{}
+
+	value, _ := json.Marshal(model.NewTransaction(uuid.New().String(), money.New(100, money.DefaultCurrency), "debit"))
+	txnService.On("AddTransaction", mock.Anything).Return(errors.New("mongo unavailable")).Once()
+	txnService.On("AddTransaction", mock.Anything).Return(nil).Once()
+	txnService.On("AssessTransaction", mock.Anything).Return(nil, nil)
+
+	err := newTestConsumer(txnService, deadLetters).process(context.Background(), kafka.Message{Value: value})
+
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	txnService.AssertNumberOfCalls(t, "AddTransaction", 2)
+	deadLetters.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestProcessRetriesFailedAssessment(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	deadLetters := &mockDeadLetterService{}
+
+	value, _ := json.Marshal(model.NewTransaction(uuid.New().String(), money.New(100, money.DefaultCurrency), "debit"))
+	txnService.On("AddTransaction", mock.Anything).Return(nil)
+	txnService.On("AssessTransaction", mock.Anything).Return(nil, errors.New("mongo unavailable")).Once()
+	txnService.On("AssessTransaction", mock.Anything).Return(&model.RiskAlert{Outcome: risk.Review}, nil).Once()
+
+	err := newTestConsumer(txnService, deadLetters).process(context.Background(), kafka.Message{Value: value})
+
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	// the transaction is stored again on the retry, which changes nothing
+	txnService.AssertNumberOfCalls(t, "AddTransaction", 2)
+	txnService.AssertNumberOfCalls(t, "AssessTransaction", 2)
+	deadLetters.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestProcessDeadLettersAfterLastRetry(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	deadLetters := &mockDeadLetterService{}
+
+	value, _ := json.Marshal(model.NewTransaction(uuid.New().String(), money.New(100, money.DefaultCurrency), "debit"))
+	txnService.On("AddTransaction", mock.Anything).Return(errors.New("mongo unavailable"))
+	deadLetters.On("Send", int64(7), 3).Return(nil)
+
+	err := newTestConsumer(txnService, deadLetters).process(context.Background(), kafka.Message{Offset: 7, Value: value})
+
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	// the first attempt and two retries
+	txnService.AssertNumberOfCalls(t, "AddTransaction", 3)
+	deadLetters.AssertExpectations(t)
+}
+
+func TestProcessDeadLettersPoisonMessage(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	deadLetters := &mockDeadLetterService{}
+	deadLetters.On("Send", int64(3), 1).Return(nil)
+
+	err := newTestConsumer(txnService, deadLetters).process(context.Background(), kafka.Message{Offset: 3, Value: []byte("not json")})
+
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got %v", err)
+	}
+	txnService.AssertNotCalled(t, "AddTransaction", mock.Anything)
+	deadLetters.AssertExpectations(t)
+}
+
+func TestProcessKeepsMessageWhenCancelled(t *testing.T) {
+
+	txnService := &mockTransactionService{}
+	deadLetters := &mockDeadLetterService{}
+	deadLetters.On("Send", mock.Anything, mock.Anything).Return(errors.New("broker unavailable"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := newTestConsumer(txnService, deadLetters).process(ctx, kafka.Message{Value: []byte("not json")})
+
+	// the message was neither stored nor dead-lettered, so it must not be committed
+	if err == nil {
+		t.Errorf("Expected an error when the message could not be dead-lettered")
+	}
+}
+
+// failingReader fails every fetch, cancelling the context on the last one
+type failingReader struct {
+	fetches int
+	last    int
+	cancel  context.CancelFunc
+}
+
+func (r *failingReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.fetches++
+	if r.fetches == r.last {
+		r.cancel()
+		return kafka.Message{}, ctx.Err()
+	}
+	return kafka.Message{}, errors.New("broker unavailable")
+}
+
+func (r *failingReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return nil
+}
+
+func TestConsumeBacksOffWhileFetchingFails(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Kafka.RetryBackoff = 20
+	cfg.Kafka.MaxRetryBackoff = 40
+	k := NewKafkaConsumer(cfg, &mockTransactionService{}, &mockDeadLetterService{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := &failingReader{last: 4, cancel: cancel}
+
+	// waits of 20, 40 and 40 milliseconds between the four fetches
+	started := time.Now()
+	k.consume(ctx, reader)
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected failed fetches to back off, but 4 fetches took %s", elapsed)
+	}
+	if reader.fetches != 4 {
+		t.Errorf("Expected 4 fetches, but got %d", reader.fetches)
+	}
+}
+
+func TestConsumeStopsBackingOffWhenCancelled(t *testing.T) {
+
+	cfg := &config.Config{}
+	cfg.Kafka.RetryBackoff = int(time.Hour / time.Millisecond)
+	k := NewKafkaConsumer(cfg, &mockTransactionService{}, &mockDeadLetterService{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		k.consume(ctx, &failingReader{cancel: cancel})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the consumer to stop when cancelled during its backoff")
+	}
+}
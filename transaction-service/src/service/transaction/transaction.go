@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
 	"time"
 
 	"github.com/banking-app/transaction-service/src/config"
+	"github.com/banking-app/protos/money"
+	"github.com/banking-app/protos/risk"
+	"github.com/banking-app/riskengine"
 	"github.com/banking-app/transaction-service/src/model"
 	"github.com/google/uuid"
 
@@ -20,10 +24,27 @@ type TransactionService interface {
 	GetTransactionsbyCount(accountId string, count int) ([]model.Transaction, error)
 	GetTransactionbyId(TransactionId string) (model.Transaction, error)
 	AddTransaction(transaction *model.Transaction) (string, error)
+	GetAccountSummary(accountId string) (*model.AccountSummary, error)
+	GetBalanceAsOf(accountId string, asOf time.Time) (*model.AccountBalance, error)
+	SearchTransactions(search *model.TransactionSearch) (*model.TransactionPage, error)
+
+	// Risk methods
+	AssessTransaction(transaction *model.Transaction) (*model.RiskAlert, error)
+	GetRiskAlerts(limit int) ([]model.RiskAlert, error)
 }
 
+// riskHistoryLimit bounds how many of an account's earlier transactions the
+// risk rules are given
+const riskHistoryLimit = 500
+
+// maxHistoryCount is the most transactions GetTransactionsbyCount returns;
+// searches page through more
+const maxHistoryCount = 30
+
 type transactionService struct {
 	db *mongo.Database
+	// risk runs the rules that raise alerts on stored transactions
+	risk *riskengine.Engine
 }
 
 func NewTransactionService(cfg *config.Config) (TransactionService, error) {
@@ -42,24 +63,274 @@ func NewTransactionService(cfg *config.Config) (TransactionService, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
+	riskEngine, err := riskengine.NewEngineFromConfig(cfg.Risk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid risk rules: %w", err)
+	}
+
 	db := client.Database("banking")
 
 	log.Println("Connected to MongoDB")
 
-	return &transactionService{db: db}, nil
+	if err := migrateAmounts(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate transaction amounts: %w", err)
+	}
+
+	return &transactionService{db: db, risk: riskEngine}, nil
+}
+
+// migrateAmounts converts documents written before amounts became fixed-point,
+// where amount was a plain number, into the {minor, currency} form
+func migrateAmounts(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("transactions")
+	filter := bson.M{"amount": bson.M{"$type": "number"}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"amount": bson.M{
+				"minor":    bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0}}},
+				"currency": money.DefaultCurrency,
+			},
+		}}},
+	}
+	res, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("Migrated %d transaction amounts to fixed-point", res.ModifiedCount)
+	}
+	return nil
 }
 
+// AddTransaction stores a transaction, keeping the ID and timestamp given by
+// the producer. The write is an upsert on _id, so storing the same
+// transaction again, e.g. when kafka redelivers it, changes nothing. A
+// reversal also marks the transaction it reverses as reversed by it.
 func (ts *transactionService) AddTransaction(transaction *model.Transaction) (string, error) {
 	collection := ts.db.Collection("transactions")
-	transaction.ID = uuid.New().String()
-	transaction.Timestamp = time.Now()
-	_, err := collection.InsertOne(context.Background(), transaction)
+	if transaction.ID == "" {
+		transaction.ID = uuid.New().String()
+	}
+	if transaction.Timestamp.IsZero() {
+		transaction.Timestamp = time.Now()
+	}
+	filter := bson.M{"_id": transaction.ID}
+	update := bson.M{"$setOnInsert": transaction}
+	_, err := collection.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true))
 	if err != nil {
 		return "", err
 	}
+
+	// the original is on the same account, so it was published, and
+	// stored, before its reversal
+	if transaction.ReversalOf != "" {
+		filter = bson.M{"_id": transaction.ReversalOf}
+		update = bson.M{"$set": bson.M{"reversedBy": transaction.ID}}
+		if _, err = collection.UpdateOne(context.Background(), filter, update); err != nil {
+			return "", fmt.Errorf("failed to mark transaction %s reversed: %w", transaction.ReversalOf, err)
+		}
+	}
 	return transaction.ID, nil
 }
 
+// AssessTransaction runs the risk rules over a stored transaction, judging it
+// against the account's transactions before it, and stores an alert unless
+// they allow it. It returns the alert, or nil if there is none. Storing the
+// alert is an upsert on the transaction ID, so assessing a redelivered
+// transaction again keeps the first alert.
+func (ts *transactionService) AssessTransaction(transaction *model.Transaction) (*model.RiskAlert, error) {
+	if !ts.risk.Enabled() {
+		return nil, nil
+	}
+	ctx := context.Background()
+	collection := ts.db.Collection("transactions")
+
+	activity := riskengine.Activity{
+		Account: transaction.Account,
+		Entry: riskengine.Entry{
+			Amount: transaction.Amount,
+			Debit:  model.IsDebitType(transaction.Type),
+			At:     transaction.Timestamp,
+		},
+	}
+
+	// the account's last transaction before this one, however long ago
+	var last model.Transaction
+	earlier := bson.M{"account": transaction.Account, "_id": bson.M{"$ne": transaction.ID}, "timestamp": bson.M{"$lte": transaction.Timestamp}}
+	latest := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	err := collection.FindOne(ctx, earlier, latest).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("failed to query account activity: %w", err)
+	}
+	activity.LastActivity = last.Timestamp
+
+	earlier["timestamp"] = bson.M{"$gte": transaction.Timestamp.Add(-ts.risk.Lookback()), "$lte": transaction.Timestamp}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(riskHistoryLimit)
+	cursor, err := collection.Find(ctx, earlier, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account history: %w", err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var entry model.Transaction
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		activity.History = append(activity.History, riskengine.Entry{
+			Amount: entry.Amount,
+			Debit:  model.IsDebitType(entry.Type),
+			At:     entry.Timestamp,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	decision := ts.risk.Evaluate(activity)
+	if decision.Outcome == risk.Allow {
+		return nil, nil
+	}
+
+	alert := model.NewRiskAlert(transaction, decision)
+	filter := bson.M{"_id": alert.TransactionID}
+	update := bson.M{"$setOnInsert": alert}
+	_, err = ts.db.Collection("risk_alerts").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store risk alert: %w", err)
+	}
+	return alert, nil
+}
+
+// GetRiskAlerts returns the most recent risk alerts
+func (ts *transactionService) GetRiskAlerts(limit int) ([]model.RiskAlert, error) {
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := ts.db.Collection("risk_alerts").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []model.RiskAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// GetAccountSummary adds up every transaction stored for an account
+func (ts *transactionService) GetAccountSummary(accountId string) (*model.AccountSummary, error) {
+	ctx := context.Background()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetProjection(bson.M{"amount": 1, "type": 1, "timestamp": 1})
+	cursor, err := ts.db.Collection("transactions").Find(ctx, bson.M{"account": accountId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []model.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return model.NewAccountSummary(accountId, transactions)
+}
+
+// GetBalanceAsOf returns an account's balance as of a point in time: the
+// balance after the last transaction posted by then. Transactions published
+// before they carried their balance are added up instead.
+func (ts *transactionService) GetBalanceAsOf(accountId string, asOf time.Time) (*model.AccountBalance, error) {
+	ctx := context.Background()
+	collection := ts.db.Collection("transactions")
+
+	var last model.Transaction
+	filter := bson.M{"account": accountId, "timestamp": bson.M{"$lte": asOf}, "balanceAfter": bson.M{"$exists": true}}
+	latest := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+	err := collection.FindOne(ctx, filter, latest).Decode(&last)
+	if err == nil {
+		return model.NewAccountBalance(accountId, asOf, []model.Transaction{last})
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	filter = bson.M{"account": accountId, "timestamp": bson.M{"$lte": asOf}}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []model.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return model.NewAccountBalance(accountId, asOf, transactions)
+}
+
+// SearchTransactions returns a page of the transactions a search matches,
+// newest first
+func (ts *transactionService) SearchTransactions(search *model.TransactionSearch) (*model.TransactionPage, error) {
+	ctx := context.Background()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(search.Limit + 1))
+	cursor, err := ts.db.Collection("transactions").Find(ctx, searchFilter(search), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []model.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return model.NewTransactionPage(transactions, search.Limit), nil
+}
+
+// searchFilter is the query matching a search. A page after a cursor starts
+// strictly after it in (timestamp, _id) order, so transactions stored while
+// paging neither repeat nor shift the pages.
+func searchFilter(search *model.TransactionSearch) bson.M {
+	conditions := bson.A{}
+	if search.Account != "" {
+		conditions = append(conditions, bson.M{"account": search.Account})
+	}
+	if len(search.Types) > 0 {
+		conditions = append(conditions, bson.M{"type": bson.M{"$in": search.Types}})
+	}
+	if search.Currency != "" {
+		conditions = append(conditions, bson.M{"amount.currency": search.Currency})
+	}
+	if search.MinAmount != nil {
+		conditions = append(conditions, bson.M{"amount.minor": bson.M{"$gte": search.MinAmount.Minor}})
+	}
+	if search.MaxAmount != nil {
+		conditions = append(conditions, bson.M{"amount.minor": bson.M{"$lte": search.MaxAmount.Minor}})
+	}
+	if !search.From.IsZero() {
+		conditions = append(conditions, bson.M{"timestamp": bson.M{"$gte": search.From}})
+	}
+	if !search.To.IsZero() {
+		conditions = append(conditions, bson.M{"timestamp": bson.M{"$lt": search.To}})
+	}
+	if search.Reference != "" {
+		conditions = append(conditions, bson.M{"reference": bson.M{"$regex": regexp.QuoteMeta(search.Reference), "$options": "i"}})
+	}
+	if search.After != nil {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"timestamp": bson.M{"$lt": search.After.Timestamp}},
+			bson.M{"timestamp": search.After.Timestamp, "_id": bson.M{"$lt": search.After.ID}},
+		}})
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
 func (ts *transactionService) GetTransactionbyId(id string) (model.Transaction, error) {
 	collection := ts.db.Collection("transactions")
 	var transaction model.Transaction
@@ -115,7 +386,10 @@ func (ts *transactionService) GetTransactionsbyCount(accountId string, count int
 	collection := ts.db.Collection("transactions")
 	filter := bson.M{"account": accountId}
 
-	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(30)
+	if count < 1 || count > maxHistoryCount {
+		count = maxHistoryCount
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(int64(count))
 
 	cursor, err := collection.Find(context.Background(), filter, opts)
 	if err != nil {
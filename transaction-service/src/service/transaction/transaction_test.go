@@ -6,9 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/banking-app/protos/money"
 	"github.com/banking-app/transaction-service/src/model"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type MockTransactionService struct {
@@ -42,7 +44,7 @@ func TestGetTransactionbyId(t *testing.T) {
 	transaction := model.Transaction{
 		ID:        uuid.New().String(),
 		Account:   uuid.New().String(),
-		Amount:    100,
+		Amount:    money.New(10000, money.DefaultCurrency),
 		Type:      "credit",
 		Timestamp: time.Now(),
 	}
@@ -69,7 +71,7 @@ func TestGetTransactionsbyMonthRange(t *testing.T) {
 	transaction := model.Transaction{
 		ID:        uuid.New().String(),
 		Account:   uuid.New().String(),
-		Amount:    100,
+		Amount:    money.New(10000, money.DefaultCurrency),
 		Type:      "credit",
 		Timestamp: time.Now(),
 	}
@@ -96,7 +98,7 @@ func TestGetTransactionsbyCount(t *testing.T) {
 	transaction := model.Transaction{
 		ID:        uuid.New().String(),
 		Account:   uuid.New().String(),
-		Amount:    100,
+		Amount:    money.New(10000, money.DefaultCurrency),
 		Type:      "credit",
 		Timestamp: time.Now(),
 	}
@@ -115,3 +117,24 @@ func TestGetTransactionsbyCount(t *testing.T) {
 		t.Errorf("Expected error to be nil, but got %v", err)
 	}
 }
+
+func TestSearchFilter(t *testing.T) {
+
+	if filter := searchFilter(&model.TransactionSearch{}); len(filter) != 0 {
+		t.Errorf("Expected an empty search to match everything but got %v", filter)
+	}
+
+	after := &model.Cursor{Timestamp: time.Now(), ID: "b"}
+	filter := searchFilter(&model.TransactionSearch{Account: "account", Reference: "rent (march)", After: after})
+	conditions, ok := filter["$and"].(bson.A)
+	if !ok || len(conditions) != 3 {
+		t.Fatalf("Expected account, reference and cursor conditions but got %v", filter)
+	}
+	reference := conditions[1].(bson.M)["reference"].(bson.M)
+	if reference["$regex"] != `rent \(march\)` || reference["$options"] != "i" {
+		t.Errorf("Expected the reference to be matched literally, ignoring case, but got %v", reference)
+	}
+	if _, ok := conditions[2].(bson.M)["$or"]; !ok {
+		t.Errorf("Expected the page to start after the cursor but got %v", conditions[2])
+	}
+}